@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"saymow/version-manager/app/handlers"
+	"time"
 
 	"github.com/alecthomas/kong"
 )
@@ -10,33 +11,119 @@ var CLI struct {
 	Init struct {
 	} `cmd:"" help:"Initialize a repository in the current directory."`
 	Add struct {
-		Paths []string `arg:"" name:"path" help:"List of files paths." type:"path"`
-	} `cmd:"" help:"Add files to the index."`
+		Paths  []string `arg:"" optional:"" name:"path" help:"List of files paths, directories or globs." type:"path"`
+		All    bool     `short:"A" name:"all" help:"Add every working directory change, including untracked files."`
+		Update bool     `short:"u" name:"update" help:"Add only the changes of tracked files."`
+	} `cmd:"" help:"Add files to the index.\n\nDirectories are added recursively and deleted tracked files are staged for removal. \n Globs (e.g. '**/*.go') are matched against the paths relative to the repository root."`
 	Rm struct {
-		Paths []string `arg:"" name:"path" help:"List of files paths." type:"path"`
+		Paths     []string `arg:"" name:"path" help:"List of files paths, directories or globs." type:"path"`
+		Recursive bool     `short:"r" name:"recursive" help:"Allow removing directories recursively."`
 	} `cmd:"" help:"Remove files from the index and working directory."`
 	Save struct {
-		Message string `short="m" name:"message" help:"Save message."`
+		Message string `short:"m" name:"message" help:"Save message."`
+		Amend   bool   `name:"amend" help:"Replace the HEAD save with the index changes folded in, keeping its message unless one is given."`
 	} `cmd:"" help:"Create a save point with the current index."`
 	Status struct {
 	} `cmd:"" help:"Show the index and working directory status."`
 	Restore struct {
-		Ref  string `optional:"" short="r" default:"HEAD" name:"ref" help:"The Ref or Save hash to restore from. If omitted, HEAD is used."`
+		Ref  string `optional:"" short:"r" default:"HEAD" name:"ref" help:"The revision to restore from. If omitted, HEAD is used."`
 		Path string `arg:"" name:"path" help:"Path to be restored."`
 	} `cmd:"" help:"Restore files from index or file tree.\n\nRestore cover 2 usecases: \n\n 1. Restore HEAD + index (...and remove the index change). \n\n It can be used to restore the current head + index changes. Index changes have higher priorities. \n Initialy Restore will look for your change in the index, if found, the index change is applied. Otherwise, \n Restore will apply the HEAD changes. \n\n 2. Restore Save \n\n It can be used to restore existing Saves to the current working directory. \n\nCaveats: \n\n - Restore will remove the existing changes in the path (forever) and restore reference. \n\n - You can use Restore to recover a deleted file from the index or from a Save. \n\n - The HEAD is not changed during Restore."`
 	Logs struct {
+		Revision string `arg:"" optional:"" name:"revision" help:"Revision or \"from..to\" revision range to log. If omitted, HEAD is used."`
 	} `cmd:"" help:"Show the repository saves logs."`
 	Refs struct {
 	} `cmd:"" help:"Show the repository saves refs."`
+	Reflog struct {
+		Ref string `arg:"" optional:"" name:"ref" help:"Ref name. If omitted, HEAD is used."`
+	} `cmd:"" help:"Show the previous positions of HEAD or a ref.\n\nThe Nth entry can be used as the \"ref@{N}\" revision, e.g. to load a Save left behind."`
 	Ref struct {
 		Name string `short:"n" name:"name" help:"Reference name."`
 	} `cmd:"" help:"Create a reference in the current Save point."`
 	Load struct {
-		Name string `arg:"" name:"name" help:"Reference name or Save hash."`
+		Name string `arg:"" name:"name" help:"Reference name or revision."`
 	} `cmd:"" help:"Load the files tree to the current working directory. HEAD is updated accordingly with name."`
 	Merge struct {
-		Name string `arg:"" name:"name" help:"Reference name."`
-	} `cmd:"" help:"Merge name files tree to the current file tree."`
+		Name     string `arg:"" optional:"" name:"name" help:"Reference name or revision."`
+		Continue bool   `name:"continue" help:"Create the merge save once the conflicts are resolved."`
+		Abort    bool   `name:"abort" help:"Cancel the merge, restoring the ref, the index and the working directory."`
+		Strategy string `name:"strategy" enum:",ours,theirs" default:"" help:"Resolve the files changed on both sides in favor of one side (ours or theirs)."`
+	} `cmd:"" help:"Merge name files tree to the current file tree.\n\nWhen the merge is stopped by conflicts, resolve them and add the files, then run \"vcs merge --continue\" to create the merge save or \"vcs merge --abort\" to cancel the merge."`
+	CherryPick struct {
+		Revisions []string `arg:"" optional:"" name:"save" help:"Saves or revisions to pick, in order."`
+		Continue  bool     `name:"continue" help:"Save the resolved changes and pick the remaining saves."`
+		Abort     bool     `name:"abort" help:"Cancel the cherry-pick, restoring the ref, the index and the working directory."`
+	} `cmd:"" name:"cherry-pick" help:"Apply the changes of saves onto the current ref as new saves.\n\nEach save keeps its message, along with the picked save hash. When the cherry-pick is stopped by conflicts, resolve them and add the files, then run \"vcs cherry-pick --continue\" or \"vcs cherry-pick --abort\"."`
+	Rebase struct {
+		Onto     string `arg:"" optional:"" name:"onto" help:"Reference name or revision to rebase onto."`
+		Plan     string `name:"plan" type:"existingfile" help:"Plan file listing the saves to pick, squash, reword or drop."`
+		Edit     bool   `name:"edit" help:"Edit the plan with $EDITOR before rebasing."`
+		Continue bool   `name:"continue" help:"Save the resolved changes and replay the remaining saves."`
+		Abort    bool   `name:"abort" help:"Cancel the rebase, restoring the ref, the index and the working directory."`
+	} `cmd:"" help:"Replay the current ref saves onto another ref.\n\nThe saves not reachable from onto are replayed in order, merge saves left out. With a plan, each line picks, squashes, rewords or drops a save, e.g. \"reword 3f674c71 New message\". When the rebase is stopped by conflicts, resolve them and add the files, then run \"vcs rebase --continue\" or \"vcs rebase --abort\"."`
+	Reset struct {
+		Revision string `arg:"" name:"save" help:"Save or revision to move the current ref to."`
+		Soft     bool   `name:"soft" help:"Keep the differences staged in the index."`
+		Mixed    bool   `name:"mixed" help:"Keep the differences in the working directory only (default)."`
+		Hard     bool   `name:"hard" help:"Discard the differences, untracked files are kept."`
+	} `cmd:"" help:"Move the current ref to a save.\n\nThe previous save stays in the reflog, run \"vcs reset HEAD@{1}\" to move back."`
+	Revert struct {
+		Revision string `arg:"" name:"save" help:"Save or revision to revert."`
+	} `cmd:"" help:"Create a save undoing the changes of a save.\n\nThe inverse changes are merged with the changes made since the save. When the revert is stopped by conflicts, resolve them, add the files and save."`
+	Resolve struct {
+		Path   string `arg:"" name:"path" help:"Conflicted file path." type:"path"`
+		Ours   bool   `name:"ours" help:"Use the current save content."`
+		Theirs bool   `name:"theirs" help:"Use the merged save content."`
+		Base   bool   `name:"base" help:"Use the content of the common save of both sides."`
+		Union  bool   `name:"union" help:"Keep the lines of both sides, the current save lines first."`
+	} `cmd:"" help:"Resolve a file conflicted by the merge in progress with the content of one side.\n\nWhen the chosen side removed the file, it is deleted and staged for removal."`
+	Show struct {
+		Ref    string `optional:"" short:"r" default:"HEAD" name:"ref" help:"The revision to show the file from. If omitted, HEAD is used."`
+		Path   string `arg:"" name:"path" help:"File path." type:"path"`
+		Ours   bool   `name:"ours" help:"Show the current save content of the merge in progress."`
+		Theirs bool   `name:"theirs" help:"Show the merged save content of the merge in progress."`
+		Base   bool   `name:"base" help:"Show the content of the common save of the merge in progress."`
+	} `cmd:"" help:"Print a file content from a Save or from a side of the merge in progress.\n\nThe content is printed as is, redirect it to a file to retrieve binary files."`
+	Diff struct {
+		Revisions  []string `arg:"" optional:"" name:"revision" help:"Zero, one or two revisions to compare, or a \"from..to\" revision range."`
+		Staged     bool     `name:"staged" help:"Compare the index instead of the working directory."`
+		Stat       bool     `name:"stat" help:"Show a summary of changed lines per file."`
+		NameStatus bool     `name:"name-status" help:"Show only the changed files paths and their status."`
+		Paths      []string `short:"p" name:"path" help:"Restrict the diff to these paths." type:"path"`
+	} `cmd:"" help:"Show line changes between the working directory, the index and Saves.\n\nWithout revisions the index is compared with the working directory, with one revision that revision \n is compared with the working directory and with two revisions they are compared with each other. \n With --staged the index is used in place of the working directory. Untracked files are left out."`
+	Stash struct {
+		Push struct {
+			Message string `short:"m" name:"message" help:"Stash message. If omitted, \"WIP on <HEAD>\" is used."`
+		} `cmd:"" default:"withargs" help:"Stash the index and the working directory changes, untracked files included, and restore HEAD files."`
+		List struct {
+		} `cmd:"" help:"Show the stashes, the newest first."`
+		Show struct {
+			Stash string `arg:"" optional:"" name:"stash" help:"Stash name (stash@{N}) or position (N). If omitted, stash@{0} is used."`
+			Stat  bool   `name:"stat" help:"Show a summary of changed lines per file."`
+		} `cmd:"" help:"Show the changes recorded in a stash."`
+		Apply struct {
+			Stash string `arg:"" optional:"" name:"stash" help:"Stash name (stash@{N}) or position (N). If omitted, stash@{0} is used."`
+		} `cmd:"" help:"Apply a stash on top of HEAD, the stash is kept.\n\nThe stash changes are merged with the changes HEAD made since the stash. When conflicts arise, resolve them and add the files."`
+		Pop struct {
+			Stash string `arg:"" optional:"" name:"stash" help:"Stash name (stash@{N}) or position (N). If omitted, stash@{0} is used."`
+		} `cmd:"" help:"Apply a stash on top of HEAD and drop it, unless it conflicts."`
+		Drop struct {
+			Stash string `arg:"" optional:"" name:"stash" help:"Stash name (stash@{N}) or position (N). If omitted, stash@{0} is used."`
+		} `cmd:"" help:"Remove a stash."`
+	} `cmd:"" help:"Put the index and working directory changes aside and restore them later.\n\nStashes are Saves kept outside of the refs, they can be used as the \"stash@{N}\" revision."`
+	Undo struct {
+	} `cmd:"" help:"Undo the last operation.\n\nHEAD, the refs, the index, the stashes and the working directory files changed by the last save, merge, load, ref, add, rm, restore, resolve, revert, cherry-pick, rebase, reset or stash are restored as they were before it. Working directory changes made since are kept, unless they touch the same files."`
+	Redo struct {
+	} `cmd:"" help:"Redo the last undone operation."`
+	Migrate struct {
+		From string `optional:"" name:"from" help:"Directory the repository was created in, if it was moved." type:"path"`
+	} `cmd:"" help:"Rewrite the saves and the index of an old repository with paths relative to the repository root.\n\nRepositories created by older versions store absolute paths, so they break once moved. \n Saves are renamed, since their names are content hashes."`
+	Gc struct {
+		DryRun      bool          `name:"dry-run" help:"Only show the objects that would be removed."`
+		GracePeriod time.Duration `name:"grace-period" default:"336h" help:"Keep unreachable objects modified within this period."`
+	} `cmd:"" help:"Remove the objects unreachable from the refs, HEAD, the reflogs, the stashes, the operations and the index."`
+	Fsck struct {
+	} `cmd:"" help:"Verify the integrity of the objects, saves, refs, HEAD, reflogs, stashes, operations and index.\n\nCorrupt and missing items are reported with exit code 3, dangling items are only reported."`
 }
 
 func Start() {
@@ -47,24 +134,62 @@ func Start() {
 		handlers.Init()
 	case "status":
 		handlers.ShowStatus()
-	case "logs":
-		handlers.ShowLogs()
+	case "logs", "logs <revision>":
+		handlers.ShowLogs(CLI.Logs.Revision)
 	case "refs":
 		handlers.ShowRefs()
-	case "add <path>":
-		handlers.Add(CLI.Add.Paths)
+	case "reflog", "reflog <ref>":
+		handlers.ShowReflog(CLI.Reflog.Ref)
+	case "add", "add <path>":
+		handlers.Add(CLI.Add.Paths, CLI.Add.All, CLI.Add.Update)
 	case "rm <path>":
-		handlers.Remove(CLI.Rm.Paths)
+		handlers.Remove(CLI.Rm.Paths, CLI.Rm.Recursive)
 	case "save":
-		handlers.Save(CLI.Save.Message)
+		handlers.Save(CLI.Save.Message, CLI.Save.Amend)
 	case "restore <path>":
 		handlers.Restore(CLI.Restore.Path, CLI.Restore.Ref)
 	case "ref":
 		handlers.CreateRef(CLI.Ref.Name)
 	case "load <name>":
 		handlers.Load(CLI.Load.Name)
-	case "merge <name>":
-		handlers.Merge(CLI.Merge.Name)
+	case "merge", "merge <name>":
+		handlers.Merge(CLI.Merge.Name, CLI.Merge.Continue, CLI.Merge.Abort, CLI.Merge.Strategy)
+	case "cherry-pick", "cherry-pick <save>":
+		handlers.CherryPick(CLI.CherryPick.Revisions, CLI.CherryPick.Continue, CLI.CherryPick.Abort)
+	case "rebase", "rebase <onto>":
+		handlers.Rebase(CLI.Rebase.Onto, CLI.Rebase.Plan, CLI.Rebase.Edit, CLI.Rebase.Continue, CLI.Rebase.Abort)
+	case "reset <save>":
+		handlers.Reset(CLI.Reset.Revision, CLI.Reset.Soft, CLI.Reset.Mixed, CLI.Reset.Hard)
+	case "revert <save>":
+		handlers.Revert(CLI.Revert.Revision)
+	case "resolve <path>":
+		handlers.Resolve(CLI.Resolve.Path, CLI.Resolve.Ours, CLI.Resolve.Theirs, CLI.Resolve.Base, CLI.Resolve.Union)
+	case "show <path>":
+		handlers.ShowFile(CLI.Show.Path, CLI.Show.Ref, CLI.Show.Ours, CLI.Show.Theirs, CLI.Show.Base)
+	case "diff", "diff <revision>":
+		handlers.ShowDiff(CLI.Diff.Revisions, CLI.Diff.Staged, CLI.Diff.Stat, CLI.Diff.NameStatus, CLI.Diff.Paths)
+	case "gc":
+		handlers.CollectGarbage(CLI.Gc.DryRun, CLI.Gc.GracePeriod)
+	case "fsck":
+		handlers.CheckIntegrity()
+	case "stash push":
+		handlers.PushStash(CLI.Stash.Push.Message)
+	case "stash list":
+		handlers.ShowStashes()
+	case "stash show", "stash show <stash>":
+		handlers.ShowStash(CLI.Stash.Show.Stash, CLI.Stash.Show.Stat)
+	case "stash apply", "stash apply <stash>":
+		handlers.ApplyStash(CLI.Stash.Apply.Stash, false)
+	case "stash pop", "stash pop <stash>":
+		handlers.ApplyStash(CLI.Stash.Pop.Stash, true)
+	case "stash drop", "stash drop <stash>":
+		handlers.DropStash(CLI.Stash.Drop.Stash)
+	case "undo":
+		handlers.Undo()
+	case "redo":
+		handlers.Redo()
+	case "migrate":
+		handlers.Migrate(CLI.Migrate.From)
 	default:
 		panic(ctx.Command())
 	}
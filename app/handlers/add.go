@@ -2,19 +2,19 @@ package handlers
 
 import (
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
 )
 
-func Add(paths []string) {
+func Add(paths []string, all bool, update bool) {
 	dir, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
 
-	repository := repositories.GetRepository(dir)
+	repository := lockRepository(dir)
+	defer unlockRepository()
 
-	for _, path := range paths {
-		checkError(repository.IndexFile(path))
-	}
+	recordOperation(repository)
+
+	checkError(repository.IndexFiles(paths, &repositories.IndexOptions{All: all, Update: update}))
 
 	checkError(repository.SaveIndex())
 }
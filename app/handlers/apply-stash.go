@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func ApplyStash(stash string, pop bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	conflicts, err := repository.ApplyStash(stash, &repositories.ApplyStashOptions{Pop: pop})
+	checkError(err)
+
+	if len(conflicts) == 0 {
+		if pop {
+			fmt.Println("Stash applied and dropped succesfully.")
+		} else {
+			fmt.Println("Stash applied succesfully.")
+		}
+
+		return
+	}
+
+	// Reload the file tree
+	repository, err = repositories.GetRepository(root)
+	checkError(err)
+
+	status, err := repository.GetStatus()
+	checkError(err)
+
+	fmt.Print("Stash applied with conflicts, resolve them and add the files:\n\n")
+	printStatus(status)
+
+	if pop {
+		fmt.Println("\nThe stash is kept, drop it once the conflicts are resolved.")
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func CheckIntegrity() {
+	root, err := os.Getwd()
+	checkError(err)
+
+	report, err := repositories.Fsck(root)
+	checkError(err)
+
+	for _, issue := range report.Issues {
+		color := "\033[31m"
+		if issue.Type == repositories.DANGLING_ISSUE {
+			color = "\033[33m"
+		}
+
+		fmt.Fprintf(os.Stdout, "%s%s %s\033[0m: %s.\n", color, issue.Type, issue.Item, issue.Message)
+	}
+
+	fmt.Fprintf(os.Stdout, "Checked %d objects and %d saves.\n", report.Objects, report.Saves)
+
+	if report.HasErrors() {
+		os.Exit(EXIT_CORRUPT)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+)
+
+func CherryPick(revisions []string, continueCherryPick bool, abortCherryPick bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	if (len(revisions) > 0) == (continueCherryPick || abortCherryPick) || (continueCherryPick && abortCherryPick) {
+		checkError(&repositories.ValidationError{Message: "use either saves, --continue or --abort."})
+	}
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	var picked []*filesystems.Checkpoint
+	var conflicts []*directories.Change
+
+	switch {
+	case continueCherryPick:
+		picked, conflicts, err = repository.ContinueCherryPick()
+	case abortCherryPick:
+		checkError(repository.AbortCherryPick())
+
+		fmt.Println("Cherry-pick aborted.")
+
+		return
+	default:
+		picked, conflicts, err = repository.CherryPick(revisions)
+	}
+	checkError(err)
+
+	for _, save := range picked {
+		fmt.Printf("Save %s created succesfully: %s\n", save.Id, save.Subject())
+	}
+
+	if len(conflicts) == 0 {
+		fmt.Println("Cherry-pick completed succesfully.")
+
+		return
+	}
+
+	// Reload the file tree
+	repository, err = repositories.GetRepository(root)
+	checkError(err)
+
+	status, err := repository.GetStatus()
+	checkError(err)
+
+	fmt.Print("Cherry-pick stopped by conflicts:\n\n")
+	printStatus(status)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+	"time"
+)
+
+func CollectGarbage(dryRun bool, gracePeriod time.Duration) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	garbageCollection, err := repository.CollectGarbage(&repositories.GarbageCollectionOptions{DryRun: dryRun, GracePeriod: gracePeriod})
+	checkError(err)
+
+	if dryRun {
+		for _, name := range garbageCollection.Pruned {
+			fmt.Fprintf(os.Stdout, "\033[31mWould remove %s\033[0m\n", name)
+		}
+		fmt.Fprintf(os.Stdout, "%d objects would be removed, %d kept by the grace period, %d reachable.\n", len(garbageCollection.Pruned), len(garbageCollection.Kept), garbageCollection.Reachable)
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%d objects removed, %d kept by the grace period, %d reachable.\n", len(garbageCollection.Pruned), len(garbageCollection.Kept), garbageCollection.Reachable)
+}
@@ -2,15 +2,16 @@ package handlers
 
 import (
 	"os"
-	"saymow/version-manager/app/pkg/errors"
-	"saymow/version-manager/app/repositories"
 )
 
 func CreateRef(name string) {
 	root, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
 
-	repository := repositories.GetRepository(root)
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
 
 	checkError(repository.CreateRef(name))
 }
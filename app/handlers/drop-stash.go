@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+)
+
+func DropStash(stash string) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	id, err := repository.DropStash(stash)
+	checkError(err)
+
+	fmt.Printf("Dropped stash %s.\n", id)
+}
@@ -1,21 +1,113 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"strings"
 )
 
+// Exit codes, scripts can rely on them to tell failures apart.
+const (
+	EXIT_VALIDATION     = 1
+	EXIT_NOT_REPOSITORY = 2
+	EXIT_CORRUPT        = 3
+	EXIT_PERMISSION     = 4
+	EXIT_LOCKED         = 5
+	EXIT_UNEXPECTED     = 70
+)
+
+// Repository locked by the running command.
+//
+// Deferred calls do not run on os.Exit, so checkError releases the lock before exiting.
+var lockedRepository *repositories.Repository
+
+func lockRepository(root string) *repositories.Repository {
+	repository, err := repositories.LockRepository(root)
+	checkError(err)
+
+	lockedRepository = repository
+
+	return repository
+}
+
+// Record the running command as an operation, so "vcs undo" can revert it.
+//
+// The operation is recorded when the lock is released, unless the command failed.
+func recordOperation(repository *repositories.Repository) {
+	checkError(repository.BeginOperation(strings.Join(os.Args[1:], " ")))
+}
+
+func unlockRepository() {
+	if lockedRepository != nil {
+		if err := lockedRepository.EndOperation(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: the operation could not be recorded: %s\n", err)
+		}
+
+		lockedRepository.Unlock()
+		lockedRepository = nil
+	}
+}
+
+func exitWithError(code int, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(code)
+}
+
 func checkError(err error) {
 	if err == nil {
 		return
 	}
 
-	if _, ok := err.(*repositories.ValidationError); ok {
-		fmt.Println(err.Error())
-		os.Exit(1)
+	// A command failing halfway is not recorded, since undoing it would not restore a consistent state
+	if lockedRepository != nil {
+		lockedRepository.CancelOperation()
 	}
+	unlockRepository()
+
+	var validationErr *repositories.ValidationError
+	var notRepositoryErr *filesystems.NotRepositoryError
+	var corruptSaveErr *filesystems.CorruptSaveError
+	var corruptIndexErr *filesystems.CorruptIndexError
+	var corruptRefsErr *filesystems.CorruptRefsError
+	var corruptMergeErr *filesystems.CorruptMergeError
+	var corruptCherryPickErr *filesystems.CorruptCherryPickError
+	var corruptRebaseErr *filesystems.CorruptRebaseError
+	var corruptReflogErr *filesystems.CorruptReflogError
+	var corruptOperationErr *filesystems.CorruptOperationError
+	var corruptStashErr *filesystems.CorruptStashError
+	var corruptObjectErr *filesystems.CorruptObjectError
+	var missingObjectErr *filesystems.MissingObjectError
+	var missingSaveErr *filesystems.MissingSaveError
+	var lockHeldErr *filesystems.LockHeldError
 
-	errors.Error("unexpected error")
+	switch {
+	case errors.As(err, &validationErr):
+		fmt.Println(err.Error())
+		os.Exit(EXIT_VALIDATION)
+	case errors.As(err, &notRepositoryErr):
+		exitWithError(EXIT_NOT_REPOSITORY, "Error: %s, run \"vcs init\" to create one.", err)
+	case errors.As(err, &corruptSaveErr),
+		errors.As(err, &corruptIndexErr),
+		errors.As(err, &corruptRefsErr),
+		errors.As(err, &corruptMergeErr),
+		errors.As(err, &corruptCherryPickErr),
+		errors.As(err, &corruptRebaseErr),
+		errors.As(err, &corruptReflogErr),
+		errors.As(err, &corruptOperationErr),
+		errors.As(err, &corruptStashErr),
+		errors.As(err, &corruptObjectErr),
+		errors.As(err, &missingObjectErr),
+		errors.As(err, &missingSaveErr):
+		exitWithError(EXIT_CORRUPT, "Error: %s, run \"vcs fsck\" to check the repository integrity.", err)
+	case errors.As(err, &lockHeldErr):
+		exitWithError(EXIT_LOCKED, "Error: %s, wait for the other command to finish.", err)
+	case errors.Is(err, fs.ErrPermission):
+		exitWithError(EXIT_PERMISSION, "Error: %s, check the file permissions.", err)
+	default:
+		exitWithError(EXIT_UNEXPECTED, "Unexpected Error: %s.", err)
+	}
 }
@@ -2,13 +2,13 @@ package handlers
 
 import (
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
 )
 
 func Init() {
 	currentDir, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
 
-	repositories.CreateRepository(currentDir)
+	_, err = repositories.CreateRepository(currentDir)
+	checkError(err)
 }
@@ -2,14 +2,16 @@ package handlers
 
 import (
 	"os"
-	"saymow/version-manager/app/pkg/errors"
-	"saymow/version-manager/app/repositories"
 )
 
 func Load(name string) {
 	root, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
 
-	repository := repositories.GetRepository(root)
 	checkError(repository.Load(name))
 }
@@ -3,28 +3,54 @@ package handlers
 import (
 	"fmt"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
 )
 
-func Merge(name string) {
+func Merge(name string, continueMerge bool, abortMerge bool, strategy string) {
 	root, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
+
+	if (name != "") == (continueMerge || abortMerge) || (continueMerge && abortMerge) {
+		checkError(&repositories.ValidationError{Message: "use either a ref name, --continue or --abort."})
+	}
 
-	repository := repositories.GetRepository(root)
-	_, err = repository.Merge(name)
-	errors.Check(err)
+	repository := lockRepository(root)
+	defer unlockRepository()
 
-	// Reload the file tree
-	repository = repositories.GetRepository(root)
-	status := repository.GetStatus()
+	recordOperation(repository)
 
-	fmt.Printf("Ref \"%s\" merged succesfully.\n", name)
+	switch {
+	case continueMerge:
+		save, err := repository.ContinueMerge()
+		checkError(err)
 
-	if status.HasChanges() {
-		fmt.Print("But you have conflicts to resolve:\n\n")
-		printStatus(status)
+		fmt.Printf("Merge save %s created succesfully.\n", save.Id)
+
+		return
+	case abortMerge:
+		checkError(repository.AbortMerge())
+
+		fmt.Println("Merge aborted.")
+
+		return
 	}
 
+	_, err = repository.Merge(name, &repositories.MergeOptions{Strategy: repositories.MergeStrategy(strategy)})
+	checkError(err)
+
+	// Reload the file tree
+	repository, err = repositories.GetRepository(root)
+	checkError(err)
+
+	status, err := repository.GetStatus()
 	checkError(err)
+
+	if status.Merge == nil {
+		fmt.Printf("Ref \"%s\" merged succesfully.\n", name)
+
+		return
+	}
+
+	fmt.Print("Merge stopped by conflicts:\n\n")
+	printStatus(status)
 }
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func Migrate(from string) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	migrated, err := repositories.MigrateRepository(root, from)
+	checkError(err)
+
+	fmt.Printf("Migrated %d saves.\n", migrated)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+)
+
+func PushStash(message string) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	stash, err := repository.PushStash(message)
+	checkError(err)
+
+	fmt.Printf("Saved working directory and index as stash@{0}: %s\n", stash.Message)
+}
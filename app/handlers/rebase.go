@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"saymow/version-manager/app/repositories"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"strings"
+)
+
+const REBASE_PLAN_HELP = `
+# Rebase plan, one save per line, applied from top to bottom:
+#
+#   pick <save>              replay the save
+#   reword <save> <message>  replay the save with a new message
+#   squash <save>            meld the save into the previous one
+#   drop <save>              leave the save out
+#
+# Saves left out of the plan are dropped, an empty plan cancels the rebase.
+`
+
+func readRebasePlan(reader io.Reader) []*filesystems.RebaseStep {
+	plan, err := filesystems.ParseRebasePlan(reader)
+	if err != nil {
+		checkError(&repositories.ValidationError{Message: fmt.Sprintf("invalid rebase plan, %s.", err)})
+	}
+	if len(plan) == 0 {
+		checkError(&repositories.ValidationError{Message: "empty rebase plan, nothing rebased."})
+	}
+
+	return plan
+}
+
+// Write the default plan to a temporary file and let the user edit it with $EDITOR.
+func editRebasePlan(repository *repositories.Repository, onto string) []*filesystems.RebaseStep {
+	plan, err := repository.GetRebasePlan(onto)
+	checkError(err)
+
+	file, err := os.CreateTemp("", "vcs-rebase-plan-*")
+	checkError(err)
+
+	for _, step := range plan {
+		_, err = fmt.Fprintln(file, filesystems.FormatRebaseStep(step))
+		checkError(err)
+	}
+	_, err = file.WriteString(REBASE_PLAN_HELP)
+	checkError(err)
+	checkError(file.Close())
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	content, readErr := os.ReadFile(file.Name())
+	os.Remove(file.Name())
+
+	if err != nil {
+		checkError(&repositories.ValidationError{Message: fmt.Sprintf("editor failed, %s.", err)})
+	}
+	checkError(readErr)
+
+	return readRebasePlan(bytes.NewReader(content))
+}
+
+func Rebase(onto string, planPath string, edit bool, continueRebase bool, abortRebase bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	if (onto != "") == (continueRebase || abortRebase) || (continueRebase && abortRebase) {
+		checkError(&repositories.ValidationError{Message: "use either a ref name, --continue or --abort."})
+	}
+	if (planPath != "" || edit) && onto == "" {
+		checkError(&repositories.ValidationError{Message: "--plan and --edit need a ref name."})
+	}
+	if planPath != "" && edit {
+		checkError(&repositories.ValidationError{Message: "use either --plan or --edit."})
+	}
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	var plan []*filesystems.RebaseStep
+
+	switch {
+	case planPath != "":
+		file, err := os.Open(planPath)
+		checkError(err)
+		defer file.Close()
+
+		plan = readRebasePlan(file)
+	case edit:
+		plan = editRebasePlan(repository, onto)
+	}
+
+	recordOperation(repository)
+
+	var replayed []*filesystems.Checkpoint
+	var conflicts []*directories.Change
+
+	switch {
+	case continueRebase:
+		replayed, conflicts, err = repository.ContinueRebase()
+	case abortRebase:
+		checkError(repository.AbortRebase())
+
+		fmt.Println("Rebase aborted.")
+
+		return
+	default:
+		replayed, conflicts, err = repository.Rebase(onto, plan)
+	}
+	checkError(err)
+
+	for _, save := range replayed {
+		fmt.Printf("Save %s created succesfully: %s\n", save.Id, save.Subject())
+	}
+
+	if len(conflicts) == 0 {
+		fmt.Println("Rebase completed succesfully.")
+
+		return
+	}
+
+	// Reload the file tree
+	repository, err = repositories.GetRepository(root)
+	checkError(err)
+
+	status, err := repository.GetStatus()
+	checkError(err)
+
+	fmt.Print("Rebase stopped by conflicts:\n\n")
+	printStatus(status)
+}
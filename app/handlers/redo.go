@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+)
+
+func Redo() {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	operation, err := repository.RedoOperation()
+	checkError(err)
+
+	fmt.Printf("Redone \"%s\".\n", operation.Command)
+}
@@ -2,19 +2,18 @@ package handlers
 
 import (
 	"os"
-	"saymow/version-manager/app/pkg/errors"
-	"saymow/version-manager/app/repositories"
 )
 
-func Remove(paths []string) {
+func Remove(paths []string, recursive bool) {
 	dir, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
 
-	repository := repositories.GetRepository(dir)
+	repository := lockRepository(dir)
+	defer unlockRepository()
 
-	for _, path := range paths {
-		checkError(repository.RemoveFile(path))
-	}
+	recordOperation(repository)
+
+	checkError(repository.RemoveFiles(paths, recursive))
 
 	checkError(repository.SaveIndex())
 }
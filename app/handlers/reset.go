@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func Reset(revision string, soft bool, mixed bool, hard bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	modes := []repositories.ResetMode{}
+	for mode, chosen := range map[repositories.ResetMode]bool{
+		repositories.RESET_SOFT:  soft,
+		repositories.RESET_MIXED: mixed,
+		repositories.RESET_HARD:  hard,
+	} {
+		if chosen {
+			modes = append(modes, mode)
+		}
+	}
+	if len(modes) > 1 {
+		checkError(&repositories.ValidationError{Message: "use one of --soft, --mixed or --hard."})
+	}
+
+	mode := repositories.RESET_MIXED
+	if len(modes) == 1 {
+		mode = modes[0]
+	}
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	entry, err := repository.Reset(revision, mode)
+	checkError(err)
+
+	fmt.Printf("Ref moved from %s to %s.\n", entry.OldId, entry.NewId)
+	fmt.Println("The previous save is kept in the reflog, run \"vcs reset HEAD@{1}\" to move back.")
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func Resolve(path string, ours bool, theirs bool, base bool, union bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	sides := []repositories.ResolveSide{}
+	for side, chosen := range map[repositories.ResolveSide]bool{
+		repositories.RESOLVE_OURS:   ours,
+		repositories.RESOLVE_THEIRS: theirs,
+		repositories.RESOLVE_BASE:   base,
+		repositories.RESOLVE_UNION:  union,
+	} {
+		if chosen {
+			sides = append(sides, side)
+		}
+	}
+	if len(sides) != 1 {
+		checkError(&repositories.ValidationError{Message: "use one of --ours, --theirs, --base or --union."})
+	}
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	checkError(repository.ResolveFile(path, sides[0]))
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func Revert(revision string) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	recordOperation(repository)
+
+	save, conflicts, err := repository.Revert(revision)
+	checkError(err)
+
+	if len(conflicts) == 0 {
+		fmt.Printf("Revert save %s created succesfully.\n", save.Id)
+
+		return
+	}
+
+	// Reload the file tree
+	repository, err = repositories.GetRepository(root)
+	checkError(err)
+
+	status, err := repository.GetStatus()
+	checkError(err)
+
+	fmt.Print("Revert stopped by conflicts, resolve them, add the files and save:\n\n")
+	printStatus(status)
+}
@@ -2,15 +2,21 @@ package handlers
 
 import (
 	"os"
-	"saymow/version-manager/app/pkg/errors"
-	"saymow/version-manager/app/repositories"
 )
 
-func Save(message string) {
+func Save(message string, amend bool) {
 	dir, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
+
+	repository := lockRepository(dir)
+	defer unlockRepository()
+
+	recordOperation(repository)
 
-	repository := repositories.GetRepository(dir)
-	_, err = repository.CreateSave(message)
+	if amend {
+		_, err = repository.AmendSave(message)
+	} else {
+		_, err = repository.CreateSave(message)
+	}
 	checkError(err)
 }
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories"
+	"saymow/version-manager/app/repositories/diffs"
+	"saymow/version-manager/app/repositories/directories"
+	"strings"
+)
+
+const DIFF_STAT_WIDTH = 40
+
+func relativePath(root, filepath string) string {
+	relativePath, err := Path.Rel(root, filepath)
+	checkError(err)
+
+	return Path.ToSlash(relativePath)
+}
+
+func printHunkLine(color, prefix, line string) {
+	fmt.Fprintf(os.Stdout, "%s%s%s\033[0m\n", color, prefix, strings.TrimSuffix(line, "\n"))
+
+	if !strings.HasSuffix(line, "\n") {
+		fmt.Fprintln(os.Stdout, "\\ No newline at end of file")
+	}
+}
+
+func printUnifiedDiff(root string, diff *repositories.Diff) {
+	for _, fileDiff := range diff.Files {
+		path := relativePath(root, fileDiff.Filepath)
+		from, to := "a/"+path, "b/"+path
+
+		switch fileDiff.ChangeType {
+		case directories.Creation:
+			from = "/dev/null"
+		case directories.Removal:
+			to = "/dev/null"
+		}
+
+		fmt.Fprintf(os.Stdout, "\033[1mdiff a/%s b/%s\n", path, path)
+		fmt.Fprintf(os.Stdout, "--- %s\n", from)
+		fmt.Fprintf(os.Stdout, "+++ %s\033[0m\n", to)
+
+		if fileDiff.Binary {
+			fmt.Fprintf(os.Stdout, "Binary files %s and %s differ (%d -> %d bytes)\n", from, to, fileDiff.OldSize, fileDiff.NewSize)
+			continue
+		}
+
+		for _, hunk := range fileDiff.Hunks {
+			oldStart, newStart := hunk.OldStart, hunk.NewStart
+
+			// Empty ranges start at the line before the change.
+			if hunk.OldLines == 0 {
+				oldStart--
+			}
+			if hunk.NewLines == 0 {
+				newStart--
+			}
+
+			fmt.Fprintf(os.Stdout, "\033[36m@@ -%d,%d +%d,%d @@\033[0m\n", oldStart, hunk.OldLines, newStart, hunk.NewLines)
+
+			for _, edit := range hunk.Edits {
+				switch edit.Operation {
+				case diffs.Equal:
+					printHunkLine("", " ", edit.Line)
+				case diffs.Deletion:
+					printHunkLine("\033[31m", "-", edit.Line)
+				case diffs.Insertion:
+					printHunkLine("\033[32m", "+", edit.Line)
+				}
+			}
+		}
+	}
+}
+
+func printDiffStat(root string, diff *repositories.Diff) {
+	pathWidth := 0
+	maxChanges := 0
+	insertions, deletions := 0, 0
+
+	for _, fileDiff := range diff.Files {
+		pathWidth = max(pathWidth, len(relativePath(root, fileDiff.Filepath)))
+		maxChanges = max(maxChanges, fileDiff.Insertions+fileDiff.Deletions)
+	}
+
+	for _, fileDiff := range diff.Files {
+		if fileDiff.Binary {
+			fmt.Fprintf(os.Stdout, " %-*s | Bin %d -> %d bytes\n", pathWidth, relativePath(root, fileDiff.Filepath), fileDiff.OldSize, fileDiff.NewSize)
+			continue
+		}
+
+		changes := fileDiff.Insertions + fileDiff.Deletions
+		plus, minus := fileDiff.Insertions, fileDiff.Deletions
+
+		if maxChanges > DIFF_STAT_WIDTH {
+			// Scale the graph down to fit the terminal
+			plus = plus * DIFF_STAT_WIDTH / maxChanges
+			minus = minus * DIFF_STAT_WIDTH / maxChanges
+		}
+
+		fmt.Fprintf(
+			os.Stdout,
+			" %-*s | %d \033[32m%s\033[31m%s\033[0m\n",
+			pathWidth,
+			relativePath(root, fileDiff.Filepath),
+			changes,
+			strings.Repeat("+", plus),
+			strings.Repeat("-", minus),
+		)
+
+		insertions += fileDiff.Insertions
+		deletions += fileDiff.Deletions
+	}
+
+	fmt.Fprintf(os.Stdout, " %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n", len(diff.Files), insertions, deletions)
+}
+
+func printDiffNameStatus(root string, diff *repositories.Diff) {
+	for _, fileDiff := range diff.Files {
+		var status string
+
+		switch fileDiff.ChangeType {
+		case directories.Creation:
+			status = "A"
+		case directories.Removal:
+			status = "D"
+		default:
+			status = "M"
+		}
+
+		fmt.Fprintf(os.Stdout, "%s\t%s\n", status, relativePath(root, fileDiff.Filepath))
+	}
+}
+
+func ShowDiff(revisions []string, staged, stat, nameStatus bool, paths []string) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
+
+	diff, err := repository.Diff(&repositories.DiffOptions{
+		Revisions: revisions,
+		Staged:    staged,
+		Paths:     paths,
+	})
+	checkError(err)
+
+	if len(diff.Files) == 0 {
+		fmt.Println("No changes to show.")
+
+		return
+	}
+
+	switch {
+	case stat:
+		printDiffStat(root, diff)
+	case nameStatus:
+		printDiffNameStatus(root, diff)
+	default:
+		printUnifiedDiff(root, diff)
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"io"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func ShowFile(path string, ref string, ours bool, theirs bool, base bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	options := &repositories.ShowOptions{Ref: ref}
+	sides := 0
+
+	for side, chosen := range map[repositories.ResolveSide]bool{
+		repositories.RESOLVE_OURS:   ours,
+		repositories.RESOLVE_THEIRS: theirs,
+		repositories.RESOLVE_BASE:   base,
+	} {
+		if chosen {
+			options.Side = side
+			sides++
+		}
+	}
+	if sides > 1 {
+		checkError(&repositories.ValidationError{Message: "use only one of --ours, --theirs or --base."})
+	}
+
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
+
+	reader, err := repository.ShowFile(path, options)
+	checkError(err)
+	defer reader.Close()
+
+	// The content is written as is, so binary files can be redirected to a file.
+	_, err = io.Copy(os.Stdout, reader)
+	checkError(err)
+}
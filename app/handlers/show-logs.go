@@ -3,19 +3,21 @@ package handlers
 import (
 	"fmt"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
 )
 
 // Wed, Nov 18, 2024, 2:35 PM
 const DATE_LAYOUT = "Mon, Jan 06, 2006, 3:04 PM"
 
-func ShowLogs() {
+func ShowLogs(revision string) {
 	root, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
 
-	repository := repositories.GetRepository(root)
-	log := repository.GetLogs()
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
+
+	log, err := repository.GetLogs(revision)
+	checkError(err)
 
 	if len(log.History) == 0 {
 		fmt.Println("Empty saves history.")
@@ -48,7 +50,7 @@ func ShowLogs() {
 			fmt.Fprint(os.Stdout, ")")
 		}
 
-		fmt.Fprintf(os.Stdout, "\033[0m %s ", saveLog.Checkpoint.Message)
+		fmt.Fprintf(os.Stdout, "\033[0m %s ", saveLog.Checkpoint.Subject())
 		fmt.Fprintf(os.Stdout, "\033[32m %s\n", saveLog.Checkpoint.CreatedAt.Format(DATE_LAYOUT))
 	}
 }
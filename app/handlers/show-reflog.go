@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func ShowReflog(ref string) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
+
+	reflog, err := repository.GetReflog(ref)
+	checkError(err)
+
+	if len(reflog.Entries) == 0 {
+		fmt.Println("Empty reflog.")
+
+		return
+	}
+
+	for idx, entry := range reflog.Entries {
+		fmt.Fprintf(os.Stdout, "\033[33m %s ", entry.NewId)
+		fmt.Fprintf(os.Stdout, "\033[34m%s@{%d}:", reflog.Name, idx)
+		fmt.Fprintf(os.Stdout, "\033[0m %s ", entry.Command)
+		fmt.Fprintf(os.Stdout, "\033[32m %s\n", entry.CreatedAt.Local().Format(DATE_LAYOUT))
+	}
+}
@@ -3,15 +3,16 @@ package handlers
 import (
 	"fmt"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
 )
 
 func ShowRefs() {
 	root, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
+
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
 
-	repository := repositories.GetRepository(root)
 	refs := repository.GetRefs()
 
 	for name, saveName := range refs.Refs {
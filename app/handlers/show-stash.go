@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func ShowStash(stash string, stat bool) {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
+
+	checkpoint, err := repository.GetStash(stash)
+	checkError(err)
+
+	// The stash changes are relative to the save it was pushed on
+	diff, err := repository.Diff(&repositories.DiffOptions{
+		Revisions: []string{checkpoint.FirstParent(), checkpoint.Id},
+	})
+	checkError(err)
+
+	if len(diff.Files) == 0 {
+		fmt.Println("No changes to show.")
+
+		return
+	}
+
+	if stat {
+		printDiffStat(root, diff)
+
+		return
+	}
+
+	printUnifiedDiff(root, diff)
+}
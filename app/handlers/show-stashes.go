@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/repositories"
+)
+
+func ShowStashes() {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository, err := repositories.GetRepository(root)
+	checkError(err)
+
+	stashes, err := repository.GetStashes()
+	checkError(err)
+
+	if len(stashes) == 0 {
+		fmt.Println("No stashes.")
+
+		return
+	}
+
+	for idx, stash := range stashes {
+		fmt.Fprintf(os.Stdout, "\033[33m %s ", stash.Id)
+		fmt.Fprintf(os.Stdout, "\033[34mstash@{%d}:", idx)
+		fmt.Fprintf(os.Stdout, "\033[0m %s ", stash.Message)
+		fmt.Fprintf(os.Stdout, "\033[32m %s\n", stash.CreatedAt.Local().Format(DATE_LAYOUT))
+	}
+}
@@ -3,11 +3,28 @@ package handlers
 import (
 	"fmt"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories"
 )
 
 func printStatus(status *repositories.Status) {
+	if status.Merge != nil {
+		fmt.Printf("Merging \"%s\" at \"%s\".\n", status.Merge.Incoming, status.Merge.Ref)
+		fmt.Print("Resolve the conflicts with \"vcs resolve\" or by editing and adding the files, then run \"vcs merge --continue\" (or \"vcs merge --abort\").\n\n")
+	}
+	if status.CherryPick != nil {
+		fmt.Printf("Cherry-picking save %s at \"%s\", %d save(s) left.\n", status.CherryPick.Current, status.CherryPick.Ref, len(status.CherryPick.Pending))
+		fmt.Print("Resolve the conflicts by editing and adding the files, then run \"vcs cherry-pick --continue\" (or \"vcs cherry-pick --abort\").\n\n")
+	}
+	if status.Rebase != nil {
+		current := ""
+		if status.Rebase.Current != nil {
+			current = status.Rebase.Current.Id
+		}
+
+		fmt.Printf("Rebasing \"%s\" onto %s, stopped at save %s, %d save(s) left.\n", status.Rebase.Ref, status.Rebase.Onto, current, len(status.Rebase.Pending))
+		fmt.Print("Resolve the conflicts by editing and adding the files, then run \"vcs rebase --continue\" (or \"vcs rebase --abort\").\n\n")
+	}
+
 	stagedChangesCount := len(status.Staged.ConflictedFilesPaths) +
 		len(status.Staged.CreatedFilesPaths) +
 		len(status.Staged.ModifiedFilePaths) +
@@ -54,9 +71,13 @@ func printStatus(status *repositories.Status) {
 
 func ShowStatus() {
 	dir, err := os.Getwd()
-	errors.Check(err)
+	checkError(err)
+
+	repository, err := repositories.GetRepository(dir)
+	checkError(err)
+
+	status, err := repository.GetStatus()
+	checkError(err)
 
-	repository := repositories.GetRepository(dir)
-	status := repository.GetStatus()
 	printStatus(status)
 }
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+)
+
+func Undo() {
+	root, err := os.Getwd()
+	checkError(err)
+
+	repository := lockRepository(root)
+	defer unlockRepository()
+
+	operation, err := repository.UndoOperation()
+	checkError(err)
+
+	fmt.Printf("Undone \"%s\".\n", operation.Command)
+}
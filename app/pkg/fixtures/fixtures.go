@@ -63,7 +63,7 @@ func WriteFile(filepath string, content []byte) {
 
 func MakeDirs(filepaths ...string) {
 	for _, filepath := range filepaths {
-		err := os.Mkdir(filepath, 0644)
+		err := os.Mkdir(filepath, 0755)
 		errors.Check(err)
 	}
 }
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/repositories/filesystems"
+)
+
+// AmendSave replaces the HEAD save with a save having the index changes folded in, with the same parents.
+//
+// The HEAD save message is kept, unless a message is given. The replaced save is left out of the ref
+// history, it can still be found through the reflog.
+func (repository *Repository) AmendSave(message string) (*filesystems.Checkpoint, error) {
+	if repository.isDetachedMode() {
+		return nil, &ValidationError{"cannot make changes in detached mode."}
+	}
+	if repository.hasEmptySaveHistory() {
+		return nil, &ValidationError{"cannot amend without saves history."}
+	}
+	if repository.isIndexConflicted() {
+		return nil, &ValidationError{"index is conflicted."}
+	}
+	if len(repository.index) == 0 && message == "" {
+		return nil, &ValidationError{"nothing to amend, the index is empty."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := repository.fs.ReadCheckpoint(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, err
+	}
+	if message == "" {
+		message = checkpoint.Message
+	}
+
+	dir, err := repository.getStagedDir()
+	if err != nil {
+		return nil, err
+	}
+	parentDir, err := repository.fs.ReadDir(checkpoint.FirstParent())
+	if err != nil {
+		return nil, err
+	}
+
+	// The changes are relative to the first parent, so they include the amended save changes
+	amendedCheckpoint, err := repository.writeAppliedSave(checkpoint.Parents, dir, diffDirs(&parentDir, dir), message, fmt.Sprintf("save (amend): %s", message))
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.clearIndex(); err != nil {
+		return nil, err
+	}
+
+	return amendedCheckpoint, nil
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidAmendSave(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	_, err := repository.AmendSave("message")
+	assert.EqualError(t, err, "Validation Error: cannot amend without saves history.")
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, err = repository.AmendSave("")
+	assert.EqualError(t, err, "Validation Error: nothing to amend, the index is empty.")
+}
+
+func TestAmendSave(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	s0, _ := repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content (v2)."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	s1, _ := repository.CreateSave("s1")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Check the index is folded into the HEAD save, keeping its message
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+
+	amended, err := repository.AmendSave("")
+	assert.Nil(t, err)
+	assert.Equal(t, amended.Message, "s1")
+	assert.Equal(t, amended.Parents, []string{s0.Id})
+	assert.Equal(t, len(amended.Changes), 2)
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), amended.Id)
+	assert.Equal(t, len(repository.index), 0)
+	assert.Equal(t, repository.findSavedFile(dir.Join("2.txt")).Filepath, dir.Join("2.txt"))
+
+	reflog, _ := repository.GetReflog(filesystems.INITIAL_REF_NAME)
+	assert.Equal(t, reflog.Entries[0].Command, "save (amend): s1")
+	assert.Equal(t, reflog.Entries[0].OldId, s1.Id)
+
+	// Check the message is replaced without index changes
+	amended, err = repository.AmendSave("s1 and 2.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, amended.Message, "s1 and 2.txt")
+	assert.Equal(t, amended.Parents, []string{s0.Id})
+
+	// Check changes reverting the HEAD save changes are left out
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.RemoveFile("2.txt")
+	repository.SaveIndex()
+
+	amended, err = repository.AmendSave("")
+	assert.Nil(t, err)
+	assert.Equal(t, len(amended.Changes), 1)
+	assert.Equal(t, amended.Changes[0].ChangeType, directories.Modification)
+}
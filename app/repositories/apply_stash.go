@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+type ApplyStashOptions struct {
+	// Drop the stash once applied, unless it conflicts.
+	Pop bool
+}
+
+// The files a stash changed, the index changes included.
+func getStashedFilepaths(baseDir, indexDir, stashDir *directories.Dir) []string {
+	baseFiles, indexFiles, stashFiles := getDirFilesMap(baseDir), getDirFilesMap(indexDir), getDirFilesMap(stashDir)
+	filepaths := make(map[string]bool)
+
+	for _, files := range []map[string]*directories.File{baseFiles, indexFiles, stashFiles} {
+		for filepath := range files {
+			baseHash := getFileHash(baseFiles[filepath])
+
+			if baseHash != getFileHash(indexFiles[filepath]) || baseHash != getFileHash(stashFiles[filepath]) {
+				filepaths[filepath] = true
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(filepaths))
+}
+
+// ApplyStash restores the changes of a stash, given as "stash@{N}" or N, on top of HEAD. The newest stash is used if omitted.
+//
+// The stash changes are three-way merged with HEAD against the save the stash was pushed on, the files
+// changed on both sides are merged by their .vcsattributes merge driver. Conflicts are written to the
+// working directory and the index, they are resolved by adding the files. The stash index changes are
+// indexed again, unless HEAD changed the same files. The files the stash changed must not have unsaved
+// changes.
+//
+// The conflicted changes are returned, with Pop the stash is dropped only when there are none.
+func (repository *Repository) ApplyStash(stash string, options *ApplyStashOptions) ([]*directories.Change, error) {
+	if repository.isDetachedMode() {
+		return nil, &ValidationError{"cannot make changes in detached mode."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, err
+	}
+	if repository.isIndexConflicted() {
+		return nil, &ValidationError{"index is conflicted."}
+	}
+
+	index, id, err := repository.findStash(stash)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := repository.fs.ReadCheckpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoint.Parents) != 2 {
+		return nil, &filesystems.CorruptSaveError{Id: id, Err: errors.New("stash save without index save")}
+	}
+
+	baseDir, err := repository.fs.ReadDir(checkpoint.Parents[0])
+	if err != nil {
+		return nil, err
+	}
+	indexDir, err := repository.fs.ReadDir(checkpoint.Parents[1])
+	if err != nil {
+		return nil, err
+	}
+	stashDir, err := repository.fs.ReadDir(checkpoint.Id)
+	if err != nil {
+		return nil, err
+	}
+	headDir, err := repository.fs.ReadDir(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, err
+	}
+
+	filepaths := getStashedFilepaths(&baseDir, &indexDir, &stashDir)
+
+	for _, filepath := range filepaths {
+		workingHash, err := hashWorkingFile(filepath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if workingHash != getFileHash(repository.findSavedFile(filepath)) || repository.findStagedChange(filepath) != nil {
+			path, err := Path.Rel(repository.fs.Root, filepath)
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, &ValidationError{fmt.Sprintf("\"%s\" has unsaved changes.", Path.ToSlash(path))}
+		}
+	}
+
+	mergedDir, conflictedChanges, err := repository.mergeDirs(&baseDir, &headDir, &stashDir, "HEAD", getStashName(index), &MergeOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	baseFiles, indexFiles, headFiles, mergedFiles := getDirFilesMap(&baseDir), getDirFilesMap(&indexDir), getDirFilesMap(&headDir), getDirFilesMap(mergedDir)
+
+	for _, filepath := range filepaths {
+		if getFileHash(mergedFiles[filepath]) == getFileHash(headFiles[filepath]) {
+			continue
+		}
+
+		if err := repository.writeWorkingFile(filepath, mergedFiles[filepath]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, filepath := range filepaths {
+		isConflicted := slices.ContainsFunc(conflictedChanges, func(change *directories.Change) bool {
+			return change.GetPath() == filepath
+		})
+		baseHash := getFileHash(baseFiles[filepath])
+
+		if isConflicted || baseHash == getFileHash(indexFiles[filepath]) || baseHash != getFileHash(headFiles[filepath]) {
+			// Not indexed, or HEAD changed the file since the stash
+			continue
+		}
+
+		if indexFiles[filepath] == nil {
+			repository.stageRemoval(filepath)
+		} else {
+			repository.stageFile(indexFiles[filepath])
+		}
+	}
+
+	repository.index = append(repository.index, conflictedChanges...)
+	if err := repository.SaveIndex(); err != nil {
+		return nil, err
+	}
+
+	if options.Pop && len(conflictedChanges) == 0 {
+		if err := repository.removeStash(index); err != nil {
+			return nil, err
+		}
+	}
+
+	return conflictedChanges, nil
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidApplyStash(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, err := repository.ApplyStash("", &ApplyStashOptions{})
+	assert.EqualError(t, err, "Validation Error: no stashes.")
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 stashed content."))
+	repository.PushStash("")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	for _, stash := range []string{"1", "stash@{1}", "stash@{x}", "other"} {
+		_, err = repository.ApplyStash(stash, &ApplyStashOptions{})
+		assert.EqualError(t, err, "Validation Error: invalid stash.")
+	}
+
+	headSaveName := repository.getCurrentSaveName()
+
+	repository.fs.WriteCherryPickState(&filesystems.CherryPickState{Ref: filesystems.INITIAL_REF_NAME, RefSave: headSaveName, Pending: []string{}})
+	_, err = repository.ApplyStash("", &ApplyStashOptions{})
+	assert.EqualError(t, err, "Validation Error: a cherry-pick is in progress, continue or abort it first.")
+	repository.fs.RemoveCherryPickState()
+
+	repository.fs.WriteRebaseState(&filesystems.RebaseState{Ref: filesystems.INITIAL_REF_NAME, RefSave: headSaveName, Onto: headSaveName, Pending: []*filesystems.RebaseStep{}})
+	_, err = repository.ApplyStash("", &ApplyStashOptions{})
+	assert.EqualError(t, err, "Validation Error: a rebase is in progress, continue or abort it first.")
+	repository.fs.RemoveRebaseState()
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 unsaved content."))
+
+	_, err = repository.ApplyStash("stash@{0}", &ApplyStashOptions{})
+	assert.EqualError(t, err, "Validation Error: \"1.txt\" has unsaved changes.")
+}
+
+func TestApplyStash(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+	fixtures.WriteFile(dir.Join("3.txt"), []byte("3 content."))
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.IndexFile("3.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 staged content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	fixtures.RemoveFile(dir.Join("3.txt"))
+	fixtures.WriteFile(dir.Join("4.txt"), []byte("4 untracked content."))
+	repository.PushStash("")
+
+	// Changes unrelated to the stash are kept
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 saved content."))
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1")
+
+	// Test
+	repository = fixtureGetRepository(t, dir.Path())
+	conflicts, err := repository.ApplyStash("", &ApplyStashOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1 staged content.")
+	content, _ = os.ReadFile(dir.Join("2.txt"))
+	assert.Equal(t, string(content), "2 saved content.")
+	content, _ = os.ReadFile(dir.Join("4.txt"))
+	assert.Equal(t, string(content), "4 untracked content.")
+	assert.NoFileExists(t, dir.Join("3.txt"))
+
+	// Check the index changes are indexed again
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, len(repository.index), 1)
+	assert.Equal(t, repository.findStagedChange(dir.Join("1.txt")).ChangeType, directories.Modification)
+
+	status, _ := repository.GetStatus()
+	assert.Equal(t, status.WorkingDir.RemovedFilePaths, []string{dir.Join("3.txt")})
+	assert.Equal(t, status.WorkingDir.UntrackedFilePaths, []string{dir.Join("4.txt")})
+
+	// Check applied stashes are kept
+	stashes, _ := repository.GetStashes()
+	assert.Equal(t, len(stashes), 1)
+}
+
+func TestPopStash(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1\nline 2\nline 3\n"))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 stashed content."))
+	repository.PushStash("second")
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (stash)\nline 2\nline 3\n"))
+	repository.PushStash("first")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (head)\nline 2\nline 3\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1")
+
+	// Check conflicted stashes are kept
+	repository = fixtureGetRepository(t, dir.Path())
+	conflicts, err := repository.ApplyStash("", &ApplyStashOptions{Pop: true})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].GetPath(), dir.Join("1.txt"))
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "<<<<<<< HEAD\nline 1 (head)\n||||||| base\nline 1\n=======\nline 1 (stash)\n>>>>>>> stash@{0}\nline 2\nline 3\n")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.True(t, repository.isIndexConflicted())
+
+	stashes, _ := repository.GetStashes()
+	assert.Equal(t, len(stashes), 2)
+
+	// Check stashes applied without conflicts are dropped
+	repository.Restore("HEAD", "1.txt")
+	repository.SaveIndex()
+	repository = fixtureGetRepository(t, dir.Path())
+
+	conflicts, err = repository.ApplyStash("stash@{1}", &ApplyStashOptions{Pop: true})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+
+	content, _ = os.ReadFile(dir.Join("2.txt"))
+	assert.Equal(t, string(content), "2 stashed content.")
+
+	stashes, _ = repository.GetStashes()
+	assert.Equal(t, len(stashes), 1)
+	assert.Equal(t, stashes[0].Message, "first")
+}
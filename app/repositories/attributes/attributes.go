@@ -0,0 +1,142 @@
+package attributes
+
+import (
+	"os"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/ignores"
+	"strings"
+)
+
+const ATTRIBUTES_FILE_NAME = ".vcsattributes"
+
+const (
+	SET_VALUE   = "true"
+	UNSET_VALUE = "false"
+)
+
+// Merge drivers, how a file changed on both sides of a merge is merged.
+const (
+	// Three-way merge the lines, the default driver.
+	MERGE_TEXT = "text"
+	// Keep the ref side file.
+	MERGE_OURS = "ours"
+	// Keep the incoming side file.
+	MERGE_THEIRS = "theirs"
+	// Three-way merge the lines, keeping both sides of conflicting regions.
+	MERGE_UNION = "union"
+	// Do not merge the content, the file is conflicted and the ref side is kept in the working directory.
+	MERGE_BINARY = "binary"
+)
+
+type Rule struct {
+	Pattern    *ignores.Pattern
+	Attributes map[string]string
+}
+
+// Matcher follows a subset of the .gitattributes rules, read from the root .vcsattributes file:
+//
+//   - Blank lines and lines starting with "#" are skipped.
+//   - Each line is a pattern, with the .vcsignore syntax, followed by whitespace separated attributes.
+//   - "name" sets the attribute, "-name" unsets it and "name=value" assigns a value.
+//   - "binary" is a shorthand for "binary merge=binary".
+//   - Negated patterns are not allowed and are skipped.
+//   - When several patterns match a path, the last one wins for each attribute.
+type Matcher struct {
+	Root  string
+	rules []*Rule
+}
+
+func Open(root string) (*Matcher, error) {
+	matcher := &Matcher{Root: root, rules: []*Rule{}}
+
+	content, err := os.ReadFile(Path.Join(root, ATTRIBUTES_FILE_NAME))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	matcher.AddRules(string(content))
+
+	return matcher, nil
+}
+
+func ParseRule(line string) *Rule {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil
+	}
+
+	pattern := ignores.ParsePattern("", fields[0])
+	if pattern == nil || pattern.Negated {
+		return nil
+	}
+
+	rule := &Rule{Pattern: pattern, Attributes: make(map[string]string)}
+
+	for _, field := range fields[1:] {
+		switch {
+		case field == "binary":
+			rule.Attributes["binary"] = SET_VALUE
+			rule.Attributes["merge"] = MERGE_BINARY
+		case strings.HasPrefix(field, "-"):
+			rule.Attributes[field[1:]] = UNSET_VALUE
+		case strings.Contains(field, "="):
+			name, value, _ := strings.Cut(field, "=")
+			rule.Attributes[name] = value
+		default:
+			rule.Attributes[field] = SET_VALUE
+		}
+	}
+
+	return rule
+}
+
+func (matcher *Matcher) AddRules(content string) {
+	for _, line := range strings.Split(content, "\n") {
+		rule := ParseRule(line)
+
+		if rule != nil {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+}
+
+// Get the attributes of an absolute file path.
+func (matcher *Matcher) Attributes(filepath string) map[string]string {
+	attributes := make(map[string]string)
+
+	relativePath, err := Path.Rel(matcher.Root, filepath)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return attributes
+	}
+
+	for _, rule := range matcher.rules {
+		if !rule.Pattern.Match(Path.ToSlash(relativePath), false) {
+			continue
+		}
+
+		for name, value := range rule.Attributes {
+			attributes[name] = value
+		}
+	}
+
+	return attributes
+}
+
+// Get the merge driver of an absolute file path, unknown drivers fall back to the text driver.
+func (matcher *Matcher) MergeDriver(filepath string) string {
+	switch driver := matcher.Attributes(filepath)["merge"]; driver {
+	case MERGE_OURS, MERGE_THEIRS, MERGE_UNION, MERGE_BINARY:
+		return driver
+	case UNSET_VALUE:
+		return MERGE_BINARY
+	default:
+		return MERGE_TEXT
+	}
+}
+
+// Check whether an absolute file path is declared binary, by the "binary" attribute or an unset "diff" attribute.
+func (matcher *Matcher) IsBinary(filepath string) bool {
+	attributes := matcher.Attributes(filepath)
+
+	return attributes["binary"] == SET_VALUE || attributes["diff"] == UNSET_VALUE
+}
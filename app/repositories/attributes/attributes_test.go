@@ -0,0 +1,77 @@
+package attributes
+
+import (
+	"saymow/version-manager/app/repositories/ignores"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestParseRule(t *testing.T) {
+	assert.Nil(t, ParseRule(""))
+	assert.Nil(t, ParseRule("  # comment"))
+	assert.Nil(t, ParseRule("!*.lock merge=theirs"))
+	assert.Equal(
+		t,
+		ParseRule("*.lock\tmerge=theirs  -diff text"),
+		&Rule{
+			Pattern:    &ignores.Pattern{Glob: "*.lock"},
+			Attributes: map[string]string{"merge": MERGE_THEIRS, "diff": UNSET_VALUE, "text": SET_VALUE},
+		},
+	)
+	assert.Equal(
+		t,
+		ParseRule("/assets/*.png binary"),
+		&Rule{
+			Pattern:    &ignores.Pattern{Glob: "assets/*.png", Anchored: true},
+			Attributes: map[string]string{"binary": SET_VALUE, "merge": MERGE_BINARY},
+		},
+	)
+}
+
+func TestMergeDriver(t *testing.T) {
+	dir := fs.NewDir(
+		t,
+		"project",
+		fs.WithFile(
+			ATTRIBUTES_FILE_NAME,
+			"# Generated files\n*.lock merge=theirs\nCHANGELOG.md merge=union\n*.png binary\nlogo.png merge=ours\nschema.sql -merge\nnotes.txt merge=unknown\n",
+		),
+	)
+	defer dir.Remove()
+
+	matcher, err := Open(dir.Path())
+	assert.Nil(t, err)
+
+	assert.Equal(t, matcher.MergeDriver(dir.Join("package.lock")), MERGE_THEIRS)
+	assert.Equal(t, matcher.MergeDriver(dir.Join("app", "yarn.lock")), MERGE_THEIRS)
+	assert.Equal(t, matcher.MergeDriver(dir.Join("CHANGELOG.md")), MERGE_UNION)
+	assert.Equal(t, matcher.MergeDriver(dir.Join("icon.png")), MERGE_BINARY)
+	assert.Equal(t, matcher.MergeDriver(dir.Join("schema.sql")), MERGE_BINARY)
+	assert.Equal(t, matcher.MergeDriver(dir.Join("notes.txt")), MERGE_TEXT)
+	assert.Equal(t, matcher.MergeDriver(dir.Join("main.go")), MERGE_TEXT)
+
+	// Later rules win for each attribute
+	assert.Equal(t, matcher.MergeDriver(dir.Join("logo.png")), MERGE_OURS)
+	assert.Equal(t, matcher.Attributes(dir.Join("logo.png"))["binary"], SET_VALUE)
+
+	// Check a missing attributes file
+	emptyDir := fs.NewDir(t, "empty")
+	defer emptyDir.Remove()
+
+	matcher, err = Open(emptyDir.Path())
+	assert.Nil(t, err)
+	assert.Equal(t, matcher.MergeDriver(emptyDir.Join("package.lock")), MERGE_TEXT)
+}
+
+func TestIsBinary(t *testing.T) {
+	matcher := &Matcher{Root: "/project", rules: []*Rule{}}
+	matcher.AddRules("*.png binary\n*.pdf -diff\n*.svg binary\nicon.svg -binary\n")
+
+	assert.True(t, matcher.IsBinary("/project/logo.png"))
+	assert.True(t, matcher.IsBinary("/project/docs/manual.pdf"))
+	assert.True(t, matcher.IsBinary("/project/logo.svg"))
+	assert.False(t, matcher.IsBinary("/project/icon.svg"))
+	assert.False(t, matcher.IsBinary("/project/main.go"))
+}
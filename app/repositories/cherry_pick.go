@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+// The saves a cherry-pick in progress refers to.
+func getCherryPickSaves(state *filesystems.CherryPickState) []string {
+	return append([]string{state.RefSave, state.Current}, state.Pending...)
+}
+
+// Check whether a cherry-pick is in progress, other commands changing the current ref must wait for it.
+func (repository *Repository) checkCherryPickInProgress() error {
+	state, err := repository.fs.ReadCherryPickState()
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		return &ValidationError{"a cherry-pick is in progress, continue or abort it first."}
+	}
+
+	return nil
+}
+
+// The picked save message keeps the original message and records the save it was picked from.
+func getCherryPickMessage(checkpoint *filesystems.Checkpoint) string {
+	return fmt.Sprintf("%s (cherry picked from %s)", checkpoint.Message, checkpoint.Id)
+}
+
+// Pick the pending saves of a cherry-pick in order, each one is saved on the current ref.
+//
+// On conflicts, the cherry-pick state is recorded and the picking stops. Saves whose changes HEAD already
+// has are skipped. The created saves are returned along with the conflicted changes.
+func (repository *Repository) pickSaves(state *filesystems.CherryPickState) ([]*filesystems.Checkpoint, []*directories.Change, error) {
+	picked := []*filesystems.Checkpoint{}
+
+	for len(state.Pending) > 0 {
+		checkpoint, err := repository.fs.ReadCheckpoint(state.Pending[0])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		state.Pending = state.Pending[1:]
+
+		parentDir, err := repository.fs.ReadDir(checkpoint.FirstParent())
+		if err != nil {
+			return nil, nil, err
+		}
+		saveDir, err := repository.fs.ReadDir(checkpoint.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergedDir, changes, conflictedChanges, err := repository.applyChanges(&parentDir, &saveDir, checkpoint.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(conflictedChanges) > 0 {
+			state.Current = checkpoint.Id
+
+			return picked, conflictedChanges, repository.fs.WriteCherryPickState(state)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		message := getCherryPickMessage(checkpoint)
+		save, err := repository.createAppliedSave(mergedDir, changes, message, fmt.Sprintf("cherry-pick: %s", message))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		picked = append(picked, save)
+	}
+
+	return picked, nil, repository.fs.RemoveCherryPickState()
+}
+
+// CherryPick applies the changes saves made to their first parent onto the current ref, in order.
+//
+// Each save changes are three-way merged with HEAD and saved as a new save with the same message, along
+// with the picked save name. When a save conflicts, the cherry-pick stops: the conflicts are written
+// to the working directory and the index, to be resolved by adding the files. The cherry-pick is then
+// continued with ContinueCherryPick or cancelled with AbortCherryPick.
+//
+// The created saves are returned along with the conflicted changes.
+func (repository *Repository) CherryPick(revisions []string) ([]*filesystems.Checkpoint, []*directories.Change, error) {
+	if repository.isDetachedMode() {
+		return nil, nil, &ValidationError{"cannot make changes in detached mode."}
+	}
+	if len(revisions) == 0 {
+		return nil, nil, &ValidationError{"nothing specified, nothing picked."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkUnsavedChanges(); err != nil {
+		return nil, nil, err
+	}
+
+	ids := []string{}
+	for _, revision := range revisions {
+		id, err := repository.resolveRevision(revision)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id == "" {
+			return nil, nil, &ValidationError{"invalid ref."}
+		}
+		if _, err := repository.fs.ReadCheckpoint(id); err != nil {
+			return nil, nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return repository.pickSaves(&filesystems.CherryPickState{
+		Ref:     repository.head,
+		RefSave: repository.getCurrentSaveName(),
+		Pending: ids,
+	})
+}
+
+// Save the resolved changes of the save the cherry-pick stopped at and pick the remaining saves.
+//
+// The index is saved with the picked save message, nothing is saved when the index is empty, e.g. when
+// the conflicts were resolved keeping HEAD files.
+func (repository *Repository) ContinueCherryPick() ([]*filesystems.Checkpoint, []*directories.Change, error) {
+	state, err := repository.fs.ReadCherryPickState()
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, &ValidationError{"no cherry-pick in progress."}
+	}
+	if repository.head != state.Ref {
+		return nil, nil, &ValidationError{fmt.Sprintf("the cherry-pick in progress is on \"%s\", not on HEAD.", state.Ref)}
+	}
+	if repository.isIndexConflicted() {
+		return nil, nil, &ValidationError{"index is conflicted."}
+	}
+
+	picked := []*filesystems.Checkpoint{}
+
+	if state.Current != "" && len(repository.index) > 0 {
+		checkpoint, err := repository.fs.ReadCheckpoint(state.Current)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dir, err := repository.getStagedDir()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		message := getCherryPickMessage(checkpoint)
+		save, err := repository.createAppliedSave(dir, slices.Clone(repository.index), message, fmt.Sprintf("cherry-pick: %s", message))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := repository.clearIndex(); err != nil {
+			return nil, nil, err
+		}
+
+		picked = append(picked, save)
+	}
+
+	// The current save is done, even if the remaining saves cannot be picked yet
+	state.Current = ""
+	if err := repository.fs.WriteCherryPickState(state); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkUnsavedChanges(); err != nil {
+		return nil, nil, err
+	}
+
+	pickedSaves, conflictedChanges, err := repository.pickSaves(state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(picked, pickedSaves...), conflictedChanges, nil
+}
+
+// Cancel a cherry-pick stopped by conflicts, the ref, the index and the working directory are restored
+// as they were before the cherry-pick.
+func (repository *Repository) AbortCherryPick() error {
+	state, err := repository.fs.ReadCherryPickState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return &ValidationError{"no cherry-pick in progress."}
+	}
+
+	dir, err := repository.fs.ReadDir(state.RefSave)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.applyDir(&dir); err != nil {
+		return err
+	}
+	if err := repository.clearIndex(); err != nil {
+		return err
+	}
+	if err := repository.setRef(state.Ref, state.RefSave, "cherry-pick: abort"); err != nil {
+		return err
+	}
+
+	return repository.fs.RemoveCherryPickState()
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+// Create a "feature" ref with saves changing the 1st line of 1.txt, creating 2.txt and changing the 3rd
+// line of 1.txt. The master ref changes the 1st line of 1.txt with masterLine.
+func fixtureCherryPickRefs(t *testing.T, masterLine string) (*fs.Dir, *Repository, []*filesystems.Checkpoint) {
+	dir, repository := fixtureGetNewProject(t)
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1\nline 2\nline 3\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CreateRef("feature")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	saves := []*filesystems.Checkpoint{}
+	for _, file := range [][]string{
+		{"1.txt", "line 1 (feature)\nline 2\nline 3\n"},
+		{"2.txt", "2 content."},
+		{"1.txt", "line 1 (feature)\nline 2\nline 3 (feature)\n"},
+	} {
+		fixtures.WriteFile(dir.Join(file[0]), []byte(file[1]))
+		repository.IndexFile(file[0])
+		repository.SaveIndex()
+		save, _ := repository.CreateSave("feature " + file[0])
+		saves = append(saves, save)
+		repository = fixtureGetRepository(t, dir.Path())
+	}
+
+	repository.Load(filesystems.INITIAL_REF_NAME)
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("1.txt"), []byte(masterLine+"\nline 2\nline 3\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("master")
+
+	return dir, fixtureGetRepository(t, dir.Path()), saves
+}
+
+func TestInvalidCherryPick(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, _, err := repository.CherryPick([]string{})
+	assert.EqualError(t, err, "Validation Error: nothing specified, nothing picked.")
+	_, _, err = repository.CherryPick([]string{"undefined"})
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+
+	_, _, err = repository.ContinueCherryPick()
+	assert.EqualError(t, err, "Validation Error: no cherry-pick in progress.")
+	assert.EqualError(t, repository.AbortCherryPick(), "Validation Error: no cherry-pick in progress.")
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 unsaved content."))
+
+	_, _, err = repository.CherryPick([]string{"HEAD"})
+	assert.EqualError(t, err, "Validation Error: unsaved changes.")
+}
+
+func TestCherryPick(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1")
+	defer dir.Remove()
+
+	headSaveName := repository.getCurrentSaveName()
+
+	picked, conflicts, err := repository.CherryPick([]string{saves[0].Id, "feature"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(picked), 2)
+	assert.Equal(t, picked[0].Message, "feature 1.txt (cherry picked from "+saves[0].Id+")")
+	assert.Equal(t, picked[0].Parents, []string{headSaveName})
+	assert.Equal(t, picked[1].Parents, []string{picked[0].Id})
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "line 1 (feature)\nline 2\nline 3 (feature)\n")
+	assert.NoFileExists(t, dir.Join("2.txt"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), picked[1].Id)
+
+	reflog, _ := repository.GetReflog(filesystems.INITIAL_REF_NAME)
+	assert.Equal(t, reflog.Entries[0].Command, "cherry-pick: "+picked[1].Message)
+
+	// Check saves whose changes HEAD already has are skipped
+	picked, conflicts, err = repository.CherryPick([]string{saves[0].Id})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(picked), 0)
+}
+
+func TestContinueCherryPick(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1 (master)")
+	defer dir.Remove()
+
+	headSaveName := repository.getCurrentSaveName()
+
+	picked, conflicts, err := repository.CherryPick([]string{saves[0].Id, saves[1].Id})
+	assert.Nil(t, err)
+	assert.Equal(t, len(picked), 0)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].GetPath(), dir.Join("1.txt"))
+
+	// Check the cherry-pick state is recorded
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), headSaveName)
+
+	status, _ := repository.GetStatus()
+	assert.Equal(t, status.CherryPick, &filesystems.CherryPickState{
+		Ref:     filesystems.INITIAL_REF_NAME,
+		RefSave: headSaveName,
+		Current: saves[0].Id,
+		Pending: []string{saves[1].Id},
+	})
+
+	_, _, err = repository.ContinueCherryPick()
+	assert.EqualError(t, err, "Validation Error: index is conflicted.")
+	_, err = repository.Merge("feature", &MergeOptions{})
+	assert.EqualError(t, err, "Validation Error: a cherry-pick is in progress, continue or abort it first.")
+	assert.EqualError(t, repository.Load("feature"), "Validation Error: a cherry-pick is in progress, continue or abort it first.")
+	assert.EqualError(t, repository.CreateRef("other"), "Validation Error: a cherry-pick is in progress, continue or abort it first.")
+
+	repository.head = "feature"
+	_, _, err = repository.ContinueCherryPick()
+	assert.EqualError(t, err, "Validation Error: the cherry-pick in progress is on \"master\", not on HEAD.")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
+	assert.NotContains(t, *repository.refs, "other")
+
+	// Check the resolved save and the remaining saves are picked
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (master and feature)\nline 2\nline 3\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+
+	picked, conflicts, err = repository.ContinueCherryPick()
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(picked), 2)
+	assert.Equal(t, picked[0].Message, "feature 1.txt (cherry picked from "+saves[0].Id+")")
+	assert.Equal(t, picked[0].Changes[0].ChangeType, directories.Modification)
+	assert.Equal(t, picked[1].Message, "feature 2.txt (cherry picked from "+saves[1].Id+")")
+
+	content, _ := os.ReadFile(dir.Join("2.txt"))
+	assert.Equal(t, string(content), "2 content.")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), picked[1].Id)
+
+	status, _ = repository.GetStatus()
+	assert.Nil(t, status.CherryPick)
+	assert.False(t, status.HasChanges())
+}
+
+func TestAbortCherryPick(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1 (master)")
+	defer dir.Remove()
+
+	headSaveName := repository.getCurrentSaveName()
+
+	// The first save is picked, the second one conflicts
+	picked, conflicts, err := repository.CherryPick([]string{saves[1].Id, saves[0].Id})
+	assert.Nil(t, err)
+	assert.Equal(t, len(picked), 1)
+	assert.Equal(t, len(conflicts), 1)
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Nil(t, repository.AbortCherryPick())
+
+	// Check the ref, the index and the working directory are restored
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), headSaveName)
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "line 1 (master)\nline 2\nline 3\n")
+	assert.NoFileExists(t, dir.Join("2.txt"))
+
+	status, _ := repository.GetStatus()
+	assert.Nil(t, status.CherryPick)
+	assert.False(t, status.HasChanges())
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+	"time"
+)
+
+// Unreachable objects younger than this are kept, they may belong to a concurrent command.
+const DEFAULT_GC_GRACE_PERIOD = 14 * 24 * time.Hour
+
+type GarbageCollectionOptions struct {
+	// Only report the objects that would be removed.
+	DryRun bool
+	// Unreachable objects modified within the grace period are kept.
+	GracePeriod time.Duration
+}
+
+type GarbageCollection struct {
+	Reachable int
+	// Unreachable objects removed (or that would be removed in dry run mode).
+	Pruned []string
+	// Unreachable objects kept because of the grace period.
+	Kept []string
+}
+
+// Collect the checkpoints reachable from the refs, HEAD, the reflogs, the stashes, the merge or cherry-pick in
+// progress and the operations snapshots.
+func (repository *Repository) getReachableCheckpoints(snapshots []*filesystems.Snapshot) ([]*filesystems.Checkpoint, error) {
+	seen := make(map[string]bool)
+	checkpoints := []*filesystems.Checkpoint{}
+	pending := []string{}
+
+	for _, saveName := range *repository.refs {
+		pending = append(pending, saveName)
+	}
+	if repository.isDetachedMode() {
+		pending = append(pending, repository.head)
+	}
+
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if mergeState != nil {
+		pending = append(pending, mergeState.RefSave, mergeState.IncomingSave)
+	}
+
+	cherryPickState, err := repository.fs.ReadCherryPickState()
+	if err != nil {
+		return nil, err
+	}
+	if cherryPickState != nil {
+		pending = append(pending, getCherryPickSaves(cherryPickState)...)
+	}
+
+	rebaseState, err := repository.fs.ReadRebaseState()
+	if err != nil {
+		return nil, err
+	}
+	if rebaseState != nil {
+		pending = append(pending, getRebaseSaves(rebaseState)...)
+	}
+
+	// Previous positions stay reachable, so they can be recovered
+	reflogNames, err := repository.fs.ListReflogs()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range reflogNames {
+		entries, err := repository.fs.ReadReflog(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			pending = append(pending, entry.OldId, entry.NewId)
+		}
+	}
+
+	stashes, err := repository.fs.ReadStashes()
+	if err != nil {
+		return nil, err
+	}
+	pending = append(pending, stashes...)
+
+	// Undoing an operation restores its snapshot
+	for _, snapshot := range snapshots {
+		pending = append(pending, getSnapshotSaveName(snapshot))
+		pending = append(pending, snapshot.Stashes...)
+		for _, saveName := range *snapshot.Refs {
+			pending = append(pending, saveName)
+		}
+		if snapshot.MergeState != nil {
+			pending = append(pending, snapshot.MergeState.RefSave, snapshot.MergeState.IncomingSave)
+		}
+		if snapshot.CherryPickState != nil {
+			pending = append(pending, getCherryPickSaves(snapshot.CherryPickState)...)
+		}
+		if snapshot.RebaseState != nil {
+			pending = append(pending, getRebaseSaves(snapshot.RebaseState)...)
+		}
+	}
+
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if id == "" || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+
+		checkpoint, err := repository.fs.ReadCheckpoint(id)
+		if err != nil {
+			// Objects cannot be collected safely while reachable saves cannot be read.
+			return nil, err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+		pending = append(pending, checkpoint.Parents...)
+	}
+
+	return checkpoints, nil
+}
+
+// Collect the objects reachable from the refs, HEAD, the reflogs, the stashes, the index and the operations snapshots,
+// including the saves tree objects.
+func (repository *Repository) getReachableObjects() (map[string]bool, error) {
+	objects := make(map[string]bool)
+
+	snapshots, err := repository.readOperationsSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := repository.getReachableCheckpoints(snapshots)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, checkpoint := range checkpoints {
+		for _, change := range checkpoint.Changes {
+			if hash := change.GetHash(); hash != "" {
+				objects[hash] = true
+			}
+		}
+
+		if checkpoint.Tree != "" {
+			if err := repository.fs.CollectTreeObjects(checkpoint.Tree, objects); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	indexes := [][]*directories.Change{repository.index}
+	for _, snapshot := range snapshots {
+		indexes = append(indexes, snapshot.Index, snapshot.WorkingFiles)
+	}
+
+	for _, index := range indexes {
+		for _, change := range index {
+			if hash := change.GetHash(); hash != "" {
+				objects[hash] = true
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// CollectGarbage removes the objects that are not reachable from the refs, HEAD, the reflogs, the stashes,
+// the index or the operations snapshots.
+//
+// Objects are content addressed and shared between saves and index entries, so they are never
+// removed when a change is replaced. Unreachable objects modified within the grace period are kept.
+func (repository *Repository) CollectGarbage(options *GarbageCollectionOptions) (*GarbageCollection, error) {
+	reachableObjects, err := repository.getReachableObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := repository.fs.ListObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	expiration := time.Now().Add(-options.GracePeriod)
+	garbageCollection := &GarbageCollection{Pruned: []string{}, Kept: []string{}}
+
+	for name, modTime := range objects {
+		switch {
+		case reachableObjects[name]:
+			garbageCollection.Reachable++
+		case modTime.After(expiration):
+			garbageCollection.Kept = append(garbageCollection.Kept, name)
+		default:
+			garbageCollection.Pruned = append(garbageCollection.Pruned, name)
+		}
+	}
+
+	slices.Sort(garbageCollection.Pruned)
+	slices.Sort(garbageCollection.Kept)
+
+	if !options.DryRun {
+		for _, name := range garbageCollection.Pruned {
+			if err := repository.fs.RemoveObject(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return garbageCollection, nil
+}
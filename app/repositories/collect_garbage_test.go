@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	objectPath := func(name string) string {
+		return dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, name)
+	}
+	expire := func(name string) {
+		past := time.Now().Add(-2 * DEFAULT_GC_GRACE_PERIOD)
+		assert.Nil(t, os.Chtimes(objectPath(name), past, past))
+	}
+
+	// Setup
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	savedObject := repository.findSavedFile(dir.Join("1.txt")).ObjectName
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content (v2)"))
+	repository.IndexFile("1.txt")
+	replacedObject := repository.findStagedChange(dir.Join("1.txt")).GetHash()
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content (v3)"))
+	repository.IndexFile("1.txt")
+	stagedObject := repository.findStagedChange(dir.Join("1.txt")).GetHash()
+
+	// Un-staging a file content shared with the history must keep the object
+	fixtures.WriteFile(dir.Join("3.txt"), []byte("2 content"))
+	repository.IndexFile("3.txt")
+	repository.RemoveFile("3.txt")
+
+	expire(savedObject)
+	expire(replacedObject)
+	expire(stagedObject)
+
+	fixtures.WriteFile(objectPath("recent-object"), []byte("x"))
+
+	// Check dry run
+	{
+		garbageCollection, _ := repository.CollectGarbage(&GarbageCollectionOptions{DryRun: true, GracePeriod: DEFAULT_GC_GRACE_PERIOD})
+
+		// The saved files and the save tree
+		assert.Equal(t, garbageCollection.Reachable, 4)
+		assert.Equal(t, garbageCollection.Pruned, []string{replacedObject})
+		assert.Equal(t, garbageCollection.Kept, []string{"recent-object"})
+		assert.True(t, fixtures.FileExists(objectPath(replacedObject)))
+	}
+
+	// Check unreachable objects are removed
+	{
+		garbageCollection, _ := repository.CollectGarbage(&GarbageCollectionOptions{GracePeriod: DEFAULT_GC_GRACE_PERIOD})
+
+		assert.Equal(t, garbageCollection.Pruned, []string{replacedObject})
+		assert.False(t, fixtures.FileExists(objectPath(replacedObject)))
+		assert.True(t, fixtures.FileExists(objectPath(savedObject)))
+		assert.True(t, fixtures.FileExists(objectPath(stagedObject)))
+		assert.True(t, fixtures.FileExists(objectPath("recent-object")))
+	}
+
+	// Check grace period
+	{
+		garbageCollection, _ := repository.CollectGarbage(&GarbageCollectionOptions{GracePeriod: 0})
+
+		assert.Equal(t, garbageCollection.Pruned, []string{"recent-object"})
+		assert.Equal(t, garbageCollection.Reachable, 4)
+		assert.False(t, fixtures.FileExists(objectPath("recent-object")))
+	}
+}
@@ -1,16 +1,43 @@
 package repositories
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Check whether a ref name can be told apart from revision expressions.
+func isValidRefName(name string) bool {
+	return name != "" &&
+		name != "HEAD" &&
+		!strings.ContainsAny(name, "~^:@ \t\n") &&
+		!strings.Contains(name, REVISION_RANGE_SEPARATOR)
+}
+
 func (repository *Repository) CreateRef(name string) error {
 	currentSaveName := repository.getCurrentSaveName()
 
+	if !isValidRefName(name) {
+		return &ValidationError{"invalid ref name."}
+	}
 	if repository.hasEmptySaveHistory() {
 		return &ValidationError{"cannot create refs when there is no save history."}
 	}
 	if saveName, found := (*repository.refs)[name]; found && saveName != currentSaveName {
 		return &ValidationError{"name already in use."}
 	}
+	if err := repository.checkMergeInProgress(); err != nil {
+		return err
+	}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return err
+	}
+
+	if err := repository.setRef(name, repository.getCurrentSaveName(), fmt.Sprintf("ref: create %s", name)); err != nil {
+		return err
+	}
 
-	repository.setRef(name, repository.getCurrentSaveName())
-	repository.setHead(name)
-	return nil
+	return repository.setHead(name, fmt.Sprintf("ref: moving to %s", name))
 }
@@ -27,7 +27,7 @@ func TestInvalidRef(t *testing.T) {
 		repository.SaveIndex()
 		save0, _ := repository.CreateSave("save message")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		repository.CreateRef("feat/a")
 
@@ -48,4 +48,11 @@ func TestInvalidRef(t *testing.T) {
 			"feat/a": save1.Id,
 		})
 	}
+
+	// Names clashing with revision expressions
+	{
+		for _, name := range []string{"", "HEAD", "feat~1", "feat^", "feat@{now}", "a..b", "feat a"} {
+			assert.Error(t, repository.CreateRef(name), "Validation Error: invalid ref name.")
+		}
+	}
 }
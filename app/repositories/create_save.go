@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"fmt"
 	"saymow/version-manager/app/repositories/filesystems"
 	"time"
 )
@@ -9,23 +10,69 @@ func (repository *Repository) CreateSave(message string) (*filesystems.Checkpoin
 	if repository.isDetachedMode() {
 		return nil, &ValidationError{"cannot make changes in detached mode."}
 	}
-	if len(repository.index) == 0 {
-		return nil, &ValidationError{"cannot save empty index."}
+
+	// Saving a conflicted merge creates the merge save
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if mergeState != nil && repository.head != mergeState.Ref {
+		return nil, &ValidationError{fmt.Sprintf("the merge in progress is on \"%s\", not on HEAD.", mergeState.Ref)}
 	}
 	if repository.isIndexConflicted() {
 		return nil, &ValidationError{"index is conflicted."}
 	}
+	if len(repository.index) == 0 && mergeState == nil {
+		return nil, &ValidationError{"cannot save empty index."}
+	}
+
+	dir, err := repository.getStagedDir()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := repository.fs.WriteTree(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := []string{}
+	if parent := repository.getCurrentSaveName(); parent != "" {
+		parents = append(parents, parent)
+	}
+
+	if mergeState != nil {
+		parents = append(parents, mergeState.IncomingSave)
+	}
 
 	save := filesystems.Checkpoint{
 		Message:   message,
-		Parent:    repository.getCurrentSaveName(),
+		Parents:   parents,
+		Tree:      tree,
 		Changes:   repository.index,
 		CreatedAt: time.Now(),
 	}
 
-	save.Id = repository.fs.WriteCheckpoint(&save)
-	repository.clearIndex()
-	repository.setRef(repository.head, save.Id)
+	id, err := repository.fs.WriteCheckpoint(&save)
+	if err != nil {
+		return nil, err
+	}
+
+	save.Id = id
+
+	if err := repository.clearIndex(); err != nil {
+		return nil, err
+	}
+	if err := repository.fs.RemoveMergeState(); err != nil {
+		return nil, err
+	}
+	reason := fmt.Sprintf("save: %s", message)
+	if mergeState != nil {
+		reason = fmt.Sprintf("save (merge): %s", message)
+	}
+
+	if err := repository.setRef(repository.head, save.Id, reason); err != nil {
+		return nil, err
+	}
 
 	return &save, nil
 }
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"fmt"
+	"os"
+	Path "path/filepath"
 	"saymow/version-manager/app/pkg/fixtures"
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
@@ -35,9 +37,9 @@ func TestInvalidCreateSave(t *testing.T) {
 		repository.SaveIndex()
 		save, _ := repository.CreateSave("valid-saved")
 
-		repository.setHead(save.Id)
+		repository.setHead(save.Id, "test")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		// test
 
@@ -48,7 +50,7 @@ func TestInvalidCreateSave(t *testing.T) {
 
 	// conflicted index
 	{
-		repository.setHead(filesystems.INITIAL_REF_NAME)
+		repository.setHead(filesystems.INITIAL_REF_NAME, "test")
 
 		// manually messing with the index
 		repository.index = append(repository.index, &directories.Change{
@@ -88,12 +90,12 @@ func TestCreateSave(t *testing.T) {
 	)
 	fixtures.WriteFile(indexFilepath, []byte(index))
 
-	repository := GetRepository(dir.Path())
+	repository := fixtureGetRepository(t, dir.Path())
 	firstSave, _ := repository.CreateSave("first save")
 	expectedFirstSaveFileContent := fmt.Sprintf(`%s
 
 %s
-
+%s
 Please do not edit the lines below.
 
 
@@ -108,16 +110,17 @@ Files:
 `,
 		firstSave.Message,
 		firstSave.CreatedAt.Format(time.Layout),
-		firstSave.Changes[0].File.Filepath,
+		firstSave.Tree,
+		"1.txt",
 		firstSave.Changes[0].File.ObjectName,
-		firstSave.Changes[1].File.Filepath,
+		"a/4.txt",
 		firstSave.Changes[1].File.ObjectName,
-		firstSave.Changes[2].File.Filepath,
+		"a/b/6.txt",
 		firstSave.Changes[2].File.ObjectName,
 	)
 
 	assert.Equal(t, firstSave.Message, "first save")
-	assert.Equal(t, firstSave.Parent, "")
+	assert.Equal(t, firstSave.Parents, []string{})
 	assert.EqualValues(
 		t,
 		firstSave.Changes,
@@ -151,17 +154,35 @@ Files:
 			dir.Join(filesystems.REPOSITORY_FOLDER_NAME),
 			fs.Expected(
 				t,
+				fs.WithMode(0755|os.ModeDir),
 				fs.WithFile(filesystems.REFS_FILE_NAME, fmt.Sprintf("Refs:\n\n%s\n%s\n", filesystems.INITIAL_REF_NAME, firstSave.Id)),
 				fs.WithFile(filesystems.HEAD_FILE_NAME, filesystems.INITIAL_REF_NAME),
 				fs.WithFile(filesystems.INDEX_FILE_NAME, "Tracked files:\n\n"),
 				fs.WithDir(filesystems.SAVES_FOLDER_NAME,
 					fs.WithFile(firstSave.Id, expectedFirstSaveFileContent),
 				),
-				fs.WithDir(filesystems.OBJECTS_FOLDER_NAME),
+				// Tree objects
+				fs.WithDir(filesystems.OBJECTS_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithDir(filesystems.LOGS_FOLDER_NAME, fs.MatchExtraFiles),
 			),
 		),
 	)
 
+	reflog, _ := repository.fs.ReadReflog(filesystems.INITIAL_REF_NAME)
+	assert.Equal(t, len(reflog), 1)
+	assert.Equal(t, reflog[0].OldId, "")
+	assert.Equal(t, reflog[0].NewId, firstSave.Id)
+	assert.Equal(t, reflog[0].Command, "save: first save")
+	headReflog, _ := repository.fs.ReadReflog(filesystems.HEAD_REFLOG_NAME)
+	assert.Equal(t, headReflog, reflog)
+
+	tree, err := repository.fs.ReadTree(firstSave.Tree, dir.Path())
+	assert.Nil(t, err)
+	assert.Equal(t, len(tree.Children), 2)
+	assert.Equal(t, tree.Children["1.txt"].File.ObjectName, "1.txt-object")
+	assert.Equal(t, tree.Children["a"].Dir.Children["4.txt"].File.ObjectName, "4.txt-object")
+	assert.Equal(t, tree.Children["a"].Dir.Children["b"].Dir.Children["6.txt"].File.ObjectName, "6.txt-object")
+
 	// Check second save
 
 	index = fmt.Sprintf(`Tracked files:
@@ -176,12 +197,12 @@ Files:
 	)
 	fixtures.WriteFile(indexFilepath, []byte(index))
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 	secondSave, _ := repository.CreateSave("second save")
 	expectedSecondSaveFileContent := fmt.Sprintf(`%s
 %s
 %s
-
+%s
 Please do not edit the lines below.
 
 
@@ -193,16 +214,17 @@ Files:
 %s
 `,
 		secondSave.Message,
-		secondSave.Parent,
+		secondSave.Parents[0],
 		secondSave.CreatedAt.Format(time.Layout),
-		secondSave.Changes[0].Removal.Filepath,
-		secondSave.Changes[1].Removal.Filepath,
-		secondSave.Changes[2].File.Filepath,
+		secondSave.Tree,
+		"1.txt",
+		"a/4.txt",
+		"a/b/c/8.txt",
 		secondSave.Changes[2].File.ObjectName,
 	)
 
 	assert.Equal(t, secondSave.Message, "second save")
-	assert.Equal(t, secondSave.Parent, firstSave.Id)
+	assert.Equal(t, secondSave.Parents, []string{firstSave.Id})
 	assert.EqualValues(
 		t,
 		secondSave.Changes,
@@ -230,6 +252,7 @@ Files:
 			dir.Join(filesystems.REPOSITORY_FOLDER_NAME),
 			fs.Expected(
 				t,
+				fs.WithMode(0755|os.ModeDir),
 				fs.WithFile(filesystems.REFS_FILE_NAME, fmt.Sprintf("Refs:\n\n%s\n%s\n", filesystems.INITIAL_REF_NAME, secondSave.Id)),
 				fs.WithFile(filesystems.HEAD_FILE_NAME, filesystems.INITIAL_REF_NAME),
 				fs.WithFile(filesystems.INDEX_FILE_NAME, "Tracked files:\n\n"),
@@ -237,8 +260,22 @@ Files:
 					fs.WithFile(firstSave.Id, expectedFirstSaveFileContent),
 					fs.WithFile(secondSave.Id, expectedSecondSaveFileContent),
 				),
-				fs.WithDir(filesystems.OBJECTS_FOLDER_NAME),
+				// Tree objects
+				fs.WithDir(filesystems.OBJECTS_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithDir(filesystems.LOGS_FOLDER_NAME, fs.MatchExtraFiles),
 			),
 		),
 	)
+
+	// Check the save file tree is read from its tree object, without its parents
+
+	assert.Nil(t, os.Remove(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME, firstSave.Id)))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Nil(t, repository.dir.FindNode("1.txt"))
+	assert.Nil(t, repository.dir.FindNode(Path.Join("a", "4.txt")))
+	assert.Equal(t, repository.dir.FindNode(Path.Join("a", "b", "6.txt")).File.ObjectName, "6.txt-object")
+	assert.Equal(t, repository.dir.FindNode(Path.Join("a", "b", "c", "8.txt")).File.ObjectName, "8.txt-object")
+	assert.Equal(t, repository.dir.Hash, secondSave.Tree)
 }
@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/repositories/diffs"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+type DiffOptions struct {
+	// Zero, one or two revisions, a single "from..to" revision range is the same as two revisions.
+	Revisions []string
+	// Compare against the index instead of the working directory.
+	Staged bool
+	// Restrict the diff to these pathspecs (files, directories or globs).
+	Paths []string
+}
+
+type FileDiff struct {
+	Filepath   string
+	ChangeType directories.ChangeType
+	Hunks      []*diffs.Hunk
+	Insertions int
+	Deletions  int
+	// Binary files have no line changes, only their sizes in bytes are compared.
+	Binary  bool
+	OldSize int
+	NewSize int
+}
+
+type Diff struct {
+	Files []*FileDiff
+}
+
+type diffSide struct {
+	hashes  map[string]string
+	content func(filepath string) (string, error)
+}
+
+func (repository *Repository) makeDirDiffSide(dir *directories.Dir) *diffSide {
+	hashes := make(map[string]string)
+
+	for _, file := range dir.CollectAllFiles() {
+		hashes[file.Filepath] = file.ObjectName
+	}
+
+	return &diffSide{
+		hashes: hashes,
+		content: func(filepath string) (string, error) {
+			buffer, err := repository.fs.ReadDirFile(&directories.File{Filepath: filepath, ObjectName: hashes[filepath]})
+
+			return buffer.String(), err
+		},
+	}
+}
+
+// Only the working directory files found in one of the tracked dirs are compared, untracked files are left out.
+func (repository *Repository) makeWorkingDirDiffSide(trackedDirs ...*directories.Dir) (*diffSide, error) {
+	hashes := make(map[string]string)
+	tracked := make(map[string]bool)
+
+	for _, dir := range trackedDirs {
+		for _, file := range dir.CollectAllFiles() {
+			tracked[file.Filepath] = true
+		}
+	}
+
+	err := repository.walkWorkingDir(func(filepath string) error {
+		if !tracked[filepath] {
+			return nil
+		}
+
+		hash, err := hashWorkingFile(filepath)
+		hashes[filepath] = hash
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &diffSide{
+		hashes: hashes,
+		content: func(filepath string) (string, error) {
+			content, err := os.ReadFile(filepath)
+
+			return string(content), err
+		},
+	}, nil
+}
+
+// Get the HEAD file tree with the index changes applied.
+func (repository *Repository) getStagedDir() (*directories.Dir, error) {
+	dir, err := repository.fs.ReadDir(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range repository.index {
+		normalizedPath, err := dir.NormalizePath(change.GetPath())
+		if err != nil {
+			return nil, &filesystems.CorruptIndexError{Err: err}
+		}
+
+		if err := dir.AddNode(normalizedPath, change); err != nil {
+			return nil, &filesystems.CorruptIndexError{Err: err}
+		}
+	}
+
+	return &dir, nil
+}
+
+func (repository *Repository) getRevisionDir(ref string) (*directories.Dir, error) {
+	if ref == "HEAD" && repository.hasEmptySaveHistory() {
+		return &directories.Dir{Path: repository.fs.Root, Children: make(map[string]*directories.Node)}, nil
+	}
+
+	checkpoint, err := repository.getCheckpoint(ref)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, &ValidationError{"invalid ref."}
+	}
+
+	return repository.buildDir(checkpoint.Id)
+}
+
+func (repository *Repository) getDiffSides(options *DiffOptions) (*diffSide, *diffSide, error) {
+	revisions := options.Revisions
+	if len(revisions) == 1 {
+		if from, to, isRange := parseRevisionRange(revisions[0]); isRange {
+			revisions = []string{from, to}
+		}
+	}
+
+	switch {
+	case len(revisions) > 2:
+		return nil, nil, &ValidationError{"too many revisions."}
+	case len(revisions) == 2:
+		if options.Staged {
+			return nil, nil, &ValidationError{"cannot compare two revisions with the index."}
+		}
+
+		fromDir, err := repository.getRevisionDir(revisions[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		toDir, err := repository.getRevisionDir(revisions[1])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repository.makeDirDiffSide(fromDir), repository.makeDirDiffSide(toDir), nil
+	case options.Staged:
+		ref := "HEAD"
+		if len(revisions) == 1 {
+			ref = revisions[0]
+		}
+
+		fromDir, err := repository.getRevisionDir(ref)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		toDir, err := repository.getStagedDir()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repository.makeDirDiffSide(fromDir), repository.makeDirDiffSide(toDir), nil
+	case len(revisions) == 1:
+		fromDir, err := repository.getRevisionDir(revisions[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		stagedDir, err := repository.getStagedDir()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		to, err := repository.makeWorkingDirDiffSide(fromDir, stagedDir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repository.makeDirDiffSide(fromDir), to, nil
+	default:
+		fromDir, err := repository.getStagedDir()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		to, err := repository.makeWorkingDirDiffSide(fromDir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repository.makeDirDiffSide(fromDir), to, nil
+	}
+}
+
+// Diff compute the line changes between two file trees.
+//
+// Depending on the options, the compared trees are:
+//
+//   - No revisions: index -> working directory.
+//   - No revisions and Staged: HEAD -> index.
+//   - One revision: revision -> working directory.
+//   - One revision and Staged: revision -> index.
+//   - Two revisions or a revision range: first revision -> second revision.
+//
+// Untracked working directory files are not compared. Files with binary content or declared binary in the
+// .vcsattributes file are not diffed line by line.
+func (repository *Repository) Diff(options *DiffOptions) (*Diff, error) {
+	pathspecs, err := repository.parsePathspecs(options.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	diffAttributes, err := repository.fs.OpenAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := repository.getDiffSides(options)
+	if err != nil {
+		return nil, err
+	}
+
+	filepaths := []string{}
+
+	for filepath := range from.hashes {
+		filepaths = append(filepaths, filepath)
+	}
+	for filepath := range to.hashes {
+		if _, ok := from.hashes[filepath]; !ok {
+			filepaths = append(filepaths, filepath)
+		}
+	}
+
+	slices.Sort(filepaths)
+
+	diff := &Diff{Files: []*FileDiff{}}
+
+	for _, filepath := range filepaths {
+		if !matchPathspecs(pathspecs, repository.fs.Root, filepath) {
+			continue
+		}
+
+		fromHash, fromOk := from.hashes[filepath]
+		toHash, toOk := to.hashes[filepath]
+
+		if fromOk && toOk && fromHash == toHash {
+			continue
+		}
+
+		fileDiff := &FileDiff{Filepath: filepath, Binary: diffAttributes.IsBinary(filepath)}
+		fromContent, toContent := "", ""
+
+		switch {
+		case !fromOk:
+			fileDiff.ChangeType = directories.Creation
+		case !toOk:
+			fileDiff.ChangeType = directories.Removal
+		default:
+			fileDiff.ChangeType = directories.Modification
+		}
+
+		if fromOk {
+			if fromContent, err = from.content(filepath); err != nil {
+				return nil, err
+			}
+		}
+		if toOk {
+			if toContent, err = to.content(filepath); err != nil {
+				return nil, err
+			}
+		}
+
+		fileDiff.OldSize, fileDiff.NewSize = len(fromContent), len(toContent)
+		fileDiff.Binary = fileDiff.Binary || diffs.IsBinary(fromContent) || diffs.IsBinary(toContent)
+
+		if fileDiff.Binary {
+			fileDiff.Hunks = []*diffs.Hunk{}
+		} else {
+			edits := diffs.Lines(diffs.SplitLines(fromContent), diffs.SplitLines(toContent))
+			fileDiff.Insertions, fileDiff.Deletions = diffs.Stat(edits)
+			fileDiff.Hunks = diffs.Hunks(edits, diffs.DEFAULT_CONTEXT_LINES)
+		}
+
+		diff.Files = append(diff.Files, fileDiff)
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/attributes"
+	"saymow/version-manager/app/repositories/diffs"
+	"saymow/version-manager/app/repositories/directories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidDiff(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	_, err := repository.Diff(&DiffOptions{Revisions: []string{"undefined"}})
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+
+	_, err = repository.Diff(&DiffOptions{Revisions: []string{"HEAD", "HEAD", "HEAD"}})
+	assert.EqualError(t, err, "Validation Error: too many revisions.")
+
+	_, err = repository.Diff(&DiffOptions{Revisions: []string{"HEAD", "HEAD"}, Staged: true})
+	assert.EqualError(t, err, "Validation Error: cannot compare two revisions with the index.")
+
+	_, err = repository.Diff(&DiffOptions{Paths: []string{dir.Join("..")}})
+	assert.EqualError(t, err, "Validation Error: invalid path.")
+}
+
+func TestDiff(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\n2\n3\n"))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("b content.\n"))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	save0, _ := repository.CreateSave("save0")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\ntwo\n3\n"))
+	fixtures.WriteFile(dir.Join("c.txt"), []byte("c content.\n"))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("c.txt")
+	repository.RemoveFile("b.txt")
+	repository.SaveIndex()
+	save1, _ := repository.CreateSave("save1")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Saves
+	{
+		diff, err := repository.Diff(&DiffOptions{Revisions: []string{save0.Id, save1.Id}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 3)
+
+		assert.Equal(t, diff.Files[0].Filepath, dir.Join("a.txt"))
+		assert.Equal(t, diff.Files[0].ChangeType, directories.Modification)
+		assert.Equal(t, diff.Files[0].Insertions, 1)
+		assert.Equal(t, diff.Files[0].Deletions, 1)
+		assert.EqualValues(t, diff.Files[0].Hunks, []*diffs.Hunk{
+			{
+				OldStart: 1,
+				OldLines: 3,
+				NewStart: 1,
+				NewLines: 3,
+				Edits: []diffs.Edit{
+					{Operation: diffs.Equal, Line: "1\n"},
+					{Operation: diffs.Deletion, Line: "2\n"},
+					{Operation: diffs.Insertion, Line: "two\n"},
+					{Operation: diffs.Equal, Line: "3\n"},
+				},
+			},
+		})
+
+		assert.Equal(t, diff.Files[1].Filepath, dir.Join("b.txt"))
+		assert.Equal(t, diff.Files[1].ChangeType, directories.Removal)
+		assert.Equal(t, diff.Files[1].Insertions, 0)
+		assert.Equal(t, diff.Files[1].Deletions, 1)
+
+		assert.Equal(t, diff.Files[2].Filepath, dir.Join("c.txt"))
+		assert.Equal(t, diff.Files[2].ChangeType, directories.Creation)
+		assert.Equal(t, diff.Files[2].Insertions, 1)
+		assert.Equal(t, diff.Files[2].Deletions, 0)
+	}
+
+	// Path filters
+	{
+		diff, err := repository.Diff(&DiffOptions{Revisions: []string{save0.Id, "HEAD"}, Paths: []string{"c.txt"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 1)
+		assert.Equal(t, diff.Files[0].Filepath, dir.Join("c.txt"))
+	}
+
+	// Clean working directory
+	{
+		diff, err := repository.Diff(&DiffOptions{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 0)
+	}
+
+	// Index and working directory
+	{
+		fixtures.WriteFile(dir.Join("a.txt"), []byte("1\ntwo\n3\n4\n"))
+		repository.IndexFile("a.txt")
+		repository.SaveIndex()
+
+		fixtures.WriteFile(dir.Join("c.txt"), []byte("c updated content.\n"))
+		fixtures.WriteFile(dir.Join("d.txt"), []byte("d content.\n"))
+
+		repository = fixtureGetRepository(t, dir.Path())
+
+		// index -> working dir
+		diff, err := repository.Diff(&DiffOptions{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 1)
+		assert.Equal(t, diff.Files[0].Filepath, dir.Join("c.txt"))
+		assert.Equal(t, diff.Files[0].ChangeType, directories.Modification)
+
+		// HEAD -> index
+		diff, err = repository.Diff(&DiffOptions{Staged: true})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 1)
+		assert.Equal(t, diff.Files[0].Filepath, dir.Join("a.txt"))
+		assert.Equal(t, diff.Files[0].Insertions, 1)
+		assert.Equal(t, diff.Files[0].Deletions, 0)
+
+		// save -> index
+		diff, err = repository.Diff(&DiffOptions{Revisions: []string{save0.Id}, Staged: true})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 3)
+
+		// save -> working dir
+		diff, err = repository.Diff(&DiffOptions{Revisions: []string{save0.Id}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 3)
+		assert.Equal(t, diff.Files[0].Filepath, dir.Join("a.txt"))
+		assert.Equal(t, diff.Files[1].Filepath, dir.Join("b.txt"))
+		assert.Equal(t, diff.Files[1].ChangeType, directories.Removal)
+		assert.Equal(t, diff.Files[2].Filepath, dir.Join("c.txt"))
+		assert.Equal(t, diff.Files[2].ChangeType, directories.Creation)
+	}
+
+	// Untracked files
+	{
+		diff, err := repository.Diff(&DiffOptions{Paths: []string{"d.txt"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 0)
+
+		// Files staged for creation are tracked
+		repository.IndexFile("d.txt")
+		repository.SaveIndex()
+		fixtures.WriteFile(dir.Join("d.txt"), []byte("d updated content.\n"))
+
+		repository = fixtureGetRepository(t, dir.Path())
+		diff, err = repository.Diff(&DiffOptions{Revisions: []string{"HEAD"}, Paths: []string{"d.txt"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 1)
+		assert.Equal(t, diff.Files[0].ChangeType, directories.Creation)
+
+		diff, err = repository.Diff(&DiffOptions{Paths: []string{"d.txt"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, len(diff.Files), 1)
+		assert.Equal(t, diff.Files[0].ChangeType, directories.Modification)
+	}
+}
+
+func TestBinaryDiff(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("image.png"), []byte("\x89PNG\x00\x01"))
+	fixtures.WriteFile(dir.Join("data.bin"), []byte("text like data\n"))
+	fixtures.WriteFile(dir.Join(attributes.ATTRIBUTES_FILE_NAME), []byte("*.bin binary\n"))
+
+	repository.IndexFile("image.png")
+	repository.IndexFile("data.bin")
+	repository.IndexFile(attributes.ATTRIBUTES_FILE_NAME)
+	repository.SaveIndex()
+	repository.CreateSave("save0")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("image.png"), []byte("\x89PNG\x00\x01\x02\x03"))
+	fixtures.WriteFile(dir.Join("data.bin"), []byte("text like data, updated\n"))
+
+	// Test
+
+	diff, err := repository.Diff(&DiffOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(diff.Files), 2)
+
+	assert.Equal(t, diff.Files[0].Filepath, dir.Join("data.bin"))
+	assert.True(t, diff.Files[0].Binary)
+	assert.Equal(t, len(diff.Files[0].Hunks), 0)
+	assert.Equal(t, diff.Files[0].OldSize, 15)
+	assert.Equal(t, diff.Files[0].NewSize, 24)
+
+	assert.Equal(t, diff.Files[1].Filepath, dir.Join("image.png"))
+	assert.True(t, diff.Files[1].Binary)
+	assert.Equal(t, len(diff.Files[1].Hunks), 0)
+	assert.Equal(t, diff.Files[1].Insertions, 0)
+	assert.Equal(t, diff.Files[1].Deletions, 0)
+	assert.Equal(t, diff.Files[1].OldSize, 6)
+	assert.Equal(t, diff.Files[1].NewSize, 8)
+}
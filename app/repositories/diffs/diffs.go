@@ -0,0 +1,244 @@
+package diffs
+
+import (
+	"strings"
+)
+
+type Operation int
+
+const (
+	Equal Operation = iota
+	Insertion
+	Deletion
+)
+
+const DEFAULT_CONTEXT_LINES = 3
+
+// Number of leading bytes inspected to detect binary content.
+const BINARY_DETECTION_SIZE = 8000
+
+type Edit struct {
+	Operation Operation
+	Line      string
+}
+
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Edits    []Edit
+}
+
+// Check whether a file content is binary, that is, it has a NUL byte within its leading bytes.
+func IsBinary(content string) bool {
+	return strings.IndexByte(content[:min(len(content), BINARY_DETECTION_SIZE)], 0) != -1
+}
+
+// Split a file content into lines.
+//
+// The line terminator is kept, so joining the lines back results in the original content.
+func SplitLines(content string) []string {
+	lines := []string{}
+
+	for len(content) > 0 {
+		idx := strings.IndexByte(content, '\n')
+		if idx == -1 {
+			lines = append(lines, content)
+			break
+		}
+
+		lines = append(lines, content[:idx+1])
+		content = content[idx+1:]
+	}
+
+	return lines
+}
+
+// Compute the shortest edit script to transform "a" into "b".
+//
+// This is an implementation of the Myers O(ND) diff algorithm.
+func Lines(a, b []string) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := []Edit{}
+
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Operation: Equal, Line: line})
+	}
+
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Operation: Equal, Line: line})
+	}
+
+	return edits
+}
+
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// Only the diagonals reachable at each step are kept, so the trace grows with D² instead of D*(N+M).
+	trace := [][]int{}
+
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	return []Edit{}
+}
+
+func backtrack(a, b []string, trace [][]int) []Edit {
+	x, y := len(a), len(b)
+	edits := []Edit{}
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		offset := d + 1
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Operation: Equal, Line: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Operation: Insertion, Line: b[y-1]})
+			} else {
+				edits = append(edits, Edit{Operation: Deletion, Line: a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for left, right := 0, len(edits)-1; left < right; left, right = left+1, right-1 {
+		edits[left], edits[right] = edits[right], edits[left]
+	}
+
+	return edits
+}
+
+// Group an edit script into hunks with "context" unchanged lines around each change.
+func Hunks(edits []Edit, context int) []*Hunk {
+	hunks := []*Hunk{}
+	idx := 0
+
+	for idx < len(edits) {
+		if edits[idx].Operation == Equal {
+			idx++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough to share its context lines.
+		end := idx
+		for next := idx; next < len(edits); next++ {
+			if edits[next].Operation != Equal {
+				end = next
+				continue
+			}
+			if next-end > 2*context {
+				break
+			}
+		}
+
+		start := max(idx-context, 0)
+		stop := min(end+context+1, len(edits))
+		oldStart, newStart := lineNumbersAt(edits, start)
+		hunk := &Hunk{OldStart: oldStart, NewStart: newStart, Edits: edits[start:stop]}
+
+		for _, edit := range hunk.Edits {
+			if edit.Operation != Insertion {
+				hunk.OldLines++
+			}
+			if edit.Operation != Deletion {
+				hunk.NewLines++
+			}
+		}
+
+		hunks = append(hunks, hunk)
+		idx = stop
+	}
+
+	return hunks
+}
+
+// Get the 1-based old and new line numbers of the edit at "position".
+func lineNumbersAt(edits []Edit, position int) (int, int) {
+	oldLine, newLine := 1, 1
+
+	for _, edit := range edits[:position] {
+		if edit.Operation != Insertion {
+			oldLine++
+		}
+		if edit.Operation != Deletion {
+			newLine++
+		}
+	}
+
+	return oldLine, newLine
+}
+
+// Count the inserted and deleted lines of an edit script.
+func Stat(edits []Edit) (int, int) {
+	insertions, deletions := 0, 0
+
+	for _, edit := range edits {
+		switch edit.Operation {
+		case Insertion:
+			insertions++
+		case Deletion:
+			deletions++
+		}
+	}
+
+	return insertions, deletions
+}
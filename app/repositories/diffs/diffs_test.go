@@ -0,0 +1,116 @@
+package diffs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func applyEdits(edits []Edit) (string, string) {
+	var old, new strings.Builder
+
+	for _, edit := range edits {
+		if edit.Operation != Insertion {
+			old.WriteString(edit.Line)
+		}
+		if edit.Operation != Deletion {
+			new.WriteString(edit.Line)
+		}
+	}
+
+	return old.String(), new.String()
+}
+
+func TestIsBinary(t *testing.T) {
+	assert.False(t, IsBinary(""))
+	assert.False(t, IsBinary("plain text\n"))
+	assert.True(t, IsBinary("\x89PNG\r\n\x1a\n\x00\x00"))
+	// Only the leading bytes are inspected
+	assert.False(t, IsBinary(strings.Repeat("a", BINARY_DETECTION_SIZE)+"\x00"))
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Equal(t, SplitLines(""), []string{})
+	assert.Equal(t, SplitLines("a"), []string{"a"})
+	assert.Equal(t, SplitLines("a\n"), []string{"a\n"})
+	assert.Equal(t, SplitLines("a\nb"), []string{"a\n", "b"})
+	assert.Equal(t, SplitLines("a\n\nb\n"), []string{"a\n", "\n", "b\n"})
+}
+
+func TestLines(t *testing.T) {
+	// Equal contents
+	{
+		edits := Lines(SplitLines("a\nb\nc\n"), SplitLines("a\nb\nc\n"))
+
+		assert.Equal(t, edits, []Edit{
+			{Operation: Equal, Line: "a\n"},
+			{Operation: Equal, Line: "b\n"},
+			{Operation: Equal, Line: "c\n"},
+		})
+	}
+
+	// Empty contents
+	{
+		assert.Equal(t, Lines([]string{}, []string{}), []Edit{})
+		assert.Equal(t, Lines([]string{}, SplitLines("a\n")), []Edit{{Operation: Insertion, Line: "a\n"}})
+		assert.Equal(t, Lines(SplitLines("a\n"), []string{}), []Edit{{Operation: Deletion, Line: "a\n"}})
+	}
+
+	// Single line change
+	{
+		edits := Lines(SplitLines("a\nb\nc\n"), SplitLines("a\nB\nc\n"))
+
+		assert.Equal(t, edits, []Edit{
+			{Operation: Equal, Line: "a\n"},
+			{Operation: Deletion, Line: "b\n"},
+			{Operation: Insertion, Line: "B\n"},
+			{Operation: Equal, Line: "c\n"},
+		})
+	}
+
+	// Shortest edit script
+	{
+		a := SplitLines("a\nb\nc\na\nb\nb\na\n")
+		b := SplitLines("c\nb\na\nb\na\nc\n")
+		edits := Lines(a, b)
+		insertions, deletions := Stat(edits)
+		old, new := applyEdits(edits)
+
+		assert.Equal(t, insertions+deletions, 5)
+		assert.Equal(t, old, strings.Join(a, ""))
+		assert.Equal(t, new, strings.Join(b, ""))
+	}
+}
+
+func TestHunks(t *testing.T) {
+	lines := []string{}
+	for idx := 0; idx < 20; idx++ {
+		lines = append(lines, string(rune('a'+idx))+"\n")
+	}
+
+	changed := make([]string, len(lines))
+	copy(changed, lines)
+	changed[1] = "B\n"
+	changed[4] = "E\n"
+	changed[15] = "P\n"
+
+	hunks := Hunks(Lines(lines, changed), DEFAULT_CONTEXT_LINES)
+
+	assert.Equal(t, len(hunks), 2)
+
+	assert.Equal(t, hunks[0].OldStart, 1)
+	assert.Equal(t, hunks[0].OldLines, 8)
+	assert.Equal(t, hunks[0].NewStart, 1)
+	assert.Equal(t, hunks[0].NewLines, 8)
+
+	assert.Equal(t, hunks[1].OldStart, 13)
+	assert.Equal(t, hunks[1].OldLines, 7)
+	assert.Equal(t, hunks[1].NewStart, 13)
+	assert.Equal(t, hunks[1].NewLines, 7)
+	assert.Equal(t, hunks[1].Edits[0], Edit{Operation: Equal, Line: "m\n"})
+	assert.Equal(t, hunks[1].Edits[3], Edit{Operation: Deletion, Line: "p\n"})
+	assert.Equal(t, hunks[1].Edits[4], Edit{Operation: Insertion, Line: "P\n"})
+
+	assert.Equal(t, len(Hunks(Lines(lines, lines), DEFAULT_CONTEXT_LINES)), 0)
+}
@@ -0,0 +1,141 @@
+package diffs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	OURS_CONFLICT_MARKER   = "<<<<<<<"
+	BASE_CONFLICT_MARKER   = "|||||||"
+	SPLIT_CONFLICT_MARKER  = "======="
+	THEIRS_CONFLICT_MARKER = ">>>>>>>"
+)
+
+type MergeOptions struct {
+	OursLabel   string
+	BaseLabel   string
+	TheirsLabel string
+	// Keep both sides of conflicting regions, "ours" lines first, instead of emitting conflict markers.
+	Union bool
+}
+
+type MergeResult struct {
+	Lines     []string
+	Conflicts int
+}
+
+func (result *MergeResult) String() string {
+	return strings.Join(result.Lines, "")
+}
+
+// Map each "a" line index to its matching "b" line index, or -1 if the line was removed.
+func matchLines(a, b []string) []int {
+	matches := make([]int, len(a))
+	aIdx, bIdx := 0, 0
+
+	for _, edit := range Lines(a, b) {
+		switch edit.Operation {
+		case Equal:
+			matches[aIdx] = bIdx
+			aIdx++
+			bIdx++
+		case Deletion:
+			matches[aIdx] = -1
+			aIdx++
+		case Insertion:
+			bIdx++
+		}
+	}
+
+	return matches
+}
+
+// Make sure a conflict section ends with a line terminator, so the next marker starts on its own line.
+func appendConflictSection(lines []string, section []string) []string {
+	for idx, line := range section {
+		if idx == len(section)-1 && !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func (result *MergeResult) appendChunk(base, ours, theirs []string, options *MergeOptions) {
+	switch {
+	case slices.Equal(ours, theirs):
+		result.Lines = append(result.Lines, ours...)
+	case slices.Equal(base, ours):
+		result.Lines = append(result.Lines, theirs...)
+	case slices.Equal(base, theirs):
+		result.Lines = append(result.Lines, ours...)
+	case options.Union:
+		result.Lines = appendConflictSection(result.Lines, ours)
+		result.Lines = append(result.Lines, theirs...)
+	default:
+		result.Conflicts++
+		result.Lines = append(result.Lines, fmt.Sprintf("%s %s\n", OURS_CONFLICT_MARKER, options.OursLabel))
+		result.Lines = appendConflictSection(result.Lines, ours)
+		result.Lines = append(result.Lines, fmt.Sprintf("%s %s\n", BASE_CONFLICT_MARKER, options.BaseLabel))
+		result.Lines = appendConflictSection(result.Lines, base)
+		result.Lines = append(result.Lines, fmt.Sprintf("%s\n", SPLIT_CONFLICT_MARKER))
+		result.Lines = appendConflictSection(result.Lines, theirs)
+		result.Lines = append(result.Lines, fmt.Sprintf("%s %s\n", THEIRS_CONFLICT_MARKER, options.TheirsLabel))
+	}
+}
+
+// Three-way merge "ours" and "theirs" changes made on top of "base".
+//
+// Regions changed on a single side are merged automatically. Regions changed differently
+// on both sides are emitted between diff3 style conflict markers:
+//
+//	<<<<<<< ours
+//	...
+//	||||||| base
+//	...
+//	=======
+//	...
+//	>>>>>>> theirs
+func Merge3(base, ours, theirs []string, options *MergeOptions) *MergeResult {
+	result := &MergeResult{Lines: []string{}}
+	oursMatches := matchLines(base, ours)
+	theirsMatches := matchLines(base, theirs)
+	baseIdx, oursIdx, theirsIdx := 0, 0, 0
+
+	for baseIdx < len(base) || oursIdx < len(ours) || theirsIdx < len(theirs) {
+		if baseIdx < len(base) && oursMatches[baseIdx] == oursIdx && theirsMatches[baseIdx] == theirsIdx {
+			// Stable line, unchanged on both sides
+
+			result.Lines = append(result.Lines, base[baseIdx])
+			baseIdx++
+			oursIdx++
+			theirsIdx++
+			continue
+		}
+
+		// Find the next stable line, the unstable chunk ends right before it.
+		nextBaseIdx, nextOursIdx, nextTheirsIdx := len(base), len(ours), len(theirs)
+
+		for idx := baseIdx; idx < len(base); idx++ {
+			if oursMatches[idx] != -1 && theirsMatches[idx] != -1 {
+				nextBaseIdx, nextOursIdx, nextTheirsIdx = idx, oursMatches[idx], theirsMatches[idx]
+				break
+			}
+		}
+
+		result.appendChunk(
+			base[baseIdx:nextBaseIdx],
+			ours[oursIdx:nextOursIdx],
+			theirs[theirsIdx:nextTheirsIdx],
+			options,
+		)
+
+		baseIdx, oursIdx, theirsIdx = nextBaseIdx, nextOursIdx, nextTheirsIdx
+	}
+
+	return result
+}
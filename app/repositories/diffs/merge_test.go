@@ -0,0 +1,83 @@
+package diffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testMergeOptions = &MergeOptions{OursLabel: "ours", BaseLabel: "base", TheirsLabel: "theirs"}
+
+func TestMerge3NonOverlappingChanges(t *testing.T) {
+	base := SplitLines("1\n2\n3\n4\n5\n6\n7\n")
+	ours := SplitLines("one\n2\n3\n4\n5\n6\n7\n")
+	theirs := SplitLines("1\n2\n3\n4\n5\n6\nseven\neight\n")
+
+	result := Merge3(base, ours, theirs, testMergeOptions)
+
+	assert.Equal(t, result.Conflicts, 0)
+	assert.Equal(t, result.String(), "one\n2\n3\n4\n5\n6\nseven\neight\n")
+}
+
+func TestMerge3SameChanges(t *testing.T) {
+	base := SplitLines("1\n2\n3\n")
+	ours := SplitLines("1\ntwo\n3\n")
+	theirs := SplitLines("1\ntwo\n3\n")
+
+	result := Merge3(base, ours, theirs, testMergeOptions)
+
+	assert.Equal(t, result.Conflicts, 0)
+	assert.Equal(t, result.String(), "1\ntwo\n3\n")
+}
+
+func TestMerge3RemovedLines(t *testing.T) {
+	base := SplitLines("1\n2\n3\n4\n5\n")
+	ours := SplitLines("2\n3\n4\n5\n")
+	theirs := SplitLines("1\n2\n3\n4\n")
+
+	result := Merge3(base, ours, theirs, testMergeOptions)
+
+	assert.Equal(t, result.Conflicts, 0)
+	assert.Equal(t, result.String(), "2\n3\n4\n")
+}
+
+func TestMerge3OverlappingChanges(t *testing.T) {
+	base := SplitLines("1\n2\n3\n4\n5\n")
+	ours := SplitLines("1\ntwo\n3\n4\nfive\n")
+	theirs := SplitLines("1\nTWO\n3\n4\n5\n")
+
+	result := Merge3(base, ours, theirs, testMergeOptions)
+
+	assert.Equal(t, result.Conflicts, 1)
+	assert.Equal(
+		t,
+		result.String(),
+		"1\n<<<<<<< ours\ntwo\n||||||| base\n2\n=======\nTWO\n>>>>>>> theirs\n3\n4\nfive\n",
+	)
+}
+
+func TestMerge3Union(t *testing.T) {
+	base := SplitLines("1\n2\n3\n4\n5")
+	ours := SplitLines("1\ntwo\n3\n4\nfive")
+	theirs := SplitLines("1\nTWO\n3\n4\nFIVE")
+
+	result := Merge3(base, ours, theirs, &MergeOptions{Union: true})
+
+	assert.Equal(t, result.Conflicts, 0)
+	assert.Equal(t, result.String(), "1\ntwo\nTWO\n3\n4\nfive\nFIVE")
+}
+
+func TestMerge3MissingFinalNewline(t *testing.T) {
+	base := SplitLines("")
+	ours := SplitLines("ours content.")
+	theirs := SplitLines("theirs content.")
+
+	result := Merge3(base, ours, theirs, testMergeOptions)
+
+	assert.Equal(t, result.Conflicts, 1)
+	assert.Equal(
+		t,
+		result.String(),
+		"<<<<<<< ours\nours content.\n||||||| base\n=======\ntheirs content.\n>>>>>>> theirs\n",
+	)
+}
@@ -2,7 +2,6 @@ package directories
 
 import (
 	Path "path/filepath"
-	"saymow/version-manager/app/pkg/errors"
 	"strings"
 )
 
@@ -53,6 +52,8 @@ type Node struct {
 type Dir struct {
 	Path     string
 	Children map[string]*Node
+	// Tree object name of the directory, empty when it changed since it was read.
+	Hash string
 }
 
 const (
@@ -72,7 +73,7 @@ func (err *DirError) Error() string {
 
 func (fileConflict *FileConflict) IsObjectTemporary() bool {
 	// If message is "Conflict.", then a temporary object is created to represent the conflicted file content.
-	// As soon as the conflict is resolved that object should be removed.
+	// As soon as the conflict is resolved that object becomes unreachable and is pruned by the garbage collection.
 	return fileConflict.Message == "Conflict."
 }
 
@@ -109,7 +110,10 @@ func (change *Change) Conflicts(otherChange *Change) bool {
 	return change.GetHash() != otherChange.GetHash()
 }
 
-func (root *Dir) addNodeHelper(segments []string, change *Change) {
+func (root *Dir) addNodeHelper(segments []string, change *Change) error {
+	// Every directory in the path changes
+	root.Hash = ""
+
 	if len(segments) == 1 {
 		switch {
 		case change.ChangeType == Removal:
@@ -130,10 +134,10 @@ func (root *Dir) addNodeHelper(segments []string, change *Change) {
 				}
 			}
 		default:
-			errors.Error("unreachable")
+			return &DirError{"invalid change type."}
 		}
 
-		return
+		return nil
 	}
 
 	var node *Node
@@ -152,19 +156,21 @@ func (root *Dir) addNodeHelper(segments []string, change *Change) {
 		root.Children[dirNodeName] = node
 	}
 
-	node.Dir.addNodeHelper(segments[1:], change)
+	err := node.Dir.addNodeHelper(segments[1:], change)
 
 	if len(node.Dir.Children) == 0 {
 		// If we remove all entries from a directory, then we dont need it anymore.
 		// This is ensure we dont restore an empty directory.
 		delete(root.Children, dirNodeName)
 	}
+
+	return err
 }
 
-func (root *Dir) AddNode(path string, change *Change) {
+func (root *Dir) AddNode(path string, change *Change) error {
 	segments := strings.Split(path, string(Path.Separator))
 
-	root.addNodeHelper(segments, change)
+	return root.addNodeHelper(segments, change)
 }
 
 func (root *Dir) findNodeHelper(segments []string) *Node {
@@ -276,19 +282,24 @@ func (root *Dir) AbsPath(path string) (string, error) {
 	return path, nil
 }
 
-func (root *Dir) Merge(dir *Dir) *Dir {
+func (root *Dir) Merge(dir *Dir) error {
 	for _, node := range dir.CollectAllFiles() {
 		if !root.IsSubpath(node.Filepath) || node.Filepath == root.Path {
 			continue
 		}
 		normalzedPath, err := root.NormalizePath(node.Filepath)
-		errors.Check(err)
+		if err != nil {
+			return err
+		}
 
-		root.AddNode(normalzedPath, &Change{ChangeType: Creation, File: &File{
+		err = root.AddNode(normalzedPath, &Change{ChangeType: Creation, File: &File{
 			Filepath:   node.Filepath,
 			ObjectName: node.ObjectName,
 		}})
+		if err != nil {
+			return err
+		}
 	}
 
-	return root
+	return nil
 }
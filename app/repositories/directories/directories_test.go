@@ -93,6 +93,18 @@ func TestAddNodeOverrideRemovalChanges(t *testing.T) {
 	assert.Equal(t, dir.Children["a.txt"].File, &File{Filepath: "home/project/a.txt", ObjectName: "newer-version"})
 }
 
+func TestAddNodeInvalidChange(t *testing.T) {
+	dir := &Dir{
+		Path:     Path.Join("home", "project"),
+		Children: make(map[string]*Node),
+	}
+
+	err := dir.AddNode(Path.Join("a", "a.txt"), &Change{ChangeType: ChangeType(-1)})
+
+	assert.EqualError(t, err, "invalid change type.")
+	assert.Equal(t, len(dir.Children), 0)
+}
+
 func TestAddNodeRemovalChangesRemovesEmptyDir(t *testing.T) {
 	dir := &Dir{
 		Path: Path.Join("home", "project"),
@@ -488,7 +500,7 @@ func TestMerge(t *testing.T) {
 			},
 		}
 
-		dir.Merge(&Dir{
+		assert.Nil(t, dir.Merge(&Dir{
 			Path: Path.Join(getOsRoot(), "home"),
 			Children: map[string]*Node{
 				"a.txt": {
@@ -506,7 +518,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-		})
+		}))
 
 		assert.Equal(t, dir.Path, Path.Join(getOsRoot(), "home", "project"))
 		assert.Equal(t, len(dir.Children), 2)
@@ -536,7 +548,7 @@ func TestMerge(t *testing.T) {
 			},
 		}
 
-		dir.Merge(&Dir{
+		assert.Nil(t, dir.Merge(&Dir{
 			Path: Path.Join(getOsRoot(), "home"),
 			Children: map[string]*Node{
 				"a.txt": {
@@ -598,7 +610,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-		})
+		}))
 
 		assert.Equal(t, dir.Path, Path.Join(getOsRoot(), "home", "project"))
 		assert.Equal(t, len(dir.Children), 5)
@@ -635,7 +647,7 @@ func TestMerge(t *testing.T) {
 			},
 		}
 
-		dir.Merge(&Dir{
+		assert.Nil(t, dir.Merge(&Dir{
 			Path: Path.Join(getOsRoot(), "home", "project"),
 			Children: map[string]*Node{
 				"a.txt": {
@@ -675,7 +687,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-		})
+		}))
 
 		assert.Equal(t, dir.Path, Path.Join(getOsRoot(), "home", "project"))
 		assert.Equal(t, len(dir.Children), 3)
@@ -975,3 +987,45 @@ func TestChangeConflicts(t *testing.T) {
 		}),
 	)
 }
+
+func TestAddNodeClearsHashes(t *testing.T) {
+	dir := &Dir{
+		Path: Path.Join("home", "project"),
+		Children: map[string]*Node{
+			"a": {
+				NodeType: DirType,
+				Dir: &Dir{
+					Path: Path.Join("home", "project", "a"),
+					Children: map[string]*Node{
+						"b": {
+							NodeType: DirType,
+							Dir: &Dir{
+								Path:     Path.Join("home", "project", "a", "b"),
+								Children: map[string]*Node{},
+								Hash:     "b hash",
+							},
+						},
+					},
+					Hash: "a hash",
+				},
+			},
+			"c": {
+				NodeType: DirType,
+				Dir: &Dir{
+					Path:     Path.Join("home", "project", "c"),
+					Children: map[string]*Node{},
+					Hash:     "c hash",
+				},
+			},
+		},
+		Hash: "root hash",
+	}
+
+	dir.AddNode(Path.Join("a", "1.txt"), &Change{ChangeType: Creation, File: &File{Filepath: Path.Join("home", "project", "a", "1.txt")}})
+
+	assert.Equal(t, dir.Hash, "")
+	assert.Equal(t, dir.Children["a"].Dir.Hash, "")
+	// Untouched directories keep their hashes
+	assert.Equal(t, dir.Children["a"].Dir.Children["b"].Dir.Hash, "b hash")
+	assert.Equal(t, dir.Children["c"].Dir.Hash, "c hash")
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"slices"
+)
+
+// Remove the stash at a position of the stashes list, the following stashes are shifted.
+func (repository *Repository) removeStash(index int) error {
+	ids, err := repository.fs.ReadStashes()
+	if err != nil {
+		return err
+	}
+
+	return repository.fs.WriteStashes(slices.Delete(ids, index, index+1))
+}
+
+// DropStash removes a stash, given as "stash@{N}" or N. The newest stash is used if omitted.
+//
+// The stash save name is returned. The stash saves are not removed, they are pruned by CollectGarbage
+// once unreachable.
+func (repository *Repository) DropStash(stash string) (string, error) {
+	index, id, err := repository.findStash(stash)
+	if err != nil {
+		return "", err
+	}
+
+	return id, repository.removeStash(index)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropStash(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 first content."))
+	first, _ := repository.PushStash("first")
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 second content."))
+	second, _ := repository.PushStash("second")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, err := repository.DropStash("2")
+	assert.EqualError(t, err, "Validation Error: invalid stash.")
+
+	id, err := repository.DropStash("1")
+	assert.Nil(t, err)
+	assert.Equal(t, id, first.Id)
+
+	stashes, _ := repository.fs.ReadStashes()
+	assert.Equal(t, stashes, []string{second.Id})
+
+	// Check dropped stashes are restored by undo
+	repository = recordOperationHelper(t, dir, repository, "stash drop", func(repository *Repository) {
+		repository.DropStash("")
+	})
+
+	stashes, _ = repository.fs.ReadStashes()
+	assert.Equal(t, stashes, []string{})
+
+	_, err = repository.UndoOperation()
+	assert.Nil(t, err)
+
+	stashes, _ = repository.fs.ReadStashes()
+	assert.Equal(t, stashes, []string{second.Id})
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1 content.")
+}
@@ -0,0 +1,104 @@
+package filesystems
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	Path "path/filepath"
+	"strings"
+)
+
+// CherryPickState is the record of a cherry-pick stopped by conflicts, kept until it is continued or aborted.
+type CherryPickState struct {
+	// Ref picked onto and its save before the cherry-pick.
+	Ref     string
+	RefSave string
+	// Save whose changes are conflicted.
+	Current string
+	// Saves left to pick, in order.
+	Pending []string
+}
+
+func formatCherryPickState(state *CherryPickState) string {
+	var stringBuilder strings.Builder
+
+	stringBuilder.WriteString("Cherry-pick:\n\n")
+	stringBuilder.WriteString(fmt.Sprintf("%s\n%s\n%s\n", state.Ref, state.RefSave, state.Current))
+	stringBuilder.WriteString("\nPending saves:\n\n")
+
+	for _, id := range state.Pending {
+		stringBuilder.WriteString(fmt.Sprintf("%s\n", id))
+	}
+
+	return stringBuilder.String()
+}
+
+func (fileSystem *FileSystem) WriteCherryPickState(state *CherryPickState) error {
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, CHERRY_PICK_FILE_NAME), []byte(formatCherryPickState(state)))
+}
+
+func ParseCherryPickState(reader io.Reader) (*CherryPickState, error) {
+	state := &CherryPickState{Pending: []string{}}
+	scanner := bufio.NewScanner(reader)
+
+	// Skip file header lines
+	scanner.Scan()
+	scanner.Scan()
+
+	for _, field := range []*string{&state.Ref, &state.RefSave, &state.Current} {
+		if !scanner.Scan() {
+			return nil, errors.New("missing cherry-pick saves")
+		}
+
+		*field = scanner.Text()
+	}
+
+	if state.Ref == "" {
+		return nil, errors.New("missing cherry-pick ref")
+	}
+
+	// Skip pending saves header lines
+	scanner.Scan()
+	scanner.Scan()
+	scanner.Scan()
+
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+
+		state.Pending = append(state.Pending, scanner.Text())
+	}
+
+	return state, scanner.Err()
+}
+
+// Read the cherry-pick state, nil when there is no cherry-pick in progress.
+func (fileSystem *FileSystem) ReadCherryPickState() (state *CherryPickState, err error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, CHERRY_PICK_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	state, err = ParseCherryPickState(file)
+	if err != nil {
+		return nil, &CorruptCherryPickError{Err: err}
+	}
+
+	return state, nil
+}
+
+func (fileSystem *FileSystem) RemoveCherryPickState() error {
+	err := os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, CHERRY_PICK_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
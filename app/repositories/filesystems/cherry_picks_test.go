@@ -0,0 +1,55 @@
+package filesystems
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestCherryPickState(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	statePath := dir.Join(REPOSITORY_FOLDER_NAME, CHERRY_PICK_FILE_NAME)
+
+	// Check there is no cherry-pick in progress
+	state, err := fileSystem.ReadCherryPickState()
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+
+	// Check the state round trip
+	expectedState := &CherryPickState{
+		Ref:     "master",
+		RefSave: "ref-save",
+		Current: "current-save",
+		Pending: []string{"save-1", "save-2"},
+	}
+	assert.Nil(t, fileSystem.WriteCherryPickState(expectedState))
+
+	content, err := os.ReadFile(statePath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "Cherry-pick:\n\nmaster\nref-save\ncurrent-save\n\nPending saves:\n\nsave-1\nsave-2\n")
+
+	state, err = fileSystem.ReadCherryPickState()
+	assert.Nil(t, err)
+	assert.Equal(t, state, expectedState)
+
+	// Check corrupt states
+	assert.Nil(t, os.WriteFile(statePath, []byte("Cherry-pick:\n\n"), 0644))
+
+	_, err = fileSystem.ReadCherryPickState()
+	assert.EqualError(t, err, "corrupt cherry-pick state: missing cherry-pick saves")
+
+	// Check the state removal
+	assert.Nil(t, fileSystem.RemoveCherryPickState())
+	assert.Nil(t, fileSystem.RemoveCherryPickState())
+
+	state, err = fileSystem.ReadCherryPickState()
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+}
@@ -0,0 +1,187 @@
+package filesystems
+
+import "fmt"
+
+// NotRepositoryError is returned when a directory has no repository folder.
+type NotRepositoryError struct {
+	Root string
+}
+
+func (err *NotRepositoryError) Error() string {
+	return fmt.Sprintf("%s is not a repository", err.Root)
+}
+
+// CorruptSaveError is returned when a save file cannot be parsed or its content does not match its name.
+type CorruptSaveError struct {
+	Id  string
+	Err error
+}
+
+func (err *CorruptSaveError) Error() string {
+	return fmt.Sprintf("corrupt save %s: %s", err.Id, err.Err)
+}
+
+func (err *CorruptSaveError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptIndexError is returned when the index file cannot be parsed.
+type CorruptIndexError struct {
+	Err error
+}
+
+func (err *CorruptIndexError) Error() string {
+	return fmt.Sprintf("corrupt index: %s", err.Err)
+}
+
+func (err *CorruptIndexError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptRefsError is returned when the refs file cannot be parsed.
+type CorruptRefsError struct {
+	Err error
+}
+
+func (err *CorruptRefsError) Error() string {
+	return fmt.Sprintf("corrupt refs: %s", err.Err)
+}
+
+func (err *CorruptRefsError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptMergeError is returned when the merge state file cannot be parsed.
+type CorruptMergeError struct {
+	Err error
+}
+
+func (err *CorruptMergeError) Error() string {
+	return fmt.Sprintf("corrupt merge state: %s", err.Err)
+}
+
+func (err *CorruptMergeError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptCherryPickError is returned when the cherry-pick state file cannot be parsed.
+type CorruptCherryPickError struct {
+	Err error
+}
+
+func (err *CorruptCherryPickError) Error() string {
+	return fmt.Sprintf("corrupt cherry-pick state: %s", err.Err)
+}
+
+func (err *CorruptCherryPickError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptRebaseError is returned when the rebase state file cannot be parsed.
+type CorruptRebaseError struct {
+	Err error
+}
+
+func (err *CorruptRebaseError) Error() string {
+	return fmt.Sprintf("corrupt rebase state: %s", err.Err)
+}
+
+func (err *CorruptRebaseError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptReflogError is returned when a reflog file cannot be parsed.
+type CorruptReflogError struct {
+	Name string
+	Err  error
+}
+
+func (err *CorruptReflogError) Error() string {
+	return fmt.Sprintf("corrupt reflog %s: %s", err.Name, err.Err)
+}
+
+func (err *CorruptReflogError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptStashError is returned when the stash file cannot be parsed.
+type CorruptStashError struct {
+	Err error
+}
+
+func (err *CorruptStashError) Error() string {
+	return fmt.Sprintf("corrupt stash: %s", err.Err)
+}
+
+func (err *CorruptStashError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptOperationError is returned when the operation log or an operation snapshot cannot be parsed.
+type CorruptOperationError struct {
+	// Operation id, 0 for the operation log itself.
+	Id  int
+	Err error
+}
+
+func (err *CorruptOperationError) Error() string {
+	if err.Id == 0 {
+		return fmt.Sprintf("corrupt operation log: %s", err.Err)
+	}
+
+	return fmt.Sprintf("corrupt operation %d: %s", err.Id, err.Err)
+}
+
+func (err *CorruptOperationError) Unwrap() error {
+	return err.Err
+}
+
+// CorruptObjectError is returned when an object cannot be decompressed or its content does not match its name.
+type CorruptObjectError struct {
+	Name string
+	Err  error
+}
+
+func (err *CorruptObjectError) Error() string {
+	return fmt.Sprintf("corrupt object %s: %s", err.Name, err.Err)
+}
+
+func (err *CorruptObjectError) Unwrap() error {
+	return err.Err
+}
+
+// MissingObjectError is returned when a referenced object does not exist.
+type MissingObjectError struct {
+	Name string
+}
+
+func (err *MissingObjectError) Error() string {
+	return fmt.Sprintf("missing object %s", err.Name)
+}
+
+// MissingSaveError is returned when a referenced save does not exist.
+type MissingSaveError struct {
+	Id string
+}
+
+func (err *MissingSaveError) Error() string {
+	return fmt.Sprintf("missing save %s", err.Id)
+}
+
+// LockHeldError is returned when the repository lock is held by another running process.
+type LockHeldError struct {
+	Pid int
+}
+
+func (err *LockHeldError) Error() string {
+	return fmt.Sprintf("repository is locked by PID %d", err.Pid)
+}
+
+// InvalidNameError is returned when a file name cannot be stored, having a tab or a line break.
+type InvalidNameError struct {
+	Path string
+}
+
+func (err *InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid name %q, names cannot have tabs or line breaks", err.Path)
+}
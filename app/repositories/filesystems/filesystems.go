@@ -8,11 +8,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	Path "path/filepath"
-	"saymow/version-manager/app/pkg/errors"
+	"saymow/version-manager/app/repositories/attributes"
 	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/ignores"
 	"slices"
 	"strings"
 	"time"
@@ -25,14 +25,28 @@ const (
 	INDEX_FILE_NAME        = "index"
 	HEAD_FILE_NAME         = "head"
 	REFS_FILE_NAME         = "refs"
+	EXCLUDE_FILE_NAME      = "exclude"
+	LOCK_FILE_NAME         = "lock"
+	MERGE_FILE_NAME        = "merge"
+	LOGS_FOLDER_NAME       = "logs"
+	OPERATIONS_FOLDER_NAME = "operations"
+	STASH_FILE_NAME        = "stash"
+	CHERRY_PICK_FILE_NAME  = "cherry-pick"
+	REBASE_FILE_NAME       = "rebase"
 
 	INITIAL_REF_NAME = "master"
 
-	USER_FILES_PERMISSIONS = 0777
+	// Objects and metadata files are written to temporary files first, then renamed into place.
+	TEMPORARY_FILE_PREFIX = "tmp-"
+
+	USER_FILES_PERMISSIONS      = 0777
+	REPOSITORY_DIRS_PERMISSIONS = 0755
 )
 
 type Save struct {
-	Id          string
+	Id string
+	// Every checkpoint reachable from the save through its parents, parents always come before
+	// their children, so the save checkpoint is the last one.
 	Checkpoints []*Checkpoint
 }
 
@@ -40,187 +54,328 @@ type Checkpoint struct {
 	Id        string
 	Message   string
 	CreatedAt time.Time
-	Parent    string
-	Changes   []*directories.Change
+	// The checkpoint changes are relative to the first parent, merge checkpoints have the merged
+	// save as second parent.
+	Parents []string
+	// Tree object of the save file tree, empty for saves written before trees were stored.
+	Tree    string
+	Changes []*directories.Change
 }
 
 type FileSystem struct {
 	Root string
+	// Directory the repository was created in, used to read the absolute paths written before
+	// paths were stored relative to the root. See MigrateRepository.
+	LegacyRoot string
+	locked     bool
 }
 
 type Refs map[string]string
 
-func Create(root string) *FileSystem {
-	err := os.Mkdir(Path.Join(root, REPOSITORY_FOLDER_NAME), 0644)
-	errors.Check(err)
+// Close a file, reporting the close error unless another error happened before.
+func closeFile(file *os.File, err *error) {
+	if closeErr := file.Close(); closeErr != nil && *err == nil {
+		*err = closeErr
+	}
+}
+
+// Write a file through a temporary file renamed into place, so readers never see a partial write.
+func writeFileAtomic(filepath string, content []byte) (err error) {
+	tempFile, err := os.CreateTemp(Path.Dir(filepath), TEMPORARY_FILE_PREFIX+"*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	if _, err = tempFile.Write(content); err != nil {
+		return err
+	}
+	if err = tempFile.Sync(); err != nil {
+		return err
+	}
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tempFile.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), filepath)
+}
+
+func Create(root string) (*FileSystem, error) {
+	err := os.Mkdir(Path.Join(root, REPOSITORY_FOLDER_NAME), REPOSITORY_DIRS_PERMISSIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(Path.Join(root, REPOSITORY_FOLDER_NAME, INDEX_FILE_NAME), []byte("Tracked files:\r\n\r\n"), 0644)
+	if err != nil {
+		return nil, err
+	}
 
-	indexFile, err := os.Create(Path.Join(root, REPOSITORY_FOLDER_NAME, INDEX_FILE_NAME))
-	errors.Check(err)
-	defer errors.CheckFn(indexFile.Close)
+	err = os.WriteFile(Path.Join(root, REPOSITORY_FOLDER_NAME, REFS_FILE_NAME), []byte(fmt.Sprintf("Refs:\n\n%s\n\n", INITIAL_REF_NAME)), 0644)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err = indexFile.Write([]byte("Tracked files:\r\n\r\n"))
-	errors.Check(err)
+	err = os.WriteFile(Path.Join(root, REPOSITORY_FOLDER_NAME, HEAD_FILE_NAME), []byte(INITIAL_REF_NAME), 0644)
+	if err != nil {
+		return nil, err
+	}
 
-	refsFile, err := os.Create(Path.Join(root, REPOSITORY_FOLDER_NAME, REFS_FILE_NAME))
-	errors.Check(err)
-	defer errors.CheckFn(refsFile.Close)
+	err = os.Mkdir(Path.Join(root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME), REPOSITORY_DIRS_PERMISSIONS)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err = refsFile.Write([]byte(fmt.Sprintf("Refs:\n\n%s\n\n", INITIAL_REF_NAME)))
-	errors.Check(err)
+	err = os.Mkdir(Path.Join(root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME), REPOSITORY_DIRS_PERMISSIONS)
+	if err != nil {
+		return nil, err
+	}
 
-	headFile, err := os.Create(Path.Join(root, REPOSITORY_FOLDER_NAME, HEAD_FILE_NAME))
-	errors.Check(err)
-	defer errors.CheckFn(headFile.Close)
+	return &FileSystem{Root: root}, nil
+}
 
-	_, err = headFile.Write([]byte(INITIAL_REF_NAME))
-	errors.Check(err)
+// Check whether root contains a repository folder.
+func Exists(root string) bool {
+	info, err := os.Stat(Path.Join(root, REPOSITORY_FOLDER_NAME))
 
-	err = os.Mkdir(Path.Join(root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME), 0644)
-	errors.Check(err)
+	return err == nil && info.IsDir()
+}
 
-	err = os.Mkdir(Path.Join(root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME), 0644)
-	errors.Check(err)
+func Open(root string) (*FileSystem, error) {
+	if !Exists(root) {
+		return nil, &NotRepositoryError{Root: root}
+	}
 
-	return &FileSystem{Root: root}
+	return &FileSystem{Root: root}, nil
 }
 
-func Open(root string) *FileSystem {
-	return &FileSystem{Root: root}
+// Open the working directory ignore rules.
+//
+// Besides the .vcsignore files, the repository exclude file can be used to ignore paths without sharing them.
+func (fileSystem *FileSystem) OpenIgnore() (*ignores.Matcher, error) {
+	return ignores.Open(fileSystem.Root, Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, EXCLUDE_FILE_NAME))
 }
 
-func (save *Save) Contains(otherSave *Save) bool {
-	otherSaveCheckpoint := otherSave.Checkpoints[len(otherSave.Checkpoints)-1]
+// Open the working directory attributes rules.
+func (fileSystem *FileSystem) OpenAttributes() (*attributes.Matcher, error) {
+	return attributes.Open(fileSystem.Root)
+}
 
-	for idx := len(save.Checkpoints) - 1; idx >= 0; idx-- {
-		if save.Checkpoints[idx].Id == otherSaveCheckpoint.Id {
-			return true
-		}
+// Convert an absolute path to the root relative, slash separated, path stored in saves and the index.
+func (fileSystem *FileSystem) storedPath(filepath string) (string, error) {
+	relativePath, err := Path.Rel(fileSystem.Root, filepath)
+	if err != nil {
+		return "", err
+	}
+
+	if relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(Path.Separator)) {
+		return "", fmt.Errorf("path \"%s\" is outside of the repository", filepath)
+	}
+
+	return Path.ToSlash(relativePath), nil
+}
+
+// Convert a path stored in saves or the index to an absolute path.
+//
+// Absolute paths are legacy ones, they are mapped from the LegacyRoot when it is set.
+func (fileSystem *FileSystem) readStoredPath(path string) string {
+	if !Path.IsAbs(path) {
+		return Path.Join(fileSystem.Root, Path.FromSlash(path))
+	}
+	if fileSystem.LegacyRoot == "" {
+		return path
 	}
 
-	return false
+	relativePath, err := Path.Rel(fileSystem.LegacyRoot, path)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(Path.Separator)) {
+		return path
+	}
+
+	return Path.Join(fileSystem.Root, relativePath)
+}
+
+// The first line of the message, squashed saves have multi-line messages.
+func (checkpoint *Checkpoint) Subject() string {
+	subject, _, _ := strings.Cut(checkpoint.Message, "\n")
+
+	return subject
+}
+
+func (checkpoint *Checkpoint) FirstParent() string {
+	if len(checkpoint.Parents) == 0 {
+		return ""
+	}
+
+	return checkpoint.Parents[0]
+}
+
+// Check whether the other save is an ancestor of the save, or the save itself.
+func (save *Save) Contains(otherSave *Save) bool {
+	otherSaveCheckpoint := otherSave.Checkpoint()
+
+	return slices.ContainsFunc(save.Checkpoints, func(checkpoint *Checkpoint) bool {
+		return checkpoint.Id == otherSaveCheckpoint.Id
+	})
 }
 
 func (save *Save) Checkpoint() *Checkpoint {
 	return save.Checkpoints[len(save.Checkpoints)-1]
 }
 
+// Find the merge base of two saves, the common ancestor that is not an ancestor of another common ancestor.
+//
+// Since parents come before their children, the last common checkpoint cannot be the ancestor of
+// another one. When the histories cross each other more than once, any of the best common ancestors
+// is returned. It returns nil for unrelated saves.
 func (save *Save) FindFirstCommonCheckpointParent(otherSave *Save) *Checkpoint {
-	seen := make(map[string]*Checkpoint)
+	otherIds := make(map[string]bool)
 
-	for idx := len(otherSave.Checkpoints) - 2; idx >= 0; idx-- {
-		seen[otherSave.Checkpoints[idx].Id] = otherSave.Checkpoints[idx]
+	for _, checkpoint := range otherSave.Checkpoints {
+		otherIds[checkpoint.Id] = true
 	}
 
-	for idx := len(save.Checkpoints) - 2; idx >= 0; idx-- {
-		if checkpoint, ok := seen[save.Checkpoints[idx].Id]; ok {
-			return checkpoint
+	for idx := len(save.Checkpoints) - 1; idx >= 0; idx-- {
+		if otherIds[save.Checkpoints[idx].Id] {
+			return save.Checkpoints[idx]
 		}
 	}
 
 	return nil
 }
 
-func (fileSystem *FileSystem) SaveIndex(index []*directories.Change) {
-	file, err := os.OpenFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, INDEX_FILE_NAME), os.O_WRONLY|os.O_TRUNC, 0644)
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
+// Format a change as its header line and object name line, shared by the index and saves formats.
+func (fileSystem *FileSystem) formatChange(change *directories.Change) (string, error) {
+	storedPath, err := fileSystem.storedPath(change.GetPath())
+	if err != nil {
+		return "", err
+	}
+
+	switch change.ChangeType {
+	case directories.Modification:
+		return fmt.Sprintf("%s\t%s\n%s\n", storedPath, directories.MODIFIED_CHANGE, change.File.ObjectName), nil
+	case directories.Creation:
+		return fmt.Sprintf("%s\t%s\n%s\n", storedPath, directories.CREATED_CHANGE, change.File.ObjectName), nil
+	case directories.Removal:
+		return fmt.Sprintf("%s\t%s\n", storedPath, directories.REMOVAL_CHANGE), nil
+	case directories.Conflict:
+		return fmt.Sprintf("%s\t%s\t%s\n%s\n", storedPath, directories.CONFLICT_CHANGE, change.Conflict.Message, change.Conflict.ObjectName), nil
+	default:
+		return "", fmt.Errorf("invalid change type %d", change.ChangeType)
+	}
+}
+
+func (fileSystem *FileSystem) formatIndex(index []*directories.Change) (string, error) {
+	var stringBuilder strings.Builder
 
-	_, err = file.Write([]byte("Tracked files:\n\n"))
-	errors.Check(err)
+	stringBuilder.WriteString("Tracked files:\n\n")
 
 	for _, change := range index {
+		line, err := fileSystem.formatChange(change)
+		if err != nil {
+			return "", err
+		}
+
+		stringBuilder.WriteString(line)
+	}
+
+	return stringBuilder.String(), nil
+}
+
+func (fileSystem *FileSystem) SaveIndex(index []*directories.Change) error {
+	content, err := fileSystem.formatIndex(index)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, INDEX_FILE_NAME), []byte(content))
+}
+
+// Parse a change header line and its object name line, shared by the index and saves formats.
+func (fileSystem *FileSystem) parseChange(scanner *bufio.Scanner, allowConflicts bool) (*directories.Change, error) {
+	change := &directories.Change{}
+	changeHeader := strings.Split(scanner.Text(), "\t")
+	changeHeaderLen := len(changeHeader)
+
+	if changeHeaderLen < 2 || changeHeaderLen > 3 || (changeHeaderLen == 3) != (changeHeader[1] == directories.CONFLICT_CHANGE) {
+		return nil, fmt.Errorf("invalid change \"%s\"", scanner.Text())
+	}
+
+	switch {
+	case changeHeader[1] == directories.MODIFIED_CHANGE || changeHeader[1] == directories.CREATED_CHANGE:
+		if changeHeader[1] == directories.MODIFIED_CHANGE {
+			change.ChangeType = directories.Modification
+		} else {
+			change.ChangeType = directories.Creation
+		}
 
-		switch change.ChangeType {
-		case directories.Modification:
-			_, err = file.Write([]byte(fmt.Sprintf("%s\t%s\n%s\n", change.File.Filepath, directories.MODIFIED_CHANGE, change.File.ObjectName)))
-		case directories.Creation:
-			_, err = file.Write([]byte(fmt.Sprintf("%s\t%s\n%s\n", change.File.Filepath, directories.CREATED_CHANGE, change.File.ObjectName)))
-		case directories.Removal:
-			_, err = file.Write([]byte(fmt.Sprintf("%s\t%s\n", change.Removal.Filepath, directories.REMOVAL_CHANGE)))
-		case directories.Conflict:
-			_, err = file.Write([]byte(fmt.Sprintf("%s\t%s\t%s\n%s\n", change.Conflict.Filepath, directories.CONFLICT_CHANGE, change.Conflict.Message, change.Conflict.ObjectName)))
-		default:
-			errors.Error("unreachable")
+		change.File = &directories.File{Filepath: fileSystem.readStoredPath(changeHeader[0])}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("missing object name of \"%s\"", changeHeader[0])
+		}
+		change.File.ObjectName = scanner.Text()
+	case changeHeader[1] == directories.REMOVAL_CHANGE:
+		change.ChangeType = directories.Removal
+		change.Removal = &directories.FileRemoval{Filepath: fileSystem.readStoredPath(changeHeader[0])}
+	case changeHeader[1] == directories.CONFLICT_CHANGE && allowConflicts:
+		change.ChangeType = directories.Conflict
+		change.Conflict = &directories.FileConflict{Filepath: fileSystem.readStoredPath(changeHeader[0]), Message: changeHeader[2]}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("missing object name of \"%s\"", changeHeader[0])
 		}
-		errors.Check(err)
+		change.Conflict.ObjectName = scanner.Text()
+	default:
+		return nil, fmt.Errorf("invalid change \"%s\"", scanner.Text())
 	}
+
+	return change, nil
 }
 
-func (fileSystem *FileSystem) parseIndex(file *os.File) []*directories.Change {
+func (fileSystem *FileSystem) ParseIndex(reader io.Reader) ([]*directories.Change, error) {
 	var index []*directories.Change
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(reader)
 
 	// Skip file header lines
 	scanner.Scan()
 	scanner.Scan()
 
 	for scanner.Scan() {
-		change := directories.Change{}
-
-		changeHeader := strings.Split(scanner.Text(), "\t")
-		changesHeaderLen := len(changeHeader)
-
-		if changesHeaderLen < 2 || changesHeaderLen > 3 {
-			errors.Error("Invalid index format.")
-		}
-
-		switch {
-		case changeHeader[1] == directories.MODIFIED_CHANGE || changeHeader[1] == directories.CREATED_CHANGE:
-			{
-				if changeHeader[1] == directories.MODIFIED_CHANGE {
-					change.ChangeType = directories.Modification
-				} else {
-					change.ChangeType = directories.Creation
-				}
-
-				change.File = &directories.File{}
-				change.File.Filepath = changeHeader[0]
-				scanner.Scan()
-				change.File.ObjectName = scanner.Text()
-			}
-		case changeHeader[1] == directories.REMOVAL_CHANGE:
-			{
-				change.ChangeType = directories.Removal
-				change.Removal = &directories.FileRemoval{}
-				change.Removal.Filepath = changeHeader[0]
-			}
-		case changeHeader[1] == directories.CONFLICT_CHANGE:
-			{
-				if changesHeaderLen != 3 {
-					errors.Error("Invalid index format.")
-				}
-
-				change.ChangeType = directories.Conflict
-				change.Conflict = &directories.FileConflict{}
-				change.Conflict.Filepath = changeHeader[0]
-				change.Conflict.Message = changeHeader[2]
-				scanner.Scan()
-				change.Conflict.ObjectName = scanner.Text()
-			}
+		change, err := fileSystem.parseChange(scanner, true)
+		if err != nil {
+			return nil, err
 		}
 
-		index = append(index, &change)
+		index = append(index, change)
 	}
 
-	return index
+	return index, scanner.Err()
 }
 
-func (fileSystem *FileSystem) ReadIndex() []*directories.Change {
-	file, err := os.OpenFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, INDEX_FILE_NAME), os.O_RDONLY, 0644)
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
+func (fileSystem *FileSystem) ReadIndex() (index []*directories.Change, err error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, INDEX_FILE_NAME))
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	index, err = fileSystem.ParseIndex(file)
+	if err != nil {
+		return nil, &CorruptIndexError{Err: err}
+	}
 
-	return fileSystem.parseIndex(file)
+	return index, nil
 }
 
-func (fileSystem *FileSystem) ReadRefs() *Refs {
+func (fileSystem *FileSystem) ParseRefs(reader io.Reader) (*Refs, error) {
 	refs := Refs{}
-
-	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REFS_FILE_NAME))
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
-
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(reader)
 
 	// Skip file header lines
 	scanner.Scan()
@@ -230,238 +385,278 @@ func (fileSystem *FileSystem) ReadRefs() *Refs {
 		key := scanner.Text()
 
 		if !scanner.Scan() {
-			errors.Error("Invalid refs format.")
+			return nil, fmt.Errorf("missing save of ref \"%s\"", key)
 		}
 
 		refs[key] = scanner.Text()
 	}
 
-	return &refs
+	return &refs, scanner.Err()
 }
 
-func (fileSystem *FileSystem) WriteRefs(refs *Refs) {
-	file, err := os.OpenFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REFS_FILE_NAME), os.O_WRONLY|os.O_TRUNC, 0644)
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
-
-	_, err = file.Write([]byte("Refs:\n\n"))
-	errors.Check(err)
-
-	for branchName, saveName := range *refs {
-		_, err = file.Write([]byte(fmt.Sprintf("%s\n%s\n", branchName, saveName)))
-		errors.Check(err)
+func (fileSystem *FileSystem) ReadRefs() (refs *Refs, err error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REFS_FILE_NAME))
+	if err != nil {
+		return nil, err
 	}
-}
+	defer closeFile(file, &err)
 
-func (fileSystem *FileSystem) WriteHead(name string) {
-	file, err := os.OpenFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, HEAD_FILE_NAME), os.O_WRONLY|os.O_TRUNC, 0644)
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
+	refs, err = fileSystem.ParseRefs(file)
+	if err != nil {
+		return nil, &CorruptRefsError{Err: err}
+	}
 
-	_, err = file.Write([]byte(name))
-	errors.Check(err)
+	return refs, nil
 }
 
-func (fileSystem *FileSystem) parseHead(file *os.File) string {
-	buffer := make([]byte, 128)
-	n, err := file.Read(buffer)
+func formatRefs(refs *Refs) string {
+	var stringBuilder strings.Builder
+
+	stringBuilder.WriteString("Refs:\n\n")
 
-	if err != nil && err != io.EOF {
-		errors.Error(err.Error())
+	for branchName, saveName := range *refs {
+		stringBuilder.WriteString(fmt.Sprintf("%s\n%s\n", branchName, saveName))
 	}
 
-	return string(buffer[:n])
+	return stringBuilder.String()
+}
+
+func (fileSystem *FileSystem) WriteRefs(refs *Refs) error {
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REFS_FILE_NAME), []byte(formatRefs(refs)))
+}
+
+func (fileSystem *FileSystem) WriteHead(name string) error {
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, HEAD_FILE_NAME), []byte(name))
 }
 
-func (fileSystem *FileSystem) ReadHead() string {
-	file, err := os.OpenFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, HEAD_FILE_NAME), os.O_RDONLY, 0644)
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
+func (fileSystem *FileSystem) ReadHead() (string, error) {
+	content, err := os.ReadFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, HEAD_FILE_NAME))
+	if err != nil {
+		return "", err
+	}
 
-	return fileSystem.parseHead(file)
+	return string(content), nil
 }
 
-func (fileSystem *FileSystem) ReadDir(saveName string) directories.Dir {
-	dir := directories.Dir{Path: fileSystem.Root, Children: make(map[string]*directories.Node)}
-	changes := []directories.Change{}
+// Read the file tree of a save.
+//
+// The tree object of the save is read when it exists. Otherwise, the changes of the saves written
+// before trees were stored are replayed on top of the closest ancestor tree.
+func (fileSystem *FileSystem) ReadDir(saveName string) (directories.Dir, error) {
+	dir := &directories.Dir{Path: fileSystem.Root, Children: make(map[string]*directories.Node)}
+	changes := []*directories.Change{}
 
 	for saveName != "" {
-		file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, saveName))
-		errors.Check(err)
-
-		scanner := bufio.NewScanner(file)
-
-		// Skip save message
-		scanner.Scan()
-
-		// Scan parent hash
-		scanner.Scan()
-		saveName = scanner.Text()
-
-		// Skip createdAt
-		scanner.Scan()
-		// Skip newline
-		scanner.Scan()
-		// Skip warn message
-		scanner.Scan()
-		// Skip newline
-		scanner.Scan()
-		// Skip newline
-		scanner.Scan()
-		// Skip header message
-		scanner.Scan()
-		// Skip newline
-		scanner.Scan()
-
-		for scanner.Scan() {
-			change := directories.Change{}
-
-			changeHeader := strings.Split(scanner.Text(), "\t")
-
-			if len(changeHeader) != 2 {
-				errors.Error("Invalid save format.")
-			}
+		checkpoint, err := fileSystem.ReadCheckpoint(saveName)
+		if err != nil {
+			return *dir, err
+		}
 
-			if changeHeader[1] == directories.MODIFIED_CHANGE || changeHeader[1] == directories.CREATED_CHANGE {
-				if changeHeader[1] == directories.MODIFIED_CHANGE {
-					change.ChangeType = directories.Modification
-				} else {
-					change.ChangeType = directories.Creation
-				}
-
-				change.File = &directories.File{}
-				change.File.Filepath = changeHeader[0]
-				scanner.Scan()
-				change.File.ObjectName = scanner.Text()
-			} else {
-				change.ChangeType = directories.Removal
-				change.Removal = &directories.FileRemoval{}
-				change.Removal.Filepath = changeHeader[0]
+		if checkpoint.Tree != "" {
+			dir, err = fileSystem.ReadTree(checkpoint.Tree, fileSystem.Root)
+			if err != nil {
+				return directories.Dir{}, err
 			}
 
-			changes = append(changes, change)
+			break
+		}
+
+		// Checkpoints are read from the last to the first, so are their changes.
+		for idx := len(checkpoint.Changes) - 1; idx >= 0; idx-- {
+			changes = append(changes, checkpoint.Changes[idx])
 		}
 
-		errors.Check(file.Close())
+		saveName = checkpoint.FirstParent()
 	}
 
 	slices.Reverse(changes)
 
 	for _, change := range changes {
 		normalizedPath, err := dir.NormalizePath(change.GetPath())
-		errors.Check(err)
+		if err != nil {
+			return *dir, err
+		}
 
-		dir.AddNode(normalizedPath, &change)
+		if err := dir.AddNode(normalizedPath, change); err != nil {
+			return *dir, err
+		}
 	}
 
-	return dir
+	return *dir, nil
 }
 
-func (fileSystem *FileSystem) WriteObject(filepath string, file *os.File) *directories.File {
-	var buffer bytes.Buffer
-	chunkBuffer := make([]byte, 1024)
+// Write an object from a reader, the content is hashed while it is compressed to a temporary file.
+//
+// The temporary file is renamed into place once the object name is known, so memory usage is
+// bounded regardless of the content size. Existing objects are not rewritten.
+func (fileSystem *FileSystem) WriteObject(filepath string, reader io.Reader) (object *directories.File, err error) {
+	objectsPath := Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME)
+
+	tempFile, err := os.CreateTemp(objectsPath, TEMPORARY_FILE_PREFIX+"*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	hasher := sha256.New()
+	compressor := gzip.NewWriter(tempFile)
+
+	if _, err = io.Copy(io.MultiWriter(hasher, compressor), reader); err != nil {
+		return nil, err
+	}
+	if err = compressor.Close(); err != nil {
+		return nil, err
+	}
+	if err = tempFile.Close(); err != nil {
+		return nil, err
+	}
+
+	objectName := hex.EncodeToString(hasher.Sum(nil))
+	objectPath := Path.Join(objectsPath, objectName)
+
+	if _, err = os.Stat(objectPath); err == nil {
+		// Objects are content addressed, the existing one has the same content.
+		return &directories.File{Filepath: filepath, ObjectName: objectName}, os.Remove(tempFile.Name())
+	}
+	if err = os.Chmod(tempFile.Name(), 0644); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(tempFile.Name(), objectPath); err != nil {
+		return nil, err
+	}
+
+	return &directories.File{Filepath: filepath, ObjectName: objectName}, nil
+}
+
+func (fileSystem *FileSystem) WriteObjectContent(filepath string, content []byte) (*directories.File, error) {
+	return fileSystem.WriteObject(filepath, bytes.NewReader(content))
+}
+
+// List the objects in the objects folder along with their modification time.
+func (fileSystem *FileSystem) ListObjects() (map[string]time.Time, error) {
+	entries, err := os.ReadDir(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME))
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		n, err := file.Read(chunkBuffer)
+	objects := make(map[string]time.Time)
 
-		if err != nil && err != io.EOF {
-			log.Fatal(err)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), TEMPORARY_FILE_PREFIX) {
+			continue
 		}
-		if n == 0 {
-			break
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
 		}
 
-		_, err = buffer.Write(chunkBuffer[:n])
-		errors.Check(err)
+		objects[entry.Name()] = info.ModTime()
 	}
 
-	hasher := sha256.New()
-	_, err := hasher.Write(buffer.Bytes())
-	errors.Check(err)
-	hash := hasher.Sum(nil)
-
-	objectName := hex.EncodeToString(hash)
-	objectFile, err := os.Create(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, objectName))
-	errors.Check(err)
-	defer errors.CheckFn(objectFile.Close)
+	return objects, nil
+}
 
-	compressor := gzip.NewWriter(objectFile)
-	_, err = compressor.Write(buffer.Bytes())
-	errors.Check(err)
-	defer errors.CheckFn(compressor.Close)
+func (fileSystem *FileSystem) ObjectExists(name string) bool {
+	_, err := os.Stat(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, name))
 
-	return &directories.File{Filepath: filepath, ObjectName: objectName}
+	return err == nil
 }
 
-func (fileSystem *FileSystem) RemoveObject(name string) {
-	err := os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, name))
-	errors.Check(err)
+func (fileSystem *FileSystem) RemoveObject(name string) error {
+	return os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, name))
 }
 
-func (fileSystem *FileSystem) WriteCheckpoint(save *Checkpoint) string {
+// Save messages are stored on a single line, their line breaks and backslashes are escaped.
+var messageEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+
+func unescapeMessage(line string) string {
 	var stringBuilder strings.Builder
 
-	_, err := stringBuilder.Write([]byte(fmt.Sprintf("%s\n", save.Message)))
-	errors.Check(err)
+	for idx := 0; idx < len(line); idx++ {
+		if line[idx] == '\\' && idx+1 < len(line) {
+			switch line[idx+1] {
+			case '\\':
+				stringBuilder.WriteByte('\\')
+				idx++
+				continue
+			case 'n':
+				stringBuilder.WriteByte('\n')
+				idx++
+				continue
+			}
+		}
 
-	_, err = stringBuilder.Write([]byte(fmt.Sprintf("%s\n", save.Parent)))
-	errors.Check(err)
+		stringBuilder.WriteByte(line[idx])
+	}
 
-	_, err = stringBuilder.Write([]byte(fmt.Sprintf("%s\n\n", save.CreatedAt.Format(time.Layout))))
-	errors.Check(err)
+	return stringBuilder.String()
+}
+
+func (fileSystem *FileSystem) WriteCheckpoint(save *Checkpoint) (string, error) {
+	var stringBuilder strings.Builder
 
-	_, err = stringBuilder.Write([]byte("Please do not edit the lines below.\n\n\nFiles:\n\n"))
-	errors.Check(err)
+	stringBuilder.WriteString(fmt.Sprintf("%s\n", messageEscaper.Replace(save.Message)))
+	stringBuilder.WriteString(fmt.Sprintf("%s\n", strings.Join(save.Parents, " ")))
+	stringBuilder.WriteString(fmt.Sprintf("%s\n", save.CreatedAt.Format(time.Layout)))
+	stringBuilder.WriteString(fmt.Sprintf("%s\n", save.Tree))
+	stringBuilder.WriteString("Please do not edit the lines below.\n\n\nFiles:\n\n")
 
 	for _, change := range save.Changes {
-		if change.ChangeType == directories.Modification {
-			_, err = stringBuilder.Write([]byte(fmt.Sprintf("%s\t%s\n%s\n", change.File.Filepath, directories.MODIFIED_CHANGE, change.File.ObjectName)))
-		} else if change.ChangeType == directories.Creation {
-			_, err = stringBuilder.Write([]byte(fmt.Sprintf("%s\t%s\n%s\n", change.File.Filepath, directories.CREATED_CHANGE, change.File.ObjectName)))
-		} else {
-			_, err = stringBuilder.Write([]byte(fmt.Sprintf("%s\t%s\n", change.Removal.Filepath, directories.REMOVAL_CHANGE)))
+		if change.ChangeType == directories.Conflict {
+			return "", fmt.Errorf("cannot save conflicted file \"%s\"", change.GetPath())
+		}
+
+		line, err := fileSystem.formatChange(change)
+		if err != nil {
+			return "", err
 		}
-		errors.Check(err)
+
+		stringBuilder.WriteString(line)
 	}
 
 	saveContent := stringBuilder.String()
 
 	hasher := sha256.New()
-	_, err = hasher.Write([]byte(saveContent))
-	errors.Check(err)
-	hash := hasher.Sum(nil)
+	hasher.Write([]byte(saveContent))
+	saveName := hex.EncodeToString(hasher.Sum(nil))
 
-	saveName := hex.EncodeToString(hash)
-
-	file, err := os.Create(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, saveName))
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
-
-	_, err = file.Write([]byte(saveContent))
-	errors.Check(err)
+	err := writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, saveName), []byte(saveContent))
+	if err != nil {
+		return "", err
+	}
 
-	return saveName
+	return saveName, nil
 }
 
-func (fileSystem *FileSystem) ParseCheckpoint(id string, file *os.File) *Checkpoint {
+func (fileSystem *FileSystem) ParseCheckpoint(id string, reader io.Reader) (*Checkpoint, error) {
 	checkpoint := &Checkpoint{}
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(reader)
 
 	checkpoint.Id = id
 
 	scanner.Scan()
-	checkpoint.Message = scanner.Text()
+	checkpoint.Message = unescapeMessage(scanner.Text())
 
+	// Space separated, blank for the first save
 	scanner.Scan()
-	checkpoint.Parent = scanner.Text()
+	checkpoint.Parents = strings.Fields(scanner.Text())
 
 	scanner.Scan()
 	createdAt, err := time.Parse(time.Layout, scanner.Text())
-	errors.Check(err)
+	if err != nil {
+		return nil, err
+	}
 	checkpoint.CreatedAt = createdAt
 
-	// skip newline
+	// Blank for saves written before trees were stored
 	scanner.Scan()
+	checkpoint.Tree = strings.TrimSpace(scanner.Text())
 	// skip warn message
 	scanner.Scan()
 	// skip newline
@@ -474,166 +669,327 @@ func (fileSystem *FileSystem) ParseCheckpoint(id string, file *os.File) *Checkpo
 	scanner.Scan()
 
 	for scanner.Scan() {
-		change := &directories.Change{}
-
-		changeHeader := strings.Split(scanner.Text(), "\t")
-
-		if len(changeHeader) != 2 {
-			errors.Error("Invalid save format.")
-		}
-
-		if changeHeader[1] == directories.MODIFIED_CHANGE || changeHeader[1] == directories.CREATED_CHANGE {
-			if changeHeader[1] == directories.MODIFIED_CHANGE {
-				change.ChangeType = directories.Modification
-			} else {
-				change.ChangeType = directories.Creation
-			}
-
-			change.File = &directories.File{}
-			change.File.Filepath = changeHeader[0]
-			scanner.Scan()
-			change.File.ObjectName = scanner.Text()
-		} else {
-			change.ChangeType = directories.Removal
-			change.Removal = &directories.FileRemoval{}
-			change.Removal.Filepath = changeHeader[0]
+		change, err := fileSystem.parseChange(scanner, false)
+		if err != nil {
+			return nil, err
 		}
 
 		checkpoint.Changes = append(checkpoint.Changes, change)
 	}
 
-	return checkpoint
+	return checkpoint, scanner.Err()
 }
 
-func (fileSystem *FileSystem) ReadSave(checkpointId string) *Save {
-	save := &Save{Id: checkpointId}
-
-	checkpointFile, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, checkpointId))
+func (fileSystem *FileSystem) ReadCheckpoint(id string) (checkpoint *Checkpoint, err error) {
+	checkpointFile, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, id))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil
+			return nil, &MissingSaveError{Id: id}
 		}
 
-		errors.Error(err.Error())
+		return nil, err
+	}
+	defer closeFile(checkpointFile, &err)
+
+	checkpoint, err = fileSystem.ParseCheckpoint(id, checkpointFile)
+	if err != nil {
+		return nil, &CorruptSaveError{Id: id, Err: err}
+	}
+
+	return checkpoint, nil
+}
+
+// List the ids of every checkpoint in the saves folder.
+func (fileSystem *FileSystem) ListCheckpoints() ([]string, error) {
+	entries, err := os.ReadDir(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME))
+	if err != nil {
+		return nil, err
 	}
-	defer errors.CheckFn(checkpointFile.Close)
 
-	save.Checkpoints = append(save.Checkpoints, fileSystem.ParseCheckpoint(checkpointId, checkpointFile))
+	ids := []string{}
 
-	for save.Checkpoints[len(save.Checkpoints)-1].Parent != "" {
-		checkpointId = save.Checkpoints[len(save.Checkpoints)-1].Parent
-		checkpointFile, err = os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, checkpointId))
-		errors.Check(err)
-		save.Checkpoints = append(save.Checkpoints, fileSystem.ParseCheckpoint(checkpointId, checkpointFile))
-		errors.Check(checkpointFile.Close())
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), TEMPORARY_FILE_PREFIX) {
+			ids = append(ids, entry.Name())
+		}
 	}
 
-	slices.Reverse(save.Checkpoints)
+	return ids, nil
+}
 
-	return save
+func (fileSystem *FileSystem) RemoveCheckpoint(id string) error {
+	return os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, id))
 }
 
-func (fileSystem *FileSystem) ReadDirFile(file *directories.File) bytes.Buffer {
-	objectFile, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, file.ObjectName))
-	errors.Check(err)
-	defer errors.CheckFn(objectFile.Close)
+// Read a save and every checkpoint reachable from it.
+//
+// Checkpoints are sorted from the newest to the oldest, a checkpoint only comes after all its
+// children. Checkpoints with the same creation date follow the first parent line first. The result
+// is then reversed, so parents come before their children.
+func (fileSystem *FileSystem) ReadSave(checkpointId string) (*Save, error) {
+	save := &Save{Id: checkpointId, Checkpoints: []*Checkpoint{}}
+	checkpoints := make(map[string]*Checkpoint)
+	pending := []string{checkpointId}
+
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if id == "" || checkpoints[id] != nil {
+			continue
+		}
 
-	decompressor, err := gzip.NewReader(objectFile)
-	errors.Check(err)
-	defer errors.CheckFn(decompressor.Close)
+		checkpoint, err := fileSystem.ReadCheckpoint(id)
+		if err != nil {
+			return nil, err
+		}
 
-	var buffer bytes.Buffer
-	tmpBuffer := make([]byte, 128)
+		checkpoints[id] = checkpoint
+		pending = append(pending, checkpoint.Parents...)
+	}
 
-	for {
-		n, err := decompressor.Read(tmpBuffer)
+	childrenCount := make(map[string]int)
+	for _, checkpoint := range checkpoints {
+		for _, parent := range checkpoint.Parents {
+			childrenCount[parent]++
+		}
+	}
+
+	ready := []*Checkpoint{}
+	if checkpoint, ok := checkpoints[checkpointId]; ok {
+		ready = append(ready, checkpoint)
+	}
 
-		if err != nil && err != io.EOF {
-			errors.Error(err.Error())
+	for len(ready) > 0 {
+		// The newest ready checkpoint, the last pushed one on ties
+		idx := len(ready) - 1
+		for otherIdx := len(ready) - 2; otherIdx >= 0; otherIdx-- {
+			if ready[otherIdx].CreatedAt.After(ready[idx].CreatedAt) {
+				idx = otherIdx
+			}
 		}
-		if n == 0 {
-			break
+
+		checkpoint := ready[idx]
+		ready = slices.Delete(ready, idx, idx+1)
+		save.Checkpoints = append(save.Checkpoints, checkpoint)
+
+		// Pushed in reverse, so the first parent is the last pushed
+		for parentIdx := len(checkpoint.Parents) - 1; parentIdx >= 0; parentIdx-- {
+			parent := checkpoint.Parents[parentIdx]
+
+			childrenCount[parent]--
+			if childrenCount[parent] == 0 {
+				ready = append(ready, checkpoints[parent])
+			}
 		}
+	}
+
+	slices.Reverse(save.Checkpoints)
+
+	return save, nil
+}
 
-		buffer.Write(tmpBuffer[:n])
+type objectReader struct {
+	name         string
+	file         *os.File
+	decompressor *gzip.Reader
+}
+
+func (reader *objectReader) Read(p []byte) (int, error) {
+	n, err := reader.decompressor.Read(p)
+	if err != nil && err != io.EOF {
+		return n, &CorruptObjectError{Name: reader.name, Err: err}
 	}
 
-	return buffer
+	return n, err
 }
 
-func (fileSystem *FileSystem) createFile(file *directories.File) {
-	sourceFile, err := os.OpenFile(file.Filepath, os.O_WRONLY|os.O_TRUNC, USER_FILES_PERMISSIONS)
+func (reader *objectReader) Close() error {
+	reader.decompressor.Close()
+
+	return reader.file.Close()
+}
+
+// Open an object decompressed content.
+//
+// Decompression failures are reported as *CorruptObjectError, the caller must close the reader.
+func (fileSystem *FileSystem) OpenObject(name string) (io.ReadCloser, error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, name))
 	if err != nil {
-		if !os.IsNotExist(err) {
-			errors.Error(err.Error())
+		if os.IsNotExist(err) {
+			return nil, &MissingObjectError{Name: name}
 		}
 
-		sourceFile, err = os.Create(file.Filepath)
-		errors.Check(err)
+		return nil, err
+	}
+
+	decompressor, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+
+		return nil, &CorruptObjectError{Name: name, Err: err}
 	}
-	defer errors.CheckFn(sourceFile.Close)
 
-	objectFile, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, file.ObjectName))
-	errors.Check(err)
-	defer errors.CheckFn(objectFile.Close)
+	return &objectReader{name: name, file: file, decompressor: decompressor}, nil
+}
 
-	decompressor, err := gzip.NewReader(objectFile)
-	errors.Check(err)
-	defer errors.CheckFn(decompressor.Close)
+// Read an object whole decompressed content, prefer OpenObject for content that may be large.
+func (fileSystem *FileSystem) ReadDirFile(file *directories.File) (bytes.Buffer, error) {
+	var buffer bytes.Buffer
 
-	buffer := make([]byte, 256)
+	reader, err := fileSystem.OpenObject(file.ObjectName)
+	if err != nil {
+		return buffer, err
+	}
+	defer reader.Close()
 
-	for {
-		n, err := decompressor.Read(buffer)
+	_, err = io.Copy(&buffer, reader)
 
-		if err != nil && err != io.EOF {
-			errors.Error(err.Error())
-		}
-		if n == 0 {
-			break
-		}
+	return buffer, err
+}
 
-		_, err = sourceFile.Write(buffer[:n])
-		errors.Check(err)
+func (fileSystem *FileSystem) createFile(file *directories.File) (err error) {
+	reader, err := fileSystem.OpenObject(file.ObjectName)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	sourceFile, err := os.Create(file.Filepath)
+	if err != nil {
+		return err
 	}
+	defer closeFile(sourceFile, &err)
+
+	_, err = io.Copy(sourceFile, reader)
+
+	return err
 }
 
-func (fileSystem *FileSystem) CreateNode(node *directories.Node) {
+func (fileSystem *FileSystem) CreateNode(node *directories.Node) error {
 	if node.NodeType == directories.FileType {
-		fileSystem.createFile(node.File)
-		return
+		return fileSystem.createFile(node.File)
 	}
 
 	err := os.Mkdir(node.Dir.Path, USER_FILES_PERMISSIONS)
-	errors.Check(err)
+	if err != nil && !os.IsExist(err) {
+		// The directory may have been kept because of ignored files.
+		return err
+	}
+
+	return nil
 }
 
-// Safely remove a directory
-//
-// This helper prevents the .repository dir to be removed
-func (fileSystem *FileSystem) SafeRemoveWorkingDir(path string) {
-	if path != fileSystem.Root {
-		err := os.RemoveAll(path)
-		errors.Check(err)
-		return
+// Remove the working directory entries of a directory, except for the ignored ones.
+func (fileSystem *FileSystem) removeWorkingDirEntries(path string, ignore *ignores.Matcher) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
 	}
 
-	entries, err := os.ReadDir(fileSystem.Root)
-	errors.Check(err)
-
 	for _, entry := range entries {
-		if entry.Name() == REPOSITORY_FOLDER_NAME {
+		filepath := Path.Join(path, entry.Name())
+
+		if filepath == Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME) {
 			continue
 		}
 
-		filepath := Path.Join(fileSystem.Root, entry.Name())
+		ignored, err := ignore.IsIgnored(filepath, entry.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			continue
+		}
 
 		if entry.IsDir() {
-			err := os.RemoveAll(filepath)
-			errors.Check(err)
-		} else {
+			if err := fileSystem.removeWorkingDirEntries(filepath, ignore); err != nil {
+				return err
+			}
+
 			err := os.Remove(filepath)
-			errors.Check(err)
+			if err != nil && !isDirNotEmpty(filepath) {
+				return err
+			}
+		} else {
+			if err := os.Remove(filepath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isDirNotEmpty(path string) bool {
+	entries, err := os.ReadDir(path)
+
+	return err == nil && len(entries) > 0
+}
+
+// Safely remove a directory
+//
+// This helper prevents the .repository dir and the ignored files to be removed
+func (fileSystem *FileSystem) SafeRemoveWorkingDir(path string, ignore *ignores.Matcher) error {
+	if path != fileSystem.Root {
+		ignored, err := ignore.IsIgnored(path, true)
+		if err != nil || ignored {
+			return err
 		}
+
+		if err := fileSystem.removeWorkingDirEntries(path, ignore); err != nil {
+			return err
+		}
+
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) && !isDirNotEmpty(path) {
+			return err
+		}
+
+		return nil
+	}
+
+	return fileSystem.removeWorkingDirEntries(fileSystem.Root, ignore)
+}
+
+// Check the object content hash matches its name.
+func (fileSystem *FileSystem) VerifyObject(name string) error {
+	reader, err := fileSystem.OpenObject(name)
+	if err != nil {
+		return err
 	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	if _, err = io.Copy(hasher, reader); err != nil {
+		return err
+	}
+
+	if hash := hex.EncodeToString(hasher.Sum(nil)); hash != name {
+		return &CorruptObjectError{Name: name, Err: fmt.Errorf("content hash is %s", hash)}
+	}
+
+	return nil
+}
+
+// Check the save file hash matches its name and parse it.
+func (fileSystem *FileSystem) VerifyCheckpoint(id string) (*Checkpoint, error) {
+	content, err := os.ReadFile(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, id))
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	hasher.Write(content)
+
+	if hash := hex.EncodeToString(hasher.Sum(nil)); hash != id {
+		return nil, &CorruptSaveError{Id: id, Err: fmt.Errorf("content hash is %s", hash)}
+	}
+
+	checkpoint, err := fileSystem.ParseCheckpoint(id, bytes.NewReader(content))
+	if err != nil {
+		return nil, &CorruptSaveError{Id: id, Err: err}
+	}
+
+	return checkpoint, nil
 }
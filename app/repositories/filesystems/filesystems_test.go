@@ -1,35 +1,42 @@
 package filesystems
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	Path "path/filepath"
 	"saymow/version-manager/app/repositories/directories"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
 )
 
 func TestSaveContains(t *testing.T) {
 	s0 := &Checkpoint{
 		Id:        "s0",
-		Parent:    "",
+		Parents:   []string{},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	s1 := &Checkpoint{
 		Id:        "s1",
-		Parent:    "s0",
+		Parents:   []string{"s0"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	s2 := &Checkpoint{
 		Id:        "s2",
-		Parent:    "s1",
+		Parents:   []string{"s1"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	s3 := &Checkpoint{
 		Id:        "s3",
-		Parent:    "s2",
+		Parents:   []string{"s2"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
@@ -265,13 +272,13 @@ func TestSaveContains(t *testing.T) {
 func TestSaveFindFirstCommonParent(t *testing.T) {
 	s0 := &Checkpoint{
 		Id:        "s0",
-		Parent:    "",
+		Parents:   []string{},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	s1 := &Checkpoint{
 		Id:        "s1",
-		Parent:    "s0",
+		Parents:   []string{"s0"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
@@ -280,19 +287,19 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 
 	as2 := &Checkpoint{
 		Id:        "as2",
-		Parent:    "s1",
+		Parents:   []string{"s1"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	as3 := &Checkpoint{
 		Id:        "as3",
-		Parent:    "as2",
+		Parents:   []string{"as2"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	as4 := &Checkpoint{
 		Id:        "as4",
-		Parent:    "as3",
+		Parents:   []string{"as3"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
@@ -301,13 +308,13 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 
 	bs2 := &Checkpoint{
 		Id:        "bs2",
-		Parent:    "s1",
+		Parents:   []string{"s1"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
 	bs3 := &Checkpoint{
 		Id:        "bs3",
-		Parent:    "bs2",
+		Parents:   []string{"bs2"},
 		CreatedAt: time.Now(),
 		Changes:   []*directories.Change{},
 	}
@@ -432,6 +439,8 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 		s0,
 	)
 
+	// A save is its own merge base with its ancestors
+
 	save = &Save{
 		Id:          "",
 		Checkpoints: []*Checkpoint{s0, s1, as2, as3, as4},
@@ -444,7 +453,7 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 				Checkpoints: []*Checkpoint{as2, as3},
 			},
 		),
-		as2,
+		as3,
 	)
 
 	save = &Save{
@@ -459,7 +468,7 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 				Checkpoints: []*Checkpoint{s0, s1, as2, as3, as4},
 			},
 		),
-		as2,
+		as3,
 	)
 
 	save = &Save{
@@ -474,14 +483,14 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 				Checkpoints: []*Checkpoint{s0, s1},
 			},
 		),
-		s0,
+		s1,
 	)
 
 	save = &Save{
 		Id:          "",
 		Checkpoints: []*Checkpoint{s0},
 	}
-	assert.Nil(
+	assert.Equal(
 		t,
 		save.FindFirstCommonCheckpointParent(
 			&Save{
@@ -489,20 +498,176 @@ func TestSaveFindFirstCommonParent(t *testing.T) {
 				Checkpoints: []*Checkpoint{s0, s1},
 			},
 		),
+		s0,
 	)
 
 	save = &Save{
 		Id:          "",
 		Checkpoints: []*Checkpoint{s0, s1},
 	}
+	assert.Equal(
+		t,
+		save.FindFirstCommonCheckpointParent(
+			&Save{
+				Id:          "",
+				Checkpoints: []*Checkpoint{s0},
+			},
+		),
+		s0,
+	)
 
+	// Unrelated saves
+
+	save = &Save{
+		Id:          "",
+		Checkpoints: []*Checkpoint{as2, as3},
+	}
 	assert.Nil(
 		t,
 		save.FindFirstCommonCheckpointParent(
 			&Save{
 				Id:          "",
-				Checkpoints: []*Checkpoint{s0},
+				Checkpoints: []*Checkpoint{bs2, bs3},
 			},
 		),
 	)
+
+	// Branch b merged into branch a, then both moved on
+
+	m := &Checkpoint{
+		Id:        "m",
+		Parents:   []string{"as3", "bs3"},
+		CreatedAt: time.Now(),
+		Changes:   []*directories.Change{},
+	}
+	bs4 := &Checkpoint{
+		Id:        "bs4",
+		Parents:   []string{"bs3"},
+		CreatedAt: time.Now(),
+		Changes:   []*directories.Change{},
+	}
+
+	save = &Save{
+		Id:          "",
+		Checkpoints: []*Checkpoint{s0, s1, as2, as3, bs2, bs3, m},
+	}
+	assert.Equal(
+		t,
+		save.FindFirstCommonCheckpointParent(
+			&Save{
+				Id:          "",
+				Checkpoints: []*Checkpoint{s0, s1, bs2, bs3, bs4},
+			},
+		),
+		bs3,
+	)
+
+	save = &Save{
+		Id:          "",
+		Checkpoints: []*Checkpoint{s0, s1, bs2, bs3, bs4},
+	}
+	assert.Equal(
+		t,
+		save.FindFirstCommonCheckpointParent(
+			&Save{
+				Id:          "",
+				Checkpoints: []*Checkpoint{s0, s1, as2, as3, bs2, bs3, m},
+			},
+		),
+		bs3,
+	)
+}
+
+func TestWriteObject(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	objectsPath := dir.Join(REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME)
+	content := strings.Repeat("large content.\n", 100000)
+
+	hasher := sha256.New()
+	hasher.Write([]byte(content))
+	objectName := hex.EncodeToString(hasher.Sum(nil))
+
+	// Check the object is hashed, compressed and renamed into place
+	object, err := fileSystem.WriteObject(dir.Join("a.txt"), strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, object, &directories.File{Filepath: dir.Join("a.txt"), ObjectName: objectName})
+
+	entries, err := os.ReadDir(objectsPath)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Name(), objectName)
+
+	info, err := entries[0].Info()
+	assert.Nil(t, err)
+	assert.Less(t, info.Size(), int64(len(content)))
+
+	// Check the object content is streamed back
+	reader, err := fileSystem.OpenObject(objectName)
+	assert.Nil(t, err)
+	readContent, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, string(readContent), content)
+
+	// Check existing objects are not rewritten
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.Nil(t, os.Chtimes(Path.Join(objectsPath, objectName), past, past))
+
+	object, err = fileSystem.WriteObject(dir.Join("b.txt"), strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, object, &directories.File{Filepath: dir.Join("b.txt"), ObjectName: objectName})
+
+	objects, err := fileSystem.ListObjects()
+	assert.Nil(t, err)
+	assert.Equal(t, objects, map[string]time.Time{objectName: past})
+
+	entries, err = os.ReadDir(objectsPath)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 1)
+}
+
+func TestOpenObject(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	_, err = fileSystem.OpenObject("missing")
+	assert.Equal(t, err, &MissingObjectError{Name: "missing"})
+
+	assert.Nil(t, os.WriteFile(dir.Join(REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, "corrupt"), []byte("not gzip"), 0644))
+
+	_, err = fileSystem.OpenObject("corrupt")
+	var corruptObjectErr *CorruptObjectError
+	assert.ErrorAs(t, err, &corruptObjectErr)
+	assert.Equal(t, corruptObjectErr.Name, "corrupt")
+}
+
+func TestCheckpointMessage(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	message := "Squashed save.\n\nFix C:\\new\\path, keep \\n as is."
+
+	id, err := fileSystem.WriteCheckpoint(&Checkpoint{Message: message, Parents: []string{}, Changes: []*directories.Change{}, CreatedAt: time.Now()})
+	assert.Nil(t, err)
+
+	// Check the message is stored on a single line
+	content, err := os.ReadFile(dir.Join(REPOSITORY_FOLDER_NAME, SAVES_FOLDER_NAME, id))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "Squashed save.\\n\\nFix C:\\\\new\\\\path, keep \\\\n as is.\n"))
+
+	checkpoint, err := fileSystem.ReadCheckpoint(id)
+	assert.Nil(t, err)
+	assert.Equal(t, checkpoint.Message, message)
+	assert.Equal(t, checkpoint.Subject(), "Squashed save.")
 }
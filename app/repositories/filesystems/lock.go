@@ -0,0 +1,145 @@
+package filesystems
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	Path "path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Check whether a process is running, signal 0 only checks the process existence.
+func isProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Create the lock file with the current process PID.
+//
+// The PID is written to a temporary file which is hard linked as the lock file, so the lock file
+// is never seen empty and linking fails if it already exists.
+func (fileSystem *FileSystem) createLockFile(lockPath string) error {
+	tempFile, err := os.CreateTemp(Path.Dir(lockPath), TEMPORARY_FILE_PREFIX+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = fmt.Fprintf(tempFile, "%d\n", os.Getpid())
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Link(tempFile.Name(), lockPath)
+}
+
+// Read the PID of the lock owner, 0 is returned when the lock file cannot be parsed.
+func readLockOwner(lockPath string) (int, error) {
+	content, err := os.ReadFile(lockPath)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, nil
+	}
+
+	return pid, nil
+}
+
+// Remove a stale lock, whose owner was found not running.
+//
+// The lock file is renamed first, so the lock file removed is the one checked: another process may
+// have removed the stale lock and acquired the lock in the meantime. Its owner is checked again once
+// renamed, a lock held by a running process is put back. Only one process can rename the lock file,
+// the others retry acquiring the lock.
+func removeStaleLock(lockPath string) error {
+	stalePath := Path.Join(Path.Dir(lockPath), fmt.Sprintf("%s%s-%d", TEMPORARY_FILE_PREFIX, LOCK_FILE_NAME, os.Getpid()))
+
+	if err := os.Rename(lockPath, stalePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+	defer os.Remove(stalePath)
+
+	pid, err := readLockOwner(stalePath)
+	if err != nil {
+		return err
+	}
+	if !isProcessAlive(pid) {
+		return nil
+	}
+
+	// Linking does not replace a lock acquired since the rename
+	if err := os.Link(stalePath, lockPath); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	return &LockHeldError{Pid: pid}
+}
+
+// Acquire the repository lock.
+//
+// A *LockHeldError is returned when another running process holds the lock. Locks left behind by
+// processes that are not running anymore are stale, they are removed and the lock is acquired.
+func (fileSystem *FileSystem) Lock() error {
+	lockPath := Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, LOCK_FILE_NAME)
+
+	for {
+		err := fileSystem.createLockFile(lockPath)
+		if err == nil {
+			fileSystem.locked = true
+
+			return nil
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		pid, err := readLockOwner(lockPath)
+		if os.IsNotExist(err) {
+			// Released in the meantime
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if isProcessAlive(pid) {
+			return &LockHeldError{Pid: pid}
+		}
+
+		if err := removeStaleLock(lockPath); err != nil {
+			return err
+		}
+	}
+}
+
+// Release the repository lock, if it is held by this file system.
+func (fileSystem *FileSystem) Unlock() error {
+	if !fileSystem.locked {
+		return nil
+	}
+
+	fileSystem.locked = false
+
+	return os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, LOCK_FILE_NAME))
+}
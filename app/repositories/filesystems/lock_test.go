@@ -0,0 +1,95 @@
+package filesystems
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestLock(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	_, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	lockPath := dir.Join(REPOSITORY_FOLDER_NAME, LOCK_FILE_NAME)
+	fileSystem, _ := Open(dir.Path())
+	otherFileSystem, _ := Open(dir.Path())
+
+	// Check the lock is exclusive
+	{
+		assert.Nil(t, fileSystem.Lock())
+
+		pid, err := readLockOwner(lockPath)
+		assert.Nil(t, err)
+		assert.Equal(t, pid, os.Getpid())
+
+		assert.Equal(t, otherFileSystem.Lock(), &LockHeldError{Pid: os.Getpid()})
+		assert.EqualError(t, otherFileSystem.Lock(), "repository is locked by PID "+strconv.Itoa(os.Getpid()))
+
+		// Only the lock owner releases the lock
+		assert.Nil(t, otherFileSystem.Unlock())
+		assert.FileExists(t, lockPath)
+
+		assert.Nil(t, fileSystem.Unlock())
+		assert.NoFileExists(t, lockPath)
+
+		assert.Nil(t, otherFileSystem.Lock())
+		assert.Nil(t, otherFileSystem.Unlock())
+	}
+
+	// Check stale locks are removed
+	{
+		// PIDs are bounded by 2^22 on Linux, so this process cannot be running.
+		assert.Nil(t, os.WriteFile(lockPath, []byte("99999999\n"), 0644))
+		assert.Nil(t, fileSystem.Lock())
+
+		pid, err := readLockOwner(lockPath)
+		assert.Nil(t, err)
+		assert.Equal(t, pid, os.Getpid())
+		assert.Nil(t, fileSystem.Unlock())
+
+		assert.Nil(t, os.WriteFile(lockPath, []byte("garbage"), 0644))
+		assert.Nil(t, fileSystem.Lock())
+		assert.Nil(t, fileSystem.Unlock())
+
+		// A lock acquired by a running process since it was found stale is put back
+		assert.Nil(t, fileSystem.Lock())
+		assert.Equal(t, removeStaleLock(lockPath), &LockHeldError{Pid: os.Getpid()})
+
+		pid, err = readLockOwner(lockPath)
+		assert.Nil(t, err)
+		assert.Equal(t, pid, os.Getpid())
+		assert.Nil(t, fileSystem.Unlock())
+
+		// A lock already removed by another process is left alone
+		assert.Nil(t, removeStaleLock(lockPath))
+		assert.NoFileExists(t, lockPath)
+	}
+
+	// Check no temporary files are left behind
+	entries, err := os.ReadDir(dir.Join(REPOSITORY_FOLDER_NAME))
+	assert.Nil(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), TEMPORARY_FILE_PREFIX)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := fs.NewDir(t, "project", fs.WithFile("index", "old content"))
+	defer dir.Remove()
+
+	assert.Nil(t, writeFileAtomic(dir.Join("index"), []byte("new content")))
+
+	content, err := os.ReadFile(dir.Join("index"))
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "new content")
+
+	entries, err := os.ReadDir(dir.Path())
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 1)
+}
@@ -0,0 +1,115 @@
+package filesystems
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	Path "path/filepath"
+	"strings"
+)
+
+// MergeState is the record of a merge stopped by conflicts, kept until it is continued or aborted.
+type MergeState struct {
+	// Ref merged into and its save before the merge.
+	Ref     string
+	RefSave string
+	// Ref or save hash merged and its save, the second parent of the merge save.
+	Incoming     string
+	IncomingSave string
+	// Files conflicted by the merge, absolute paths.
+	ConflictedPaths []string
+}
+
+func (fileSystem *FileSystem) formatMergeState(state *MergeState) (string, error) {
+	var stringBuilder strings.Builder
+
+	stringBuilder.WriteString("Merge:\n\n")
+	stringBuilder.WriteString(fmt.Sprintf("%s\n%s\n%s\n%s\n", state.Ref, state.RefSave, state.Incoming, state.IncomingSave))
+	stringBuilder.WriteString("\nConflicted files:\n\n")
+
+	for _, path := range state.ConflictedPaths {
+		storedPath, err := fileSystem.storedPath(path)
+		if err != nil {
+			return "", err
+		}
+
+		stringBuilder.WriteString(fmt.Sprintf("%s\n", storedPath))
+	}
+
+	return stringBuilder.String(), nil
+}
+
+func (fileSystem *FileSystem) WriteMergeState(state *MergeState) error {
+	content, err := fileSystem.formatMergeState(state)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, MERGE_FILE_NAME), []byte(content))
+}
+
+func (fileSystem *FileSystem) ParseMergeState(reader io.Reader) (*MergeState, error) {
+	state := &MergeState{ConflictedPaths: []string{}}
+	scanner := bufio.NewScanner(reader)
+
+	// Skip file header lines
+	scanner.Scan()
+	scanner.Scan()
+
+	for _, field := range []*string{&state.Ref, &state.RefSave, &state.Incoming, &state.IncomingSave} {
+		if !scanner.Scan() {
+			return nil, errors.New("missing merge saves")
+		}
+
+		*field = scanner.Text()
+	}
+
+	if state.Ref == "" || state.IncomingSave == "" {
+		return nil, errors.New("missing merge saves")
+	}
+
+	// Skip conflicted files header lines
+	scanner.Scan()
+	scanner.Scan()
+	scanner.Scan()
+
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+
+		state.ConflictedPaths = append(state.ConflictedPaths, fileSystem.readStoredPath(scanner.Text()))
+	}
+
+	return state, scanner.Err()
+}
+
+// Read the merge state, nil when there is no merge in progress.
+func (fileSystem *FileSystem) ReadMergeState() (state *MergeState, err error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, MERGE_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	state, err = fileSystem.ParseMergeState(file)
+	if err != nil {
+		return nil, &CorruptMergeError{Err: err}
+	}
+
+	return state, nil
+}
+
+func (fileSystem *FileSystem) RemoveMergeState() error {
+	err := os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, MERGE_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,53 @@
+package filesystems
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestMergeState(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	statePath := dir.Join(REPOSITORY_FOLDER_NAME, MERGE_FILE_NAME)
+
+	// Check there is no merge in progress
+	state, err := fileSystem.ReadMergeState()
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+
+	// Check the state round trip with root relative paths
+	expectedState := &MergeState{
+		Ref:             "master",
+		RefSave:         "ref-save",
+		Incoming:        "feature",
+		IncomingSave:    "incoming-save",
+		ConflictedPaths: []string{dir.Join("a.txt"), dir.Join("a", "b c.txt")},
+	}
+	assert.Nil(t, fileSystem.WriteMergeState(expectedState))
+
+	content, err := os.ReadFile(statePath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "Merge:\n\nmaster\nref-save\nfeature\nincoming-save\n\nConflicted files:\n\na.txt\na/b c.txt\n")
+
+	state, err = fileSystem.ReadMergeState()
+	assert.Nil(t, err)
+	assert.Equal(t, state, expectedState)
+
+	// Check corrupt state
+	assert.Nil(t, os.WriteFile(statePath, []byte("Merge:\n\nmaster\n"), 0644))
+
+	_, err = fileSystem.ReadMergeState()
+	assert.EqualError(t, err, "corrupt merge state: missing merge saves")
+
+	// Check removal
+	assert.Nil(t, fileSystem.RemoveMergeState())
+	assert.Nil(t, fileSystem.RemoveMergeState())
+	assert.NoFileExists(t, statePath)
+}
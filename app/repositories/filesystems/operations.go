@@ -0,0 +1,378 @@
+package filesystems
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	Path "path/filepath"
+	"reflect"
+	"saymow/version-manager/app/repositories/directories"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OPLOG_FILE_NAME         = "oplog"
+	WORKING_FILES_FILE_NAME = "working"
+
+	// Older operations are dropped along with their snapshots, so they stop keeping objects from the garbage collection.
+	MAX_OPERATIONS = 100
+)
+
+type SnapshotSide string
+
+const (
+	SNAPSHOT_BEFORE SnapshotSide = "before"
+	SNAPSHOT_AFTER  SnapshotSide = "after"
+)
+
+// Operation is a recorded command, with snapshots of the repository before and after it.
+type Operation struct {
+	// Sequence number, starting at 1.
+	Id int
+	// The command line, e.g. "add a.txt".
+	Command   string
+	CreatedAt time.Time
+	// Undone operations can be redone until another operation is recorded.
+	Undone bool
+}
+
+// Snapshot is the repository state an operation changes.
+type Snapshot struct {
+	Head  string
+	Refs  *Refs
+	Index []*directories.Change
+	// Merge stopped by conflicts, nil when there was no merge in progress.
+	MergeState *MergeState
+	// Cherry-pick stopped by conflicts, nil when there was no cherry-pick in progress.
+	CherryPickState *CherryPickState
+	// Rebase stopped by conflicts, nil when there was no rebase in progress.
+	RebaseState *RebaseState
+	// Working directory files the operation overwrote or removed, as creations, or as removals for
+	// missing files. Both snapshots of an operation have the same paths.
+	WorkingFiles []*directories.Change
+	// Stashes saves names, from the newest to the oldest.
+	Stashes []string
+}
+
+// Check whether two snapshots have the same HEAD, refs, index, merge, cherry-pick and rebase states and stashes, the
+// working files are not compared.
+func (snapshot *Snapshot) SameState(otherSnapshot *Snapshot) bool {
+	return snapshot.Head == otherSnapshot.Head &&
+		maps.Equal(*snapshot.Refs, *otherSnapshot.Refs) &&
+		slices.EqualFunc(snapshot.Index, otherSnapshot.Index, func(change, otherChange *directories.Change) bool {
+			return reflect.DeepEqual(change, otherChange)
+		}) &&
+		reflect.DeepEqual(snapshot.MergeState, otherSnapshot.MergeState) &&
+		reflect.DeepEqual(snapshot.CherryPickState, otherSnapshot.CherryPickState) &&
+		reflect.DeepEqual(snapshot.RebaseState, otherSnapshot.RebaseState) &&
+		slices.Equal(snapshot.Stashes, otherSnapshot.Stashes)
+}
+
+func (fileSystem *FileSystem) operationPath(paths ...string) string {
+	return Path.Join(append([]string{fileSystem.Root, REPOSITORY_FOLDER_NAME, OPERATIONS_FOLDER_NAME}, paths...)...)
+}
+
+func (fileSystem *FileSystem) snapshotPath(id int, side SnapshotSide) string {
+	return fileSystem.operationPath(strconv.Itoa(id), string(side))
+}
+
+func (fileSystem *FileSystem) formatWorkingFiles(files []*directories.Change) (string, error) {
+	var stringBuilder strings.Builder
+
+	stringBuilder.WriteString("Working files:\n\n")
+
+	for _, change := range files {
+		line, err := fileSystem.formatChange(change)
+		if err != nil {
+			return "", err
+		}
+
+		stringBuilder.WriteString(line)
+	}
+
+	return stringBuilder.String(), nil
+}
+
+func (fileSystem *FileSystem) ParseWorkingFiles(reader io.Reader) ([]*directories.Change, error) {
+	files := []*directories.Change{}
+	scanner := bufio.NewScanner(reader)
+
+	// Skip file header lines
+	scanner.Scan()
+	scanner.Scan()
+
+	for scanner.Scan() {
+		change, err := fileSystem.parseChange(scanner, false)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, change)
+	}
+
+	return files, scanner.Err()
+}
+
+func (fileSystem *FileSystem) writeSnapshot(path string, snapshot *Snapshot) error {
+	if err := os.MkdirAll(path, REPOSITORY_DIRS_PERMISSIONS); err != nil {
+		return err
+	}
+
+	index, err := fileSystem.formatIndex(snapshot.Index)
+	if err != nil {
+		return err
+	}
+
+	workingFiles, err := fileSystem.formatWorkingFiles(snapshot.WorkingFiles)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		HEAD_FILE_NAME:          snapshot.Head,
+		REFS_FILE_NAME:          formatRefs(snapshot.Refs),
+		INDEX_FILE_NAME:         index,
+		STASH_FILE_NAME:         formatStashes(snapshot.Stashes),
+		WORKING_FILES_FILE_NAME: workingFiles,
+	}
+
+	if snapshot.MergeState != nil {
+		if files[MERGE_FILE_NAME], err = fileSystem.formatMergeState(snapshot.MergeState); err != nil {
+			return err
+		}
+	}
+
+	if snapshot.CherryPickState != nil {
+		files[CHERRY_PICK_FILE_NAME] = formatCherryPickState(snapshot.CherryPickState)
+	}
+
+	if snapshot.RebaseState != nil {
+		files[REBASE_FILE_NAME] = formatRebaseState(snapshot.RebaseState)
+	}
+
+	for name, content := range files {
+		if err := writeFileAtomic(Path.Join(path, name), []byte(content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Read the snapshot of the repository before or after an operation.
+func (fileSystem *FileSystem) ReadSnapshot(id int, side SnapshotSide) (*Snapshot, error) {
+	path := fileSystem.snapshotPath(id, side)
+	snapshot := &Snapshot{}
+
+	readFile := func(name string) ([]byte, error) {
+		content, err := os.ReadFile(Path.Join(path, name))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &CorruptOperationError{Id: id, Err: fmt.Errorf("missing %s snapshot %s", side, name)}
+		}
+
+		return content, err
+	}
+
+	head, err := readFile(HEAD_FILE_NAME)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Head = string(head)
+
+	refs, err := readFile(REFS_FILE_NAME)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Refs, err = fileSystem.ParseRefs(bytes.NewReader(refs)); err != nil {
+		return nil, &CorruptOperationError{Id: id, Err: err}
+	}
+
+	index, err := readFile(INDEX_FILE_NAME)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Index, err = fileSystem.ParseIndex(bytes.NewReader(index)); err != nil {
+		return nil, &CorruptOperationError{Id: id, Err: err}
+	}
+
+	stashes, err := readFile(STASH_FILE_NAME)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Stashes, err = ParseStashes(bytes.NewReader(stashes)); err != nil {
+		return nil, &CorruptOperationError{Id: id, Err: err}
+	}
+
+	workingFiles, err := readFile(WORKING_FILES_FILE_NAME)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.WorkingFiles, err = fileSystem.ParseWorkingFiles(bytes.NewReader(workingFiles)); err != nil {
+		return nil, &CorruptOperationError{Id: id, Err: err}
+	}
+
+	mergeState, err := os.ReadFile(Path.Join(path, MERGE_FILE_NAME))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if snapshot.MergeState, err = fileSystem.ParseMergeState(bytes.NewReader(mergeState)); err != nil {
+			return nil, &CorruptOperationError{Id: id, Err: err}
+		}
+	}
+
+	cherryPickState, err := os.ReadFile(Path.Join(path, CHERRY_PICK_FILE_NAME))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if snapshot.CherryPickState, err = ParseCherryPickState(bytes.NewReader(cherryPickState)); err != nil {
+			return nil, &CorruptOperationError{Id: id, Err: err}
+		}
+	}
+
+	rebaseState, err := os.ReadFile(Path.Join(path, REBASE_FILE_NAME))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if snapshot.RebaseState, err = ParseRebaseState(bytes.NewReader(rebaseState)); err != nil {
+			return nil, &CorruptOperationError{Id: id, Err: err}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func ParseOperations(reader io.Reader) ([]*Operation, error) {
+	operations := []*Operation{}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 4)
+		if len(fields) != 4 || (fields[1] != "done" && fields[1] != "undone") {
+			return nil, fmt.Errorf("invalid operation \"%s\"", scanner.Text())
+		}
+
+		id, err := strconv.Atoi(fields[0])
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid operation \"%s\"", scanner.Text())
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation \"%s\"", scanner.Text())
+		}
+
+		operations = append(operations, &Operation{Id: id, Undone: fields[1] == "undone", CreatedAt: createdAt, Command: fields[3]})
+	}
+
+	return operations, scanner.Err()
+}
+
+// Read the recorded operations, from the oldest to the newest.
+func (fileSystem *FileSystem) ReadOperations() (operations []*Operation, err error) {
+	file, err := os.Open(fileSystem.operationPath(OPLOG_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Operation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	operations, err = ParseOperations(file)
+	if err != nil {
+		return nil, &CorruptOperationError{Err: err}
+	}
+
+	return operations, nil
+}
+
+// Write the operation log, the snapshots of the operations left out are removed.
+func (fileSystem *FileSystem) WriteOperations(operations []*Operation) error {
+	var stringBuilder strings.Builder
+
+	for _, operation := range operations {
+		status := "done"
+		if operation.Undone {
+			status = "undone"
+		}
+
+		stringBuilder.WriteString(fmt.Sprintf("%d\t%s\t%s\t%s\n", operation.Id, status, operation.CreatedAt.Format(time.RFC3339), operation.Command))
+	}
+
+	if err := os.MkdirAll(fileSystem.operationPath(), REPOSITORY_DIRS_PERMISSIONS); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(fileSystem.operationPath(OPLOG_FILE_NAME), []byte(stringBuilder.String())); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(fileSystem.operationPath())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		id, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		if !slices.ContainsFunc(operations, func(operation *Operation) bool { return operation.Id == id }) {
+			if err := os.RemoveAll(fileSystem.operationPath(entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Record an operation after the done ones, the undone operations cannot be redone anymore.
+//
+// The snapshots are written before the operation log, so a recorded operation always has its snapshots.
+func (fileSystem *FileSystem) AppendOperation(command string, before *Snapshot, after *Snapshot) (*Operation, error) {
+	operations, err := fileSystem.ReadOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	operation := &Operation{Id: 1, Command: command, CreatedAt: time.Now()}
+	if len(operations) > 0 {
+		operation.Id = operations[len(operations)-1].Id + 1
+	}
+
+	operations = slices.DeleteFunc(operations, func(operation *Operation) bool {
+		return operation.Undone
+	})
+	operations = append(operations, operation)
+	operations = operations[max(len(operations)-MAX_OPERATIONS, 0):]
+
+	// Leftovers of an operation whose log was not written
+	if err := os.RemoveAll(fileSystem.operationPath(strconv.Itoa(operation.Id))); err != nil {
+		return nil, err
+	}
+
+	if err := fileSystem.writeSnapshot(fileSystem.snapshotPath(operation.Id, SNAPSHOT_BEFORE), before); err != nil {
+		return nil, err
+	}
+	if err := fileSystem.writeSnapshot(fileSystem.snapshotPath(operation.Id, SNAPSHOT_AFTER), after); err != nil {
+		return nil, err
+	}
+
+	return operation, fileSystem.WriteOperations(operations)
+}
+
+// Remove the operation log and the snapshots.
+func (fileSystem *FileSystem) RemoveOperations() error {
+	return os.RemoveAll(fileSystem.operationPath())
+}
@@ -0,0 +1,121 @@
+package filesystems
+
+import (
+	"os"
+	"saymow/version-manager/app/repositories/directories"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestOperations(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	// Check there are no operations
+	operations, err := fileSystem.ReadOperations()
+	assert.Nil(t, err)
+	assert.Equal(t, operations, []*Operation{})
+
+	// Check snapshots round trip
+	before := &Snapshot{
+		Head:         INITIAL_REF_NAME,
+		Refs:         &Refs{INITIAL_REF_NAME: ""},
+		Index:        nil,
+		Stashes:      []string{},
+		WorkingFiles: []*directories.Change{},
+	}
+	after := &Snapshot{
+		Head: INITIAL_REF_NAME,
+		Refs: &Refs{INITIAL_REF_NAME: "save-0", "feature": "save-1"},
+		Index: []*directories.Change{
+			{ChangeType: directories.Creation, File: &directories.File{Filepath: dir.Join("a", "1.txt"), ObjectName: "1.txt-object"}},
+		},
+		MergeState: &MergeState{
+			Ref:             INITIAL_REF_NAME,
+			RefSave:         "save-0",
+			Incoming:        "feature",
+			IncomingSave:    "save-1",
+			ConflictedPaths: []string{dir.Join("a", "1.txt")},
+		},
+		CherryPickState: &CherryPickState{
+			Ref:     INITIAL_REF_NAME,
+			RefSave: "save-0",
+			Current: "save-1",
+			Pending: []string{},
+		},
+		RebaseState: &RebaseState{
+			Ref:     "feature",
+			RefSave: "save-1",
+			Onto:    "save-0",
+			Pending: []*RebaseStep{{Action: REBASE_REWORD, Id: "save-1", Message: "New message."}},
+		},
+		Stashes: []string{"stash-1", "stash-0"},
+		WorkingFiles: []*directories.Change{
+			{ChangeType: directories.Creation, File: &directories.File{Filepath: dir.Join("a", "1.txt"), ObjectName: "1.txt-object"}},
+			{ChangeType: directories.Removal, Removal: &directories.FileRemoval{Filepath: dir.Join("a", "2.txt")}},
+		},
+	}
+
+	operation, err := fileSystem.AppendOperation("add a", before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Id, 1)
+	assert.Equal(t, operation.Command, "add a")
+
+	snapshot, err := fileSystem.ReadSnapshot(1, SNAPSHOT_BEFORE)
+	assert.Nil(t, err)
+	assert.Equal(t, snapshot, before)
+	assert.True(t, snapshot.SameState(before))
+	assert.False(t, snapshot.SameState(after))
+
+	snapshot, err = fileSystem.ReadSnapshot(1, SNAPSHOT_AFTER)
+	assert.Nil(t, err)
+	assert.Equal(t, snapshot, after)
+
+	// Check undone operations are dropped by the next operation
+	fileSystem.AppendOperation("add b", before, after)
+	operations, _ = fileSystem.ReadOperations()
+	operations[1].Undone = true
+	assert.Nil(t, fileSystem.WriteOperations(operations))
+
+	operations, err = fileSystem.ReadOperations()
+	assert.Nil(t, err)
+	assert.Equal(t, len(operations), 2)
+	assert.True(t, operations[1].Undone)
+
+	operation, err = fileSystem.AppendOperation("add c", before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Id, 3)
+
+	operations, _ = fileSystem.ReadOperations()
+	assert.Equal(t, len(operations), 2)
+	assert.Equal(t, operations[0].Command, "add a")
+	assert.Equal(t, operations[1].Command, "add c")
+	assert.NoDirExists(t, dir.Join(REPOSITORY_FOLDER_NAME, OPERATIONS_FOLDER_NAME, "2"))
+
+	// Check older operations are dropped
+	for idx := 0; idx < MAX_OPERATIONS; idx++ {
+		fileSystem.AppendOperation("add "+strconv.Itoa(idx), before, after)
+	}
+
+	operations, _ = fileSystem.ReadOperations()
+	assert.Equal(t, len(operations), MAX_OPERATIONS)
+	assert.Equal(t, operations[0].Command, "add 0")
+	assert.NoDirExists(t, dir.Join(REPOSITORY_FOLDER_NAME, OPERATIONS_FOLDER_NAME, "3"))
+
+	// Check corrupt operations
+	assert.Nil(t, os.Remove(dir.Join(REPOSITORY_FOLDER_NAME, OPERATIONS_FOLDER_NAME, strconv.Itoa(operations[0].Id), string(SNAPSHOT_AFTER), HEAD_FILE_NAME)))
+
+	_, err = fileSystem.ReadSnapshot(operations[0].Id, SNAPSHOT_AFTER)
+	assert.EqualError(t, err, "corrupt operation "+strconv.Itoa(operations[0].Id)+": missing after snapshot head")
+
+	assert.Nil(t, os.WriteFile(dir.Join(REPOSITORY_FOLDER_NAME, OPERATIONS_FOLDER_NAME, OPLOG_FILE_NAME), []byte("1\tlost\n"), 0644))
+
+	_, err = fileSystem.ReadOperations()
+	assert.EqualError(t, err, "corrupt operation log: invalid operation \"1\tlost\"")
+}
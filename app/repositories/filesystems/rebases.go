@@ -0,0 +1,196 @@
+package filesystems
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	Path "path/filepath"
+	"slices"
+	"strings"
+)
+
+type RebaseAction string
+
+const (
+	// Replay the save changes with its message.
+	REBASE_PICK RebaseAction = "pick"
+	// Meld the save changes into the previous replayed save, joining their messages.
+	REBASE_SQUASH RebaseAction = "squash"
+	// Replay the save changes with the step message.
+	REBASE_REWORD RebaseAction = "reword"
+	// Leave the save out.
+	REBASE_DROP RebaseAction = "drop"
+)
+
+// RebaseStep is a line of a rebase plan, e.g. "reword 3f674c71 New message".
+type RebaseStep struct {
+	Action RebaseAction
+	// Save name, or a revision in plans written by hand.
+	Id string
+	// New message of reworded saves, other actions keep the save message for reference.
+	Message string
+}
+
+// RebaseState is the record of a rebase stopped by conflicts, kept until it is continued or aborted.
+type RebaseState struct {
+	// Ref rebased and its save before the rebase.
+	Ref     string
+	RefSave string
+	// Save the ref is rebased onto.
+	Onto string
+	// Step whose changes are conflicted, nil when the rebase stopped between steps.
+	Current *RebaseStep
+	// Steps left to replay, in order.
+	Pending []*RebaseStep
+}
+
+func FormatRebaseStep(step *RebaseStep) string {
+	if step.Message == "" {
+		return fmt.Sprintf("%s %s", step.Action, step.Id)
+	}
+
+	return fmt.Sprintf("%s %s %s", step.Action, step.Id, step.Message)
+}
+
+func ParseRebaseStep(line string) (*RebaseStep, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	if len(fields) < 2 || fields[1] == "" {
+		return nil, fmt.Errorf("invalid step \"%s\"", line)
+	}
+
+	step := &RebaseStep{Action: RebaseAction(fields[0]), Id: fields[1]}
+	if !slices.Contains([]RebaseAction{REBASE_PICK, REBASE_SQUASH, REBASE_REWORD, REBASE_DROP}, step.Action) {
+		return nil, fmt.Errorf("invalid step action \"%s\"", fields[0])
+	}
+	if len(fields) == 3 {
+		step.Message = strings.TrimSpace(fields[2])
+	}
+
+	return step, nil
+}
+
+// Parse a rebase plan, one step per line. Empty lines and lines starting with "#" are skipped.
+func ParseRebasePlan(reader io.Reader) ([]*RebaseStep, error) {
+	steps := []*RebaseStep{}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		step, err := ParseRebaseStep(line)
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, scanner.Err()
+}
+
+func formatRebaseState(state *RebaseState) string {
+	var stringBuilder strings.Builder
+
+	current := ""
+	if state.Current != nil {
+		current = FormatRebaseStep(state.Current)
+	}
+
+	stringBuilder.WriteString("Rebase:\n\n")
+	stringBuilder.WriteString(fmt.Sprintf("%s\n%s\n%s\n%s\n", state.Ref, state.RefSave, state.Onto, current))
+	stringBuilder.WriteString("\nPending steps:\n\n")
+
+	for _, step := range state.Pending {
+		stringBuilder.WriteString(fmt.Sprintf("%s\n", FormatRebaseStep(step)))
+	}
+
+	return stringBuilder.String()
+}
+
+func (fileSystem *FileSystem) WriteRebaseState(state *RebaseState) error {
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REBASE_FILE_NAME), []byte(formatRebaseState(state)))
+}
+
+func ParseRebaseState(reader io.Reader) (*RebaseState, error) {
+	state := &RebaseState{Pending: []*RebaseStep{}}
+	scanner := bufio.NewScanner(reader)
+
+	// Skip file header lines
+	scanner.Scan()
+	scanner.Scan()
+
+	fields := []string{}
+	for len(fields) < 4 && scanner.Scan() {
+		fields = append(fields, scanner.Text())
+	}
+	if len(fields) < 4 {
+		return nil, errors.New("missing rebase saves")
+	}
+
+	state.Ref, state.RefSave, state.Onto = fields[0], fields[1], fields[2]
+	if state.Ref == "" || state.Onto == "" {
+		return nil, errors.New("missing rebase ref")
+	}
+
+	if fields[3] != "" {
+		step, err := ParseRebaseStep(fields[3])
+		if err != nil {
+			return nil, err
+		}
+
+		state.Current = step
+	}
+
+	// Skip pending steps header lines
+	scanner.Scan()
+	scanner.Scan()
+	scanner.Scan()
+
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+
+		step, err := ParseRebaseStep(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+
+		state.Pending = append(state.Pending, step)
+	}
+
+	return state, scanner.Err()
+}
+
+// Read the rebase state, nil when there is no rebase in progress.
+func (fileSystem *FileSystem) ReadRebaseState() (state *RebaseState, err error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REBASE_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	state, err = ParseRebaseState(file)
+	if err != nil {
+		return nil, &CorruptRebaseError{Err: err}
+	}
+
+	return state, nil
+}
+
+func (fileSystem *FileSystem) RemoveRebaseState() error {
+	err := os.Remove(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, REBASE_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
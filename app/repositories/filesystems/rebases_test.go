@@ -0,0 +1,83 @@
+package filesystems
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestRebasePlan(t *testing.T) {
+	plan, err := ParseRebasePlan(strings.NewReader("pick save-0 First save.\n\n# Comment\nsquash save-1\n  reword save-2 New message.  \ndrop save-3\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, plan, []*RebaseStep{
+		{Action: REBASE_PICK, Id: "save-0", Message: "First save."},
+		{Action: REBASE_SQUASH, Id: "save-1"},
+		{Action: REBASE_REWORD, Id: "save-2", Message: "New message."},
+		{Action: REBASE_DROP, Id: "save-3"},
+	})
+	assert.Equal(t, FormatRebaseStep(plan[1]), "squash save-1")
+	assert.Equal(t, FormatRebaseStep(plan[2]), "reword save-2 New message.")
+
+	_, err = ParseRebasePlan(strings.NewReader("pick\n"))
+	assert.EqualError(t, err, "invalid step \"pick\"")
+	_, err = ParseRebasePlan(strings.NewReader("edit save-0\n"))
+	assert.EqualError(t, err, "invalid step action \"edit\"")
+}
+
+func TestRebaseState(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	statePath := dir.Join(REPOSITORY_FOLDER_NAME, REBASE_FILE_NAME)
+
+	// Check there is no rebase in progress
+	state, err := fileSystem.ReadRebaseState()
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+
+	// Check the state round trip
+	expectedState := &RebaseState{
+		Ref:     "feature",
+		RefSave: "ref-save",
+		Onto:    "onto-save",
+		Current: &RebaseStep{Action: REBASE_PICK, Id: "current-save", Message: "Current."},
+		Pending: []*RebaseStep{{Action: REBASE_SQUASH, Id: "save-1"}},
+	}
+	assert.Nil(t, fileSystem.WriteRebaseState(expectedState))
+
+	content, err := os.ReadFile(statePath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "Rebase:\n\nfeature\nref-save\nonto-save\npick current-save Current.\n\nPending steps:\n\nsquash save-1\n")
+
+	state, err = fileSystem.ReadRebaseState()
+	assert.Nil(t, err)
+	assert.Equal(t, state, expectedState)
+
+	// Check a rebase stopped between steps
+	expectedState.Current = nil
+	assert.Nil(t, fileSystem.WriteRebaseState(expectedState))
+
+	state, err = fileSystem.ReadRebaseState()
+	assert.Nil(t, err)
+	assert.Equal(t, state, expectedState)
+
+	// Check corrupt states
+	assert.Nil(t, os.WriteFile(statePath, []byte("Rebase:\n\nfeature\n"), 0644))
+
+	_, err = fileSystem.ReadRebaseState()
+	assert.EqualError(t, err, "corrupt rebase state: missing rebase saves")
+
+	// Check the state removal
+	assert.Nil(t, fileSystem.RemoveRebaseState())
+	assert.Nil(t, fileSystem.RemoveRebaseState())
+
+	state, err = fileSystem.ReadRebaseState()
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+}
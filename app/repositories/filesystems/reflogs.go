@@ -0,0 +1,142 @@
+package filesystems
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	Path "path/filepath"
+	"strings"
+	"time"
+)
+
+// Name of the HEAD reflog, refs reflogs are named after their ref.
+const HEAD_REFLOG_NAME = "HEAD"
+
+// ReflogEntry records a movement of HEAD or a ref.
+type ReflogEntry struct {
+	// Save names before and after the movement, empty when there was no save.
+	OldId string
+	NewId string
+	// The command that moved HEAD or the ref, e.g. "save: message".
+	Command   string
+	CreatedAt time.Time
+}
+
+func (fileSystem *FileSystem) reflogPath(name string) string {
+	if name == HEAD_REFLOG_NAME {
+		return Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, LOGS_FOLDER_NAME, HEAD_REFLOG_NAME)
+	}
+
+	return Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, LOGS_FOLDER_NAME, REFS_FILE_NAME, Path.FromSlash(name))
+}
+
+func formatReflogEntry(entry *ReflogEntry) string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s\n", entry.OldId, entry.NewId, entry.CreatedAt.Format(time.RFC3339), entry.Command)
+}
+
+// Append an entry to a reflog, the reflog is created if needed.
+func (fileSystem *FileSystem) AppendReflog(name string, entry *ReflogEntry) (err error) {
+	reflogPath := fileSystem.reflogPath(name)
+
+	if err := os.MkdirAll(Path.Dir(reflogPath), REPOSITORY_DIRS_PERMISSIONS); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(reflogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer closeFile(file, &err)
+
+	_, err = file.WriteString(formatReflogEntry(entry))
+
+	return err
+}
+
+// Rewrite a whole reflog, used when saves are renamed.
+func (fileSystem *FileSystem) WriteReflog(name string, entries []*ReflogEntry) error {
+	var stringBuilder strings.Builder
+
+	for _, entry := range entries {
+		stringBuilder.WriteString(formatReflogEntry(entry))
+	}
+
+	return writeFileAtomic(fileSystem.reflogPath(name), []byte(stringBuilder.String()))
+}
+
+func ParseReflog(reader io.Reader) ([]*ReflogEntry, error) {
+	entries := []*ReflogEntry{}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid reflog entry \"%s\"", scanner.Text())
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reflog entry \"%s\"", scanner.Text())
+		}
+
+		entries = append(entries, &ReflogEntry{OldId: fields[0], NewId: fields[1], CreatedAt: createdAt, Command: fields[3]})
+	}
+
+	return entries, scanner.Err()
+}
+
+// Read a reflog entries, from the oldest to the newest. Missing reflogs have no entries.
+func (fileSystem *FileSystem) ReadReflog(name string) (entries []*ReflogEntry, err error) {
+	file, err := os.Open(fileSystem.reflogPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return []*ReflogEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	entries, err = ParseReflog(file)
+	if err != nil {
+		return nil, &CorruptReflogError{Name: name, Err: err}
+	}
+
+	return entries, nil
+}
+
+// List the reflogs names, HEAD first when it has a reflog.
+func (fileSystem *FileSystem) ListReflogs() ([]string, error) {
+	names := []string{}
+	logsPath := Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, LOGS_FOLDER_NAME)
+
+	if _, err := os.Stat(fileSystem.reflogPath(HEAD_REFLOG_NAME)); err == nil {
+		names = append(names, HEAD_REFLOG_NAME)
+	}
+
+	refsLogsPath := Path.Join(logsPath, REFS_FILE_NAME)
+	err := Path.WalkDir(refsLogsPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), TEMPORARY_FILE_PREFIX) {
+			return nil
+		}
+
+		name, err := Path.Rel(refsLogsPath, path)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, Path.ToSlash(name))
+
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+
+	return names, err
+}
@@ -0,0 +1,65 @@
+package filesystems
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestReflogs(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	// Check there are no reflogs
+	names, err := fileSystem.ListReflogs()
+	assert.Nil(t, err)
+	assert.Equal(t, names, []string{})
+
+	entries, err := fileSystem.ReadReflog(HEAD_REFLOG_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, entries, []*ReflogEntry{})
+
+	// Check entries are appended
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	first := &ReflogEntry{OldId: "", NewId: "save-0", Command: "save: first save", CreatedAt: createdAt}
+	second := &ReflogEntry{OldId: "save-0", NewId: "save-1", Command: "merge feature: fast-forward", CreatedAt: createdAt.Add(time.Hour)}
+
+	assert.Nil(t, fileSystem.AppendReflog(HEAD_REFLOG_NAME, first))
+	assert.Nil(t, fileSystem.AppendReflog(HEAD_REFLOG_NAME, second))
+	assert.Nil(t, fileSystem.AppendReflog("feat/a", first))
+
+	content, err := os.ReadFile(dir.Join(REPOSITORY_FOLDER_NAME, LOGS_FOLDER_NAME, HEAD_REFLOG_NAME))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		string(content),
+		"\tsave-0\t2026-10-01T12:00:00Z\tsave: first save\nsave-0\tsave-1\t2026-10-01T13:00:00Z\tmerge feature: fast-forward\n",
+	)
+
+	entries, err = fileSystem.ReadReflog(HEAD_REFLOG_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, entries, []*ReflogEntry{first, second})
+
+	names, err = fileSystem.ListReflogs()
+	assert.Nil(t, err)
+	assert.Equal(t, names, []string{HEAD_REFLOG_NAME, "feat/a"})
+
+	// Check rewrite
+	assert.Nil(t, fileSystem.WriteReflog("feat/a", []*ReflogEntry{second}))
+
+	entries, err = fileSystem.ReadReflog("feat/a")
+	assert.Nil(t, err)
+	assert.Equal(t, entries, []*ReflogEntry{second})
+
+	// Check corrupt reflog
+	assert.Nil(t, os.WriteFile(dir.Join(REPOSITORY_FOLDER_NAME, LOGS_FOLDER_NAME, REFS_FILE_NAME, "feat", "a"), []byte("save-0\tsave-1\n"), 0644))
+
+	_, err = fileSystem.ReadReflog("feat/a")
+	assert.EqualError(t, err, "corrupt reflog feat/a: invalid reflog entry \"save-0\tsave-1\"")
+}
@@ -0,0 +1,68 @@
+package filesystems
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	Path "path/filepath"
+	"strings"
+)
+
+// Stashes are saves kept outside of the refs: the working directory save has the HEAD save as first
+// parent and the index save as second parent. They are listed from the newest to the oldest.
+
+func formatStashes(ids []string) string {
+	var stringBuilder strings.Builder
+
+	stringBuilder.WriteString("Stashes:\n\n")
+
+	for _, id := range ids {
+		stringBuilder.WriteString(fmt.Sprintf("%s\n", id))
+	}
+
+	return stringBuilder.String()
+}
+
+func ParseStashes(reader io.Reader) ([]string, error) {
+	ids := []string{}
+	scanner := bufio.NewScanner(reader)
+
+	// Skip file header lines
+	scanner.Scan()
+	scanner.Scan()
+
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			return nil, errors.New("empty stash save")
+		}
+
+		ids = append(ids, scanner.Text())
+	}
+
+	return ids, scanner.Err()
+}
+
+// Read the stashes saves names, from the newest to the oldest.
+func (fileSystem *FileSystem) ReadStashes() (ids []string, err error) {
+	file, err := os.Open(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, STASH_FILE_NAME))
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(file, &err)
+
+	ids, err = ParseStashes(file)
+	if err != nil {
+		return nil, &CorruptStashError{Err: err}
+	}
+
+	return ids, nil
+}
+
+func (fileSystem *FileSystem) WriteStashes(ids []string) error {
+	return writeFileAtomic(Path.Join(fileSystem.Root, REPOSITORY_FOLDER_NAME, STASH_FILE_NAME), []byte(formatStashes(ids)))
+}
@@ -0,0 +1,34 @@
+package filesystems
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestStashes(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	// Check there are no stashes
+	ids, err := fileSystem.ReadStashes()
+	assert.Nil(t, err)
+	assert.Equal(t, ids, []string{})
+
+	assert.Nil(t, fileSystem.WriteStashes([]string{"stash-1", "stash-0"}))
+
+	ids, err = fileSystem.ReadStashes()
+	assert.Nil(t, err)
+	assert.Equal(t, ids, []string{"stash-1", "stash-0"})
+
+	// Check corrupt stashes
+	assert.Nil(t, os.WriteFile(dir.Join(REPOSITORY_FOLDER_NAME, STASH_FILE_NAME), []byte("Stashes:\n\nstash-1\n\nstash-0\n"), 0644))
+
+	_, err = fileSystem.ReadStashes()
+	assert.EqualError(t, err, "corrupt stash: empty stash save")
+}
@@ -0,0 +1,182 @@
+package filesystems
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"slices"
+	"strings"
+)
+
+const (
+	TREE_ENTRY = "tree"
+	BLOB_ENTRY = "blob"
+)
+
+// TreeEntry is a line of a tree object: a file or subdirectory name and its object name.
+//
+// Tree objects are stored as the other objects, their content is one "<type>\t<object name>\t<name>"
+// line per entry, sorted by name. Since a tree object name is the hash of its entries, identical
+// subtrees share the same object.
+type TreeEntry struct {
+	NodeType   directories.NodeType
+	ObjectName string
+	Name       string
+}
+
+// Check whether a file name can be stored. Trees, saves and the index store one tab separated entry
+// per line, so names cannot have tabs or line breaks.
+func IsValidName(name string) bool {
+	return !strings.ContainsAny(name, "\t\r\n")
+}
+
+// Write the tree objects of a directory and its subdirectories, bottom up.
+//
+// Directories with a hash did not change since they were read, so their objects already exist.
+func (fileSystem *FileSystem) WriteTree(dir *directories.Dir) (string, error) {
+	if dir.Hash != "" {
+		return dir.Hash, nil
+	}
+
+	names := []string{}
+	for name := range dir.Children {
+		if !IsValidName(name) {
+			return "", &InvalidNameError{Path: Path.Join(dir.Path, name)}
+		}
+
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var stringBuilder strings.Builder
+
+	for _, name := range names {
+		node := dir.Children[name]
+
+		if node.NodeType == directories.DirType {
+			hash, err := fileSystem.WriteTree(node.Dir)
+			if err != nil {
+				return "", err
+			}
+
+			stringBuilder.WriteString(fmt.Sprintf("%s\t%s\t%s\n", TREE_ENTRY, hash, name))
+		} else {
+			stringBuilder.WriteString(fmt.Sprintf("%s\t%s\t%s\n", BLOB_ENTRY, node.File.ObjectName, name))
+		}
+	}
+
+	object, err := fileSystem.WriteObjectContent(dir.Path, []byte(stringBuilder.String()))
+	if err != nil {
+		return "", err
+	}
+
+	dir.Hash = object.ObjectName
+
+	return dir.Hash, nil
+}
+
+func ParseTree(reader io.Reader) ([]*TreeEntry, error) {
+	entries := []*TreeEntry{}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 || fields[1] == "" || fields[2] == "" {
+			return nil, fmt.Errorf("invalid tree entry \"%s\"", scanner.Text())
+		}
+
+		entry := &TreeEntry{ObjectName: fields[1], Name: fields[2]}
+
+		switch fields[0] {
+		case TREE_ENTRY:
+			entry.NodeType = directories.DirType
+		case BLOB_ENTRY:
+			entry.NodeType = directories.FileType
+		default:
+			return nil, fmt.Errorf("invalid tree entry \"%s\"", scanner.Text())
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+func (fileSystem *FileSystem) ReadTreeEntries(hash string) ([]*TreeEntry, error) {
+	reader, err := fileSystem.OpenObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	entries, err := ParseTree(reader)
+	if err != nil {
+		var corruptObjectErr *CorruptObjectError
+		if errors.As(err, &corruptObjectErr) {
+			return nil, err
+		}
+
+		return nil, &CorruptObjectError{Name: hash, Err: err}
+	}
+
+	return entries, nil
+}
+
+// Read the directory of a tree object, path is the directory absolute path.
+func (fileSystem *FileSystem) ReadTree(hash string, path string) (*directories.Dir, error) {
+	entries, err := fileSystem.ReadTreeEntries(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := &directories.Dir{Path: path, Children: make(map[string]*directories.Node), Hash: hash}
+
+	for _, entry := range entries {
+		entryPath := Path.Join(path, entry.Name)
+
+		if entry.NodeType == directories.DirType {
+			subdir, err := fileSystem.ReadTree(entry.ObjectName, entryPath)
+			if err != nil {
+				return nil, err
+			}
+
+			dir.Children[entry.Name] = &directories.Node{NodeType: directories.DirType, Dir: subdir}
+		} else {
+			dir.Children[entry.Name] = &directories.Node{
+				NodeType: directories.FileType,
+				File:     &directories.File{Filepath: entryPath, ObjectName: entry.ObjectName},
+			}
+		}
+	}
+
+	return dir, nil
+}
+
+// Collect the objects of a tree, including the tree objects. Subtrees already in objects are skipped.
+func (fileSystem *FileSystem) CollectTreeObjects(hash string, objects map[string]bool) error {
+	if objects[hash] {
+		return nil
+	}
+
+	objects[hash] = true
+
+	entries, err := fileSystem.ReadTreeEntries(hash)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.NodeType == directories.DirType {
+			if err := fileSystem.CollectTreeObjects(entry.ObjectName, objects); err != nil {
+				return err
+			}
+		} else {
+			objects[entry.ObjectName] = true
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package filesystems
+
+import (
+	"fmt"
+	"os"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestTree(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	makeDir := func(changes map[string]string) *directories.Dir {
+		root := &directories.Dir{Path: dir.Path(), Children: make(map[string]*directories.Node)}
+
+		for path, objectName := range changes {
+			root.AddNode(path, &directories.Change{
+				ChangeType: directories.Creation,
+				File:       &directories.File{Filepath: dir.Join(path), ObjectName: objectName},
+			})
+		}
+
+		return root
+	}
+
+	first := makeDir(map[string]string{
+		"1.txt":       "1.txt-object",
+		"a/2.txt":     "2.txt-object",
+		"a/b/3.txt":   "3.txt-object",
+		"c/4.txt":     "4.txt-object",
+		"c/d e/5.txt": "5.txt-object",
+	})
+	second := makeDir(map[string]string{
+		"1.txt":       "1.txt-object-v2",
+		"a/2.txt":     "2.txt-object",
+		"a/b/3.txt":   "3.txt-object",
+		"c/4.txt":     "4.txt-object",
+		"c/d e/5.txt": "5.txt-object",
+	})
+
+	// Check the tree objects round trip
+	firstHash, err := fileSystem.WriteTree(first)
+	assert.Nil(t, err)
+	assert.Equal(t, first.Hash, firstHash)
+
+	tree, err := fileSystem.ReadTree(firstHash, dir.Path())
+	assert.Nil(t, err)
+	assert.Equal(t, tree.Hash, firstHash)
+	assert.ElementsMatch(t, tree.CollectAllFiles(), first.CollectAllFiles())
+	assert.Equal(t, tree.Children["c"].Dir.Children["d e"].Dir.Path, dir.Join("c", "d e"))
+
+	// Check identical subtrees share their objects
+	secondHash, err := fileSystem.WriteTree(second)
+	assert.Nil(t, err)
+	assert.NotEqual(t, secondHash, firstHash)
+	assert.Equal(t, second.Children["a"].Dir.Hash, first.Children["a"].Dir.Hash)
+	assert.Equal(t, second.Children["c"].Dir.Hash, first.Children["c"].Dir.Hash)
+
+	objects, err := fileSystem.ListObjects()
+	assert.Nil(t, err)
+	// 5 trees for the first dir and a root tree for the second one
+	assert.Equal(t, len(objects), 6)
+
+	collected := make(map[string]bool)
+	assert.Nil(t, fileSystem.CollectTreeObjects(secondHash, collected))
+	assert.Equal(t, len(collected), 10)
+	assert.True(t, collected["1.txt-object-v2"])
+	assert.False(t, collected["1.txt-object"])
+
+	// Check unchanged directories are not rewritten
+	assert.Nil(t, os.Remove(dir.Join(REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, tree.Children["a"].Dir.Hash)))
+
+	tree.AddNode("c/6.txt", &directories.Change{
+		ChangeType: directories.Creation,
+		File:       &directories.File{Filepath: dir.Join("c", "6.txt"), ObjectName: "6.txt-object"},
+	})
+	assert.Equal(t, tree.Hash, "")
+	assert.Equal(t, tree.Children["c"].Dir.Hash, "")
+	assert.Equal(t, tree.Children["a"].Dir.Hash, first.Children["a"].Dir.Hash)
+
+	_, err = fileSystem.WriteTree(tree)
+	assert.Nil(t, err)
+	assert.NoFileExists(t, dir.Join(REPOSITORY_FOLDER_NAME, OBJECTS_FOLDER_NAME, tree.Children["a"].Dir.Hash))
+}
+
+func TestTreeInvalidName(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fileSystem, err := Create(dir.Path())
+	assert.Nil(t, err)
+
+	for _, name := range []string{"1\t.txt", "2\n.txt", "3\r.txt"} {
+		root := &directories.Dir{Path: dir.Path(), Children: make(map[string]*directories.Node)}
+		assert.Nil(t, root.AddNode(Path.Join("a", name), &directories.Change{
+			ChangeType: directories.Creation,
+			File:       &directories.File{Filepath: dir.Join("a", name), ObjectName: "object"},
+		}))
+
+		_, err = fileSystem.WriteTree(root)
+		assert.EqualError(t, err, fmt.Sprintf("invalid name %q, names cannot have tabs or line breaks", dir.Join("a", name)))
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	entries, err := ParseTree(strings.NewReader("tree\tsubtree-object\ta b\nblob\tfile-object\tc\td.txt\n"))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		entries,
+		[]*TreeEntry{
+			{NodeType: directories.DirType, ObjectName: "subtree-object", Name: "a b"},
+			{NodeType: directories.FileType, ObjectName: "file-object", Name: "c\td.txt"},
+		},
+	)
+
+	_, err = ParseTree(strings.NewReader("link\tobject\tname\n"))
+	assert.EqualError(t, err, "invalid tree entry \"link\tobject\tname\"")
+
+	_, err = ParseTree(strings.NewReader("blob\tobject\n"))
+	assert.EqualError(t, err, "invalid tree entry \"blob\tobject\"")
+}
@@ -0,0 +1,401 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+	"strconv"
+)
+
+type FsckIssueType string
+
+const (
+	// The item content does not match its name or cannot be parsed.
+	CORRUPT_ISSUE FsckIssueType = "corrupt"
+	// The item is referenced but does not exist.
+	MISSING_ISSUE FsckIssueType = "missing"
+	// The item exists but is not reachable. Dangling items are expected and removed by the garbage collection.
+	DANGLING_ISSUE FsckIssueType = "dangling"
+)
+
+type FsckIssue struct {
+	Type FsckIssueType
+	// The item kind and name, e.g. "object <hash>", "save <hash>", "ref <name>", "HEAD", "index", "reflog <name>",
+	// "stash", "operation <id> <side>", "merge state" or "cherry-pick state".
+	Item    string
+	Message string
+}
+
+type FsckReport struct {
+	Objects int
+	Saves   int
+	Issues  []*FsckIssue
+}
+
+// Check whether the report has corrupt or missing items.
+func (report *FsckReport) HasErrors() bool {
+	return slices.ContainsFunc(report.Issues, func(issue *FsckIssue) bool {
+		return issue.Type != DANGLING_ISSUE
+	})
+}
+
+func (report *FsckReport) addIssue(issueType FsckIssueType, item string, message string) {
+	report.Issues = append(report.Issues, &FsckIssue{Type: issueType, Item: item, Message: message})
+}
+
+// Report a corrupt item, the typed error cause is used as message since the item is already named.
+func (report *FsckReport) addCorruptIssue(item string, err error) {
+	if cause := errors.Unwrap(err); cause != nil {
+		err = cause
+	}
+
+	report.addIssue(CORRUPT_ISSUE, item, err.Error())
+}
+
+// Fsck verifies the repository integrity.
+//
+// Every object is re-hashed after decompression and every save file is re-hashed against its name.
+// Saves parents, changes and trees objects, refs, HEAD, reflogs, stashes, operations snapshots and the index are
+// checked to exist. Unlike the other operations, Fsck does not load the repository, so it reports broken
+// items instead of failing on them.
+func Fsck(root string) (*FsckReport, error) {
+	fileSystem, err := filesystems.Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FsckReport{Issues: []*FsckIssue{}}
+	referencedObjects := make(map[string]bool)
+
+	objects, err := fileSystem.ListObjects()
+	if err != nil {
+		return nil, err
+	}
+	objectNames := []string{}
+	for name := range objects {
+		objectNames = append(objectNames, name)
+	}
+	slices.Sort(objectNames)
+
+	corruptObjects := make(map[string]bool)
+
+	for _, name := range objectNames {
+		if err := fileSystem.VerifyObject(name); err != nil {
+			corruptObjects[name] = true
+			report.addCorruptIssue("object "+name, err)
+		}
+	}
+
+	checkObjects := func(changes []*directories.Change, referrer string) {
+		for _, change := range changes {
+			hash := change.GetHash()
+			if hash == "" {
+				continue
+			}
+
+			referencedObjects[hash] = true
+
+			if _, ok := objects[hash]; !ok {
+				report.addIssue(MISSING_ISSUE, "object "+hash, fmt.Sprintf("%s of %s", change.GetPath(), referrer))
+			}
+		}
+	}
+
+	saveNames, err := fileSystem.ListCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(saveNames)
+	saveExists := make(map[string]bool)
+	checkpoints := make(map[string]*filesystems.Checkpoint)
+
+	for _, id := range saveNames {
+		saveExists[id] = true
+	}
+
+	for _, id := range saveNames {
+		checkpoint, err := fileSystem.VerifyCheckpoint(id)
+		if err != nil {
+			report.addCorruptIssue("save "+id, err)
+			continue
+		}
+
+		checkpoints[id] = checkpoint
+	}
+
+	for _, id := range saveNames {
+		checkpoint, ok := checkpoints[id]
+		if !ok {
+			continue
+		}
+
+		for _, parent := range checkpoint.Parents {
+			if !saveExists[parent] {
+				report.addIssue(MISSING_ISSUE, "save "+parent, "parent of save "+id)
+			}
+		}
+
+		checkObjects(checkpoint.Changes, "save "+id)
+	}
+
+	// Trees are checked after the changes, their files missing objects are already reported by the changes.
+	checkedTrees := make(map[string]bool)
+	var checkTree func(hash string, path string, owner string)
+	checkTree = func(hash string, path string, owner string) {
+		if checkedTrees[hash] {
+			return
+		}
+
+		checkedTrees[hash] = true
+		referencedObjects[hash] = true
+
+		referrer := "tree of " + owner
+		if path != fileSystem.Root {
+			referrer = fmt.Sprintf("tree %s of %s", path, owner)
+		}
+
+		if _, ok := objects[hash]; !ok {
+			report.addIssue(MISSING_ISSUE, "object "+hash, referrer)
+			return
+		}
+
+		entries, err := fileSystem.ReadTreeEntries(hash)
+		if err != nil {
+			if !corruptObjects[hash] {
+				report.addCorruptIssue("object "+hash, err)
+			}
+			return
+		}
+
+		for _, entry := range entries {
+			entryPath := Path.Join(path, entry.Name)
+
+			if entry.NodeType == directories.DirType {
+				checkTree(entry.ObjectName, entryPath, owner)
+				continue
+			}
+
+			if _, ok := objects[entry.ObjectName]; !ok && !referencedObjects[entry.ObjectName] {
+				report.addIssue(MISSING_ISSUE, "object "+entry.ObjectName, fmt.Sprintf("%s of %s", entryPath, referrer))
+			}
+
+			referencedObjects[entry.ObjectName] = true
+		}
+	}
+
+	for _, id := range saveNames {
+		if checkpoint, ok := checkpoints[id]; ok && checkpoint.Tree != "" {
+			checkTree(checkpoint.Tree, fileSystem.Root, "save "+id)
+		}
+	}
+
+	index, err := fileSystem.ReadIndex()
+	if err != nil {
+		report.addCorruptIssue("index", err)
+	} else {
+		checkObjects(index, "the index")
+	}
+
+	// Saves reachable from the refs, HEAD, the reflogs, the stashes, the operations snapshots and the merge or
+	// cherry-pick in progress
+	reachableSaves := make(map[string]bool)
+	markReachable := func(id string) {
+		pending := []string{id}
+
+		for len(pending) > 0 {
+			id := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+
+			if id == "" || reachableSaves[id] {
+				continue
+			}
+
+			reachableSaves[id] = true
+
+			if checkpoint, ok := checkpoints[id]; ok {
+				pending = append(pending, checkpoint.Parents...)
+			}
+		}
+	}
+
+	refs, err := fileSystem.ReadRefs()
+	if err != nil {
+		report.addCorruptIssue("refs", err)
+	} else {
+		refNames := []string{}
+		for name := range *refs {
+			refNames = append(refNames, name)
+		}
+		slices.Sort(refNames)
+
+		for _, name := range refNames {
+			saveName := (*refs)[name]
+			if saveName == "" {
+				// Ref without saves history
+				continue
+			}
+
+			if !saveExists[saveName] {
+				report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by ref "+name)
+			}
+
+			markReachable(saveName)
+		}
+	}
+
+	head, err := fileSystem.ReadHead()
+	if err != nil {
+		report.addCorruptIssue("HEAD", err)
+	} else if refs != nil {
+		if _, ok := (*refs)[head]; !ok {
+			// Detached HEAD points to a save
+			if !saveExists[head] {
+				report.addIssue(MISSING_ISSUE, "save "+head, "pointed by HEAD")
+			}
+
+			markReachable(head)
+		}
+	}
+
+	reflogNames, err := fileSystem.ListReflogs()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range reflogNames {
+		entries, err := fileSystem.ReadReflog(name)
+		if err != nil {
+			report.addCorruptIssue("reflog "+name, err)
+			continue
+		}
+
+		reportedSaves := make(map[string]bool)
+		for _, entry := range entries {
+			for _, saveName := range []string{entry.OldId, entry.NewId} {
+				if saveName != "" && !saveExists[saveName] && !reportedSaves[saveName] {
+					reportedSaves[saveName] = true
+					report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by the reflog of "+name)
+				}
+
+				markReachable(saveName)
+			}
+		}
+	}
+
+	stashes, err := fileSystem.ReadStashes()
+	if err != nil {
+		report.addCorruptIssue("stash", err)
+		stashes = []string{}
+	}
+	for idx, saveName := range stashes {
+		if !saveExists[saveName] {
+			report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by stash@{"+strconv.Itoa(idx)+"}")
+		}
+
+		markReachable(saveName)
+	}
+
+	operations, err := fileSystem.ReadOperations()
+	if err != nil {
+		report.addCorruptIssue("operation log", err)
+		operations = []*filesystems.Operation{}
+	}
+	for _, operation := range operations {
+		for _, side := range []filesystems.SnapshotSide{filesystems.SNAPSHOT_BEFORE, filesystems.SNAPSHOT_AFTER} {
+			item := fmt.Sprintf("operation %d %s", operation.Id, side)
+
+			snapshot, err := fileSystem.ReadSnapshot(operation.Id, side)
+			if err != nil {
+				report.addCorruptIssue(item, err)
+				continue
+			}
+
+			saveNames := []string{}
+			for _, saveName := range *snapshot.Refs {
+				saveNames = append(saveNames, saveName)
+			}
+			if _, ok := (*snapshot.Refs)[snapshot.Head]; !ok {
+				saveNames = append(saveNames, snapshot.Head)
+			}
+			if snapshot.MergeState != nil {
+				saveNames = append(saveNames, snapshot.MergeState.RefSave, snapshot.MergeState.IncomingSave)
+			}
+			if snapshot.CherryPickState != nil {
+				saveNames = append(saveNames, getCherryPickSaves(snapshot.CherryPickState)...)
+			}
+			if snapshot.RebaseState != nil {
+				saveNames = append(saveNames, getRebaseSaves(snapshot.RebaseState)...)
+			}
+			saveNames = append(saveNames, snapshot.Stashes...)
+			slices.Sort(saveNames)
+
+			for _, saveName := range slices.Compact(saveNames) {
+				if saveName != "" && !saveExists[saveName] {
+					report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by "+item)
+				}
+
+				markReachable(saveName)
+			}
+
+			checkObjects(snapshot.Index, item)
+			checkObjects(snapshot.WorkingFiles, item)
+		}
+	}
+
+	mergeState, err := fileSystem.ReadMergeState()
+	if err != nil {
+		report.addCorruptIssue("merge state", err)
+	} else if mergeState != nil {
+		for _, saveName := range []string{mergeState.RefSave, mergeState.IncomingSave} {
+			if saveName != "" && !saveExists[saveName] {
+				report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by the merge in progress")
+			}
+
+			markReachable(saveName)
+		}
+	}
+
+	cherryPickState, err := fileSystem.ReadCherryPickState()
+	if err != nil {
+		report.addCorruptIssue("cherry-pick state", err)
+	} else if cherryPickState != nil {
+		for _, saveName := range getCherryPickSaves(cherryPickState) {
+			if saveName != "" && !saveExists[saveName] {
+				report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by the cherry-pick in progress")
+			}
+
+			markReachable(saveName)
+		}
+	}
+
+	rebaseState, err := fileSystem.ReadRebaseState()
+	if err != nil {
+		report.addCorruptIssue("rebase state", err)
+	} else if rebaseState != nil {
+		for _, saveName := range getRebaseSaves(rebaseState) {
+			if saveName != "" && !saveExists[saveName] {
+				report.addIssue(MISSING_ISSUE, "save "+saveName, "pointed by the rebase in progress")
+			}
+
+			markReachable(saveName)
+		}
+	}
+
+	for _, id := range saveNames {
+		if _, ok := checkpoints[id]; ok && !reachableSaves[id] && refs != nil {
+			report.addIssue(DANGLING_ISSUE, "save "+id, "not reachable from refs, HEAD, reflogs or stashes")
+		}
+	}
+	for _, name := range objectNames {
+		if !referencedObjects[name] {
+			report.addIssue(DANGLING_ISSUE, "object "+name, "not referenced by saves, the index or operations")
+		}
+	}
+
+	report.Objects = len(objectNames)
+	report.Saves = len(saveNames)
+
+	return report, nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestFsck(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	repositoryPath := func(paths ...string) string {
+		return dir.Join(append([]string{filesystems.REPOSITORY_FOLDER_NAME}, paths...)...)
+	}
+
+	// Check not a repository
+	{
+		emptyDir := fs.NewDir(t, "empty")
+		defer emptyDir.Remove()
+
+		_, err := Fsck(emptyDir.Path())
+		assert.IsType(t, &filesystems.NotRepositoryError{}, err)
+	}
+
+	// Setup
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	firstSave, _ := repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.IndexFile("3.txt")
+	repository.SaveIndex()
+	secondSave, _ := repository.CreateSave("s1")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Check healthy repository
+	{
+		report, err := Fsck(dir.Path())
+
+		assert.Nil(t, err)
+		assert.Equal(t, report.Objects, 5)
+		assert.Equal(t, report.Saves, 2)
+		assert.Equal(t, report.Issues, []*FsckIssue{})
+		assert.False(t, report.HasErrors())
+	}
+
+	// Check dangling items
+	{
+		fixtures.WriteFile(dir.Join("4.txt"), []byte("4 content (v2)"))
+		repository.IndexFile("4.txt")
+		danglingObject := repository.findStagedChange(dir.Join("4.txt")).GetHash()
+		repository.RemoveFile("4.txt")
+		repository.SaveIndex()
+		danglingSaveName, _ := repository.fs.WriteCheckpoint(&filesystems.Checkpoint{Message: "dangling", Parents: []string{firstSave.Id}, CreatedAt: time.Now()})
+		// Saves left behind by a ref are still reachable from its reflog
+		repository.setRef(filesystems.INITIAL_REF_NAME, firstSave.Id, "test")
+
+		report, err := Fsck(dir.Path())
+
+		assert.Nil(t, err)
+		assert.False(t, report.HasErrors())
+		assert.Equal(
+			t,
+			report.Issues,
+			[]*FsckIssue{
+				{Type: DANGLING_ISSUE, Item: "save " + danglingSaveName, Message: "not reachable from refs, HEAD, reflogs or stashes"},
+				{Type: DANGLING_ISSUE, Item: "object " + danglingObject, Message: "not referenced by saves, the index or operations"},
+			},
+		)
+
+		repository.setRef(filesystems.INITIAL_REF_NAME, secondSave.Id, "test")
+		repository.fs.RemoveCheckpoint(danglingSaveName)
+		repository.CollectGarbage(&GarbageCollectionOptions{})
+	}
+
+	// Check corrupt and missing items
+	{
+		missingObject := secondSave.Changes[0].File.ObjectName
+		corruptObject := firstSave.Changes[1].File.ObjectName
+		orphanSaveName, _ := repository.fs.WriteCheckpoint(&filesystems.Checkpoint{Message: "orphan", Parents: []string{"missing-parent"}, CreatedAt: time.Now()})
+
+		assert.Nil(t, os.Remove(repositoryPath(filesystems.OBJECTS_FOLDER_NAME, missingObject)))
+		assert.Nil(t, os.Remove(repositoryPath(filesystems.OBJECTS_FOLDER_NAME, secondSave.Tree)))
+		fixtures.WriteFile(repositoryPath(filesystems.OBJECTS_FOLDER_NAME, corruptObject), gzipHelper([]byte("tampered")))
+		fixtures.WriteFile(repositoryPath(filesystems.SAVES_FOLDER_NAME, "corrupt-save"), []byte("tampered"))
+		fixtures.WriteFile(repositoryPath(filesystems.INDEX_FILE_NAME), []byte("Tracked files:\n\n1.txt\t(unknown)\n"))
+		repository.setRef("feature", orphanSaveName, "test")
+		repository.setRef("broken", "missing-save", "test")
+		fixtures.WriteFile(repositoryPath(filesystems.LOGS_FOLDER_NAME, filesystems.REFS_FILE_NAME, "feature"), []byte("tampered\n"))
+		repository.fs.WriteStashes([]string{"missing-stash"})
+
+		hasher := sha256.New()
+		hasher.Write([]byte("tampered"))
+		tamperedHash := hex.EncodeToString(hasher.Sum(nil))
+
+		report, err := Fsck(dir.Path())
+
+		assert.Nil(t, err)
+		assert.True(t, report.HasErrors())
+		// Saves are checked in name order, which depends on their content
+		assert.ElementsMatch(
+			t,
+			report.Issues,
+			[]*FsckIssue{
+				{Type: CORRUPT_ISSUE, Item: "object " + corruptObject, Message: "content hash is " + tamperedHash},
+				{Type: CORRUPT_ISSUE, Item: "save corrupt-save", Message: "content hash is " + tamperedHash},
+				{Type: MISSING_ISSUE, Item: "object " + missingObject, Message: dir.Join("3.txt") + " of save " + secondSave.Id},
+				{Type: MISSING_ISSUE, Item: "object " + secondSave.Tree, Message: "tree of save " + secondSave.Id},
+				{Type: MISSING_ISSUE, Item: "save missing-parent", Message: "parent of save " + orphanSaveName},
+				{Type: CORRUPT_ISSUE, Item: "index", Message: "invalid change \"1.txt\t(unknown)\""},
+				{Type: MISSING_ISSUE, Item: "save missing-save", Message: "pointed by ref broken"},
+				{Type: MISSING_ISSUE, Item: "save missing-save", Message: "pointed by the reflog of broken"},
+				{Type: CORRUPT_ISSUE, Item: "reflog feature", Message: "invalid reflog entry \"tampered\""},
+				{Type: MISSING_ISSUE, Item: "save missing-stash", Message: "pointed by stash@{0}"},
+			},
+		)
+	}
+}
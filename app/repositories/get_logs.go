@@ -11,27 +11,66 @@ type Log struct {
 	History []*SaveLog
 }
 
-func (repository *Repository) GetLogs() *Log {
-	save := repository.getSave(repository.head)
+// Get the saves history of a revision, HEAD if omitted.
+//
+// With a "from..to" revision range, only the saves reachable from "to" and not from "from" are logged.
+func (repository *Repository) GetLogs(revision string) (*Log, error) {
+	if revision == "" {
+		revision = "HEAD"
+	}
 
-	if save == nil {
+	from, to, isRange := parseRevisionRange(revision)
+	if !isRange {
+		to = revision
+	}
+
+	if repository.hasEmptySaveHistory() {
 		// repostory without saves history
 
 		return &Log{
 			Head:    repository.head,
 			History: []*SaveLog{},
+		}, nil
+	}
+
+	save, err := repository.getSave(to)
+	if err != nil {
+		return nil, err
+	}
+	if save == nil {
+		return nil, &ValidationError{"invalid ref."}
+	}
+
+	checkpoints := save.Checkpoints
+
+	if isRange {
+		fromSave, err := repository.getSave(from)
+		if err != nil {
+			return nil, err
+		}
+		if fromSave == nil {
+			return nil, &ValidationError{"invalid ref."}
 		}
+
+		fromIds := make(map[string]bool)
+		for _, checkpoint := range fromSave.Checkpoints {
+			fromIds[checkpoint.Id] = true
+		}
+
+		checkpoints = collections.Filter(checkpoints, func(checkpoint *filesystems.Checkpoint, _ int) bool {
+			return !fromIds[checkpoint.Id]
+		})
 	}
 
 	savesToRefsMap := collections.InvertMap(*repository.refs)
 
 	// By default the save checkpoints is ordered by createdAt in ascending order.
 	// The other way around is better for logging.
-	slices.Reverse(save.Checkpoints)
+	slices.Reverse(checkpoints)
 
 	return &Log{
 		Head: repository.head,
-		History: collections.Map(save.Checkpoints, func(checkpoint *filesystems.Checkpoint, _ int) *SaveLog {
+		History: collections.Map(checkpoints, func(checkpoint *filesystems.Checkpoint, _ int) *SaveLog {
 			var refs []string
 
 			if mapSaves, ok := savesToRefsMap[checkpoint.Id]; ok {
@@ -40,5 +79,5 @@ func (repository *Repository) GetLogs() *Log {
 
 			return &SaveLog{Checkpoint: checkpoint, Refs: refs}
 		}),
-	}
+	}, nil
 }
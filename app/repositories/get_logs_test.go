@@ -15,9 +15,10 @@ func TestGetLogs(t *testing.T) {
 
 	// History empty
 
+	log, _ := repository.GetLogs("")
 	assert.EqualValues(
 		t,
-		repository.GetLogs(),
+		log,
 		&Log{Head: filesystems.INITIAL_REF_NAME, History: []*SaveLog{}},
 	)
 
@@ -29,14 +30,14 @@ func TestGetLogs(t *testing.T) {
 	repository.SaveIndex()
 	save0, _ := repository.CreateSave("save0")
 
-	log := repository.GetLogs()
+	log, _ = repository.GetLogs("")
 	assert.Equal(t, log.Head, filesystems.INITIAL_REF_NAME)
 	assert.Equal(t, len(log.History), 1)
 	assert.Equal(t, len(log.History[0].Refs), 1)
 	assert.Equal(t, log.History[0].Refs[0], filesystems.INITIAL_REF_NAME)
 	assert.Equal(t, log.History[0].Checkpoint.Id, save0.Id)
 	assert.Equal(t, log.History[0].Checkpoint.Message, save0.Message)
-	assert.Equal(t, log.History[0].Checkpoint.Parent, save0.Parent)
+	assert.Equal(t, log.History[0].Checkpoint.Parents, save0.Parents)
 	// When saving the time in the file, using the Layout format, we lose the ms precision.
 	// Therefore this is needed to compare times
 	assert.Equal(t, log.History[0].Checkpoint.CreatedAt.Format(time.Layout), save0.CreatedAt.Format(time.Layout))
@@ -48,7 +49,7 @@ func TestGetLogs(t *testing.T) {
 
 	// After Save 1
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("2.txt"), []byte("file 2 original content."))
 
@@ -56,14 +57,14 @@ func TestGetLogs(t *testing.T) {
 	repository.SaveIndex()
 	save1, _ := repository.CreateSave("save1")
 
-	log = repository.GetLogs()
+	log, _ = repository.GetLogs("")
 	assert.Equal(t, log.Head, "a")
 	assert.Equal(t, len(log.History), 2)
 	assert.Equal(t, len(log.History[0].Refs), 1)
 	assert.Equal(t, log.History[0].Refs[0], "a")
 	assert.Equal(t, log.History[0].Checkpoint.Id, save1.Id)
 	assert.Equal(t, log.History[0].Checkpoint.Message, save1.Message)
-	assert.Equal(t, log.History[0].Checkpoint.Parent, save1.Parent)
+	assert.Equal(t, log.History[0].Checkpoint.Parents, save1.Parents)
 	// When saving the time in the file, using the Layout format, we lose the ms precision.
 	// Therefore this is needed to compare times
 	assert.Equal(t, log.History[0].Checkpoint.CreatedAt.Format(time.Layout), save1.CreatedAt.Format(time.Layout))
@@ -73,7 +74,7 @@ func TestGetLogs(t *testing.T) {
 	assert.Equal(t, log.History[1].Refs[0], filesystems.INITIAL_REF_NAME)
 	assert.Equal(t, log.History[1].Checkpoint.Id, save0.Id)
 	assert.Equal(t, log.History[1].Checkpoint.Message, save0.Message)
-	assert.Equal(t, log.History[1].Checkpoint.Parent, save0.Parent)
+	assert.Equal(t, log.History[1].Checkpoint.Parents, save0.Parents)
 	// When saving the time in the file, using the Layout format, we lose the ms precision.
 	// Therefore this is needed to compare times
 	assert.Equal(t, log.History[1].Checkpoint.CreatedAt.Format(time.Layout), save0.CreatedAt.Format(time.Layout))
@@ -81,7 +82,7 @@ func TestGetLogs(t *testing.T) {
 
 	// After Save 2
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("3.txt"), []byte("file 3 original content."))
 
@@ -94,13 +95,13 @@ func TestGetLogs(t *testing.T) {
 	repository.CreateRef("b")
 	repository.CreateRef("c")
 
-	log = repository.GetLogs()
+	log, _ = repository.GetLogs("")
 	assert.Equal(t, log.Head, "c")
 	assert.Equal(t, len(log.History), 3)
 	assert.Equal(t, len(log.History[0].Refs), 3)
 	assert.Equal(t, log.History[0].Checkpoint.Id, save2.Id)
 	assert.Equal(t, log.History[0].Checkpoint.Message, save2.Message)
-	assert.Equal(t, log.History[0].Checkpoint.Parent, save2.Parent)
+	assert.Equal(t, log.History[0].Checkpoint.Parents, save2.Parents)
 	// When saving the time in the file, using the Layout format, we lose the ms precision.
 	// Therefore this is needed to compare times
 	assert.Equal(t, log.History[0].Checkpoint.CreatedAt.Format(time.Layout), save2.CreatedAt.Format(time.Layout))
@@ -109,7 +110,7 @@ func TestGetLogs(t *testing.T) {
 	assert.Equal(t, len(log.History[1].Refs), 0)
 	assert.Equal(t, log.History[1].Checkpoint.Id, save1.Id)
 	assert.Equal(t, log.History[1].Checkpoint.Message, save1.Message)
-	assert.Equal(t, log.History[1].Checkpoint.Parent, save1.Parent)
+	assert.Equal(t, log.History[1].Checkpoint.Parents, save1.Parents)
 	// When saving the time in the file, using the Layout format, we lose the ms precision.
 	// Therefore this is needed to compare times
 	assert.Equal(t, log.History[1].Checkpoint.CreatedAt.Format(time.Layout), save1.CreatedAt.Format(time.Layout))
@@ -119,9 +120,62 @@ func TestGetLogs(t *testing.T) {
 	assert.Equal(t, log.History[2].Refs[0], filesystems.INITIAL_REF_NAME)
 	assert.Equal(t, log.History[2].Checkpoint.Id, save0.Id)
 	assert.Equal(t, log.History[2].Checkpoint.Message, save0.Message)
-	assert.Equal(t, log.History[2].Checkpoint.Parent, save0.Parent)
+	assert.Equal(t, log.History[2].Checkpoint.Parents, save0.Parents)
 	// When saving the time in the file, using the Layout format, we lose the ms precision.
 	// Therefore this is needed to compare times
 	assert.Equal(t, log.History[2].Checkpoint.CreatedAt.Format(time.Layout), save0.CreatedAt.Format(time.Layout))
 	assert.EqualValues(t, log.History[2].Checkpoint.Changes, save0.Changes)
 }
+
+func TestGetLogsRange(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save0, _ := repository.CreateSave("save0")
+	repository.CreateRef("feature")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 feature content."))
+
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save1, _ := repository.CreateSave("save1")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 feature content."))
+
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	save2, _ := repository.CreateSave("save2")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+
+	log, err := repository.GetLogs(filesystems.INITIAL_REF_NAME + "..feature")
+	assert.Nil(t, err)
+	assert.Equal(t, len(log.History), 2)
+	assert.Equal(t, log.History[0].Checkpoint.Id, save2.Id)
+	assert.Equal(t, log.History[1].Checkpoint.Id, save1.Id)
+
+	log, err = repository.GetLogs("HEAD~1")
+	assert.Nil(t, err)
+	assert.Equal(t, len(log.History), 2)
+	assert.Equal(t, log.History[0].Checkpoint.Id, save1.Id)
+	assert.Equal(t, log.History[1].Checkpoint.Id, save0.Id)
+
+	log, err = repository.GetLogs("feature..")
+	assert.Nil(t, err)
+	assert.Equal(t, len(log.History), 0)
+
+	_, err = repository.GetLogs("undefined..feature")
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+type Reflog struct {
+	// HEAD or the ref name.
+	Name string
+	// Entries from the newest to the oldest, so the Nth entry is "name@{N}".
+	Entries []*filesystems.ReflogEntry
+}
+
+// Get the movements of a ref, HEAD if omitted.
+func (repository *Repository) GetReflog(ref string) (*Reflog, error) {
+	if ref == "" {
+		ref = filesystems.HEAD_REFLOG_NAME
+	}
+
+	reflogName := repository.getRevisionReflogName(ref)
+	if reflogName == "" {
+		return nil, &ValidationError{"invalid ref."}
+	}
+
+	entries, err := repository.fs.ReadReflog(reflogName)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(entries)
+
+	return &Reflog{Name: reflogName, Entries: entries}, nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"saymow/version-manager/app/pkg/collections"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReflog(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	getCommands := func(reflog *Reflog) []string {
+		return collections.Map(reflog.Entries, func(entry *filesystems.ReflogEntry, _ int) string {
+			return entry.Command
+		})
+	}
+
+	// Check empty reflogs
+	{
+		reflog, err := repository.GetReflog("")
+		assert.Nil(t, err)
+		assert.Equal(t, reflog.Name, filesystems.HEAD_REFLOG_NAME)
+		assert.Equal(t, len(reflog.Entries), 0)
+
+		_, err = repository.GetReflog("undefined")
+		assert.EqualError(t, err, "Validation Error: invalid ref.")
+	}
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save0, _ := repository.CreateSave("save0")
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CreateRef("feature")
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 updated content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save1, _ := repository.CreateSave("save1")
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Load(filesystems.INITIAL_REF_NAME)
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Merge("feature", &MergeOptions{})
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+
+	reflog, err := repository.GetReflog("HEAD")
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		getCommands(reflog),
+		[]string{
+			"merge feature: fast-forward",
+			"load: moving from feature to master",
+			"save: save1",
+			"ref: moving to feature",
+			"save: save0",
+		},
+	)
+	assert.Equal(t, reflog.Entries[0].OldId, save0.Id)
+	assert.Equal(t, reflog.Entries[0].NewId, save1.Id)
+	assert.Equal(t, reflog.Entries[1].NewId, save0.Id)
+
+	reflog, err = repository.GetReflog(filesystems.INITIAL_REF_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, getCommands(reflog), []string{"merge feature: fast-forward", "save: save0"})
+
+	reflog, err = repository.GetReflog("feature")
+	assert.Nil(t, err)
+	assert.Equal(t, getCommands(reflog), []string{"save: save1", "ref: create feature"})
+
+	// Check previous positions can be loaded
+	assert.Nil(t, repository.Load("master@{1}"))
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.head, save0.Id)
+}
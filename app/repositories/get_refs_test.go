@@ -24,7 +24,7 @@ func TestRefs(t *testing.T) {
 	repository.CreateRef("feat/b")
 
 	// Test
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 	assert.Equal(
 		t,
 		fixtures.ReadFile(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.HEAD_FILE_NAME)),
@@ -39,7 +39,7 @@ func TestRefs(t *testing.T) {
 	// Save (move current save as a side effect) and create refs
 	{
 		// Setup
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("new.txt"), []byte("it does not matter."))
 
@@ -63,7 +63,7 @@ func TestRefs(t *testing.T) {
 		})
 
 		// Setup
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("new.txt"), []byte("it does not matter 2.0."))
 
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"fmt"
+	"regexp"
+	"saymow/version-manager/app/repositories/filesystems"
+	"strconv"
+)
+
+// Stashes are named "stash@{N}", the newest stash is "stash@{0}".
+const STASH_REVISION = "stash"
+
+var stashNameRegex = regexp.MustCompile(`^(?:stash@\{(\d+)\}|(\d+))$`)
+
+func getStashName(index int) string {
+	return fmt.Sprintf("%s@{%d}", STASH_REVISION, index)
+}
+
+// Find the position and save name of a stash, given as "stash@{N}" or N. The newest stash is used if omitted.
+func (repository *Repository) findStash(stash string) (int, string, error) {
+	ids, err := repository.fs.ReadStashes()
+	if err != nil {
+		return 0, "", err
+	}
+
+	index := 0
+	if stash != "" {
+		match := stashNameRegex.FindStringSubmatch(stash)
+		if match == nil {
+			return 0, "", &ValidationError{"invalid stash."}
+		}
+
+		if index, err = strconv.Atoi(match[1] + match[2]); err != nil {
+			return 0, "", &ValidationError{"invalid stash."}
+		}
+	}
+
+	if index >= len(ids) {
+		if stash == "" {
+			return 0, "", &ValidationError{"no stashes."}
+		}
+
+		return 0, "", &ValidationError{"invalid stash."}
+	}
+
+	return index, ids[index], nil
+}
+
+// Get a stash save, given as "stash@{N}" or N. The newest stash is used if omitted.
+//
+// The stash save has the HEAD save it was pushed on as first parent and the index save as second parent.
+func (repository *Repository) GetStash(stash string) (*filesystems.Checkpoint, error) {
+	_, id, err := repository.findStash(stash)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.fs.ReadCheckpoint(id)
+}
+
+// Get the stashes saves, from the newest to the oldest, so the Nth stash is "stash@{N}".
+func (repository *Repository) GetStashes() ([]*filesystems.Checkpoint, error) {
+	ids, err := repository.fs.ReadStashes()
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := []*filesystems.Checkpoint{}
+	for _, id := range ids {
+		checkpoint, err := repository.fs.ReadCheckpoint(id)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"saymow/version-manager/app/pkg/collections"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStashes(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	getMessages := func(checkpoints []*filesystems.Checkpoint) []string {
+		return collections.Map(checkpoints, func(checkpoint *filesystems.Checkpoint, _ int) string {
+			return checkpoint.Message
+		})
+	}
+
+	// Check empty stashes
+	stashes, err := repository.GetStashes()
+	assert.Nil(t, err)
+	assert.Equal(t, len(stashes), 0)
+
+	_, err = repository.GetStash("")
+	assert.EqualError(t, err, "Validation Error: no stashes.")
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save, _ := repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	for _, message := range []string{"first", "second", "third"} {
+		fixtures.WriteFile(dir.Join("1.txt"), []byte(message+" content."))
+		repository.PushStash(message)
+		repository = fixtureGetRepository(t, dir.Path())
+	}
+
+	// Test
+	stashes, err = repository.GetStashes()
+	assert.Nil(t, err)
+	assert.Equal(t, getMessages(stashes), []string{"third", "second", "first"})
+
+	for stash, message := range map[string]string{"": "third", "1": "second", "stash@{2}": "first"} {
+		checkpoint, err := repository.GetStash(stash)
+		assert.Nil(t, err)
+		assert.Equal(t, checkpoint.Message, message)
+		assert.Equal(t, checkpoint.Parents[0], save.Id)
+	}
+
+	_, err = repository.GetStash("stash@{3}")
+	assert.EqualError(t, err, "Validation Error: invalid stash.")
+}
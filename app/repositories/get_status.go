@@ -1,22 +1,19 @@
 package repositories
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"io/fs"
 	"os"
 	Path "path/filepath"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
-	"strings"
 
 	"github.com/golang-collections/collections/set"
 )
 
-func (repository *Repository) GetStatus() *Status {
+func (repository *Repository) GetStatus() (*Status, error) {
 	status := Status{}
 	seenPaths := set.New()
 	trackedPaths := set.New()
@@ -29,15 +26,7 @@ func (repository *Repository) GetStatus() *Status {
 		trackedPaths.Insert(change.GetPath())
 	}
 
-	Path.Walk(repository.fs.Root, func(filepath string, info fs.FileInfo, err error) error {
-		errors.Check(err)
-		if repository.fs.Root == filepath || strings.HasPrefix(filepath, Path.Join(repository.fs.Root, filesystems.REPOSITORY_FOLDER_NAME)) {
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
-
+	err := repository.walkWorkingDir(func(filepath string) error {
 		seenPaths.Insert(filepath)
 
 		savedFile := repository.findSavedFile(filepath)
@@ -48,32 +37,11 @@ func (repository *Repository) GetStatus() *Status {
 			return nil
 		}
 
-		file, err := os.Open(filepath)
-		errors.Check(err)
-		defer errors.CheckFn(file.Close)
-
-		var buffer bytes.Buffer
-		chunkBuffer := make([]byte, 1024)
-
-		for {
-			n, err := file.Read(chunkBuffer)
-
-			if err != nil && err != io.EOF {
-				errors.Error(err.Error())
-			}
-			if n == 0 {
-				break
-			}
-
-			_, err = buffer.Write(chunkBuffer[:n])
-			errors.Check(err)
+		fileHash, err := hashWorkingFile(filepath)
+		if err != nil {
+			return err
 		}
 
-		hasher := sha256.New()
-		_, err = hasher.Write(buffer.Bytes())
-		errors.Check(err)
-		fileHash := hex.EncodeToString(hasher.Sum(nil))
-
 		if stagedChange != nil {
 			if stagedChange.ChangeType == directories.Removal {
 				status.Staged.RemovedFilePaths = append(status.Staged.RemovedFilePaths, stagedChange.Removal.Filepath)
@@ -105,6 +73,9 @@ func (repository *Repository) GetStatus() *Status {
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	trackedPaths.Difference(seenPaths).Do(func(i interface{}) {
 		filepath := i.(string)
@@ -116,5 +87,64 @@ func (repository *Repository) GetStatus() *Status {
 		}
 	})
 
-	return &status
+	if status.Merge, err = repository.fs.ReadMergeState(); err != nil {
+		return nil, err
+	}
+	if status.CherryPick, err = repository.fs.ReadCherryPickState(); err != nil {
+		return nil, err
+	}
+	if status.Rebase, err = repository.fs.ReadRebaseState(); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// Walk the working directory files, skipping the repository folder.
+//
+// Ignored paths are skipped as well, unless they are tracked.
+func (repository *Repository) walkWorkingDir(callback func(filepath string) error) error {
+	return Path.Walk(repository.fs.Root, func(filepath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if repository.fs.Root == filepath {
+			return nil
+		}
+		if filepath == Path.Join(repository.fs.Root, filesystems.REPOSITORY_FOLDER_NAME) {
+			return Path.SkipDir
+		}
+
+		ignored, err := repository.ignore.IsIgnored(filepath, info.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored && !repository.isTracked(filepath) {
+			if info.IsDir() {
+				return Path.SkipDir
+			}
+
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		return callback(filepath)
+	})
+}
+
+func hashWorkingFile(filepath string) (string, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
@@ -4,6 +4,8 @@ import (
 	path "path/filepath"
 	"saymow/version-manager/app/pkg/fixtures"
 	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"saymow/version-manager/app/repositories/ignores"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,7 +25,7 @@ func TestGetStatus(t *testing.T) {
 		repository.SaveIndex()
 		repository.CreateSave("initial save")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		repository.IndexFile("2.txt")
 		fixtures.WriteFile(dir.Join("a", "4.txt"), []byte("4 new content"))
@@ -31,12 +33,12 @@ func TestGetStatus(t *testing.T) {
 		repository.RemoveFile(path.Join("a", "b", "6.txt"))
 		repository.SaveIndex()
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("c", "8.txt"), []byte("8 new content"))
 		fixtures.RemoveFile(dir.Join("c", "9.txt"))
 
-		status := repository.GetStatus()
+		status, _ := repository.GetStatus()
 
 		assert.EqualValues(t, status.Staged.CreatedFilesPaths, []string{dir.Join("2.txt")})
 		assert.EqualValues(t, status.Staged.ModifiedFilePaths, []string{dir.Join("a", "4.txt")})
@@ -64,7 +66,7 @@ func TestGetStatus(t *testing.T) {
 
 		fixtures.WriteFile(dir.Join("1.txt"), []byte("it is definitely gonna fix the conflict."))
 
-		status := repository.GetStatus()
+		status, _ := repository.GetStatus()
 
 		assert.EqualValues(t, len(status.Staged.ConflictedFilesPaths), 1)
 		assert.EqualValues(t, status.Staged.ConflictedFilesPaths[0].Filepath, dir.Join("1.txt"))
@@ -81,3 +83,38 @@ func TestGetStatus(t *testing.T) {
 		)
 	}
 }
+
+func TestGetStatusIgnoredFiles(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join(ignores.IGNORE_FILE_NAME), []byte("*.log\nb/\n"))
+	fixtures.WriteFile(dir.Join("a", ignores.IGNORE_FILE_NAME), []byte("5.txt\n"))
+	fixtures.WriteFile(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.EXCLUDE_FILE_NAME), []byte("c/\n"))
+	fixtures.WriteFile(dir.Join("error.log"), []byte("error."))
+
+	repository.IndexFile("1.txt")
+	repository.IndexFile(ignores.IGNORE_FILE_NAME)
+	repository.SaveIndex()
+	repository.CreateSave("initial save")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	status, _ := repository.GetStatus()
+
+	assert.EqualValues(
+		t,
+		status.WorkingDir.UntrackedFilePaths,
+		[]string{dir.Join("2.txt"), dir.Join("3.txt"), dir.Join("a", ignores.IGNORE_FILE_NAME), dir.Join("a", "4.txt")},
+	)
+
+	// Tracked files are not ignored
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 updated content"))
+	fixtures.WriteFile(dir.Join(ignores.IGNORE_FILE_NAME), []byte("*.log\nb/\n1.txt\n"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	status, _ = repository.GetStatus()
+
+	assert.EqualValues(t, status.WorkingDir.ModifiedFilePaths, []string{dir.Join(ignores.IGNORE_FILE_NAME), dir.Join("1.txt")})
+}
@@ -0,0 +1,216 @@
+package ignores
+
+import (
+	"os"
+	"path"
+	Path "path/filepath"
+	"strings"
+)
+
+const IGNORE_FILE_NAME = ".vcsignore"
+
+type Pattern struct {
+	// Slash separated directory, relative to the root, where the pattern was declared.
+	Base     string
+	Glob     string
+	Negated  bool
+	DirOnly  bool
+	Anchored bool
+}
+
+// Matcher follows the .gitignore rules:
+//
+//   - Blank lines and lines starting with "#" are skipped.
+//   - A "!" prefix negates the pattern, re-including previously ignored paths.
+//   - A trailing "/" only matches directories.
+//   - Patterns with a leading or middle "/" are relative to the .vcsignore directory, otherwise
+//     they match at any depth.
+//   - "*", "?" and "[...]" match within a path segment, "**" matches any number of segments.
+//   - The last matching pattern wins and deeper .vcsignore files take precedence over upper ones.
+//   - Paths inside an ignored directory cannot be re-included.
+type Matcher struct {
+	Root     string
+	patterns []*Pattern
+	loaded   map[string]bool
+}
+
+func Open(root string, excludeFilepath string) (*Matcher, error) {
+	matcher := &Matcher{Root: root, patterns: []*Pattern{}, loaded: make(map[string]bool)}
+
+	// The exclude file has the lowest precedence, so it is loaded first.
+	if err := matcher.loadFile("", excludeFilepath); err != nil {
+		return nil, err
+	}
+
+	return matcher, nil
+}
+
+func ParsePattern(base string, line string) *Pattern {
+	line = strings.TrimRight(line, "\r")
+
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	pattern := &Pattern{Base: base}
+
+	if strings.HasPrefix(line, "!") {
+		pattern.Negated = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pattern.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		pattern.Anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	pattern.Glob = line
+
+	return pattern
+}
+
+func (matcher *Matcher) AddPatterns(base string, content string) {
+	for _, line := range strings.Split(content, "\n") {
+		pattern := ParsePattern(base, line)
+
+		if pattern != nil {
+			matcher.patterns = append(matcher.patterns, pattern)
+		}
+	}
+}
+
+func (matcher *Matcher) loadFile(base string, filepath string) error {
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	matcher.AddPatterns(base, string(content))
+
+	return nil
+}
+
+// Load the .vcsignore file of a directory, relative to the root, once.
+func (matcher *Matcher) loadDir(dir string) error {
+	if matcher.loaded[dir] {
+		return nil
+	}
+
+	matcher.loaded[dir] = true
+
+	return matcher.loadFile(dir, Path.Join(matcher.Root, Path.FromSlash(dir), IGNORE_FILE_NAME))
+}
+
+// Match glob segments against path segments, "**" matches zero or more segments.
+func matchSegments(globSegments []string, pathSegments []string) bool {
+	if len(globSegments) == 0 {
+		return len(pathSegments) == 0
+	}
+
+	if globSegments[0] == "**" {
+		for idx := 0; idx <= len(pathSegments); idx++ {
+			if matchSegments(globSegments[1:], pathSegments[idx:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(pathSegments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(globSegments[0], pathSegments[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(globSegments[1:], pathSegments[1:])
+}
+
+// Match a slash separated glob against a slash separated path.
+func MatchGlob(glob string, filepath string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(filepath, "/"))
+}
+
+// Check whether the pattern matches a slash separated path relative to the root.
+func (pattern *Pattern) Match(filepath string, isDir bool) bool {
+	if pattern.DirOnly && !isDir {
+		return false
+	}
+
+	if pattern.Base != "" {
+		if !strings.HasPrefix(filepath, pattern.Base+"/") {
+			return false
+		}
+
+		filepath = filepath[len(pattern.Base)+1:]
+	}
+
+	if !pattern.Anchored {
+		return MatchGlob("**/"+pattern.Glob, filepath)
+	}
+
+	return MatchGlob(pattern.Glob, filepath)
+}
+
+func (matcher *Matcher) matchPath(filepath string, isDir bool) bool {
+	for idx := len(matcher.patterns) - 1; idx >= 0; idx-- {
+		if matcher.patterns[idx].Match(filepath, isDir) {
+			return !matcher.patterns[idx].Negated
+		}
+	}
+
+	return false
+}
+
+// Check whether an absolute path is ignored.
+func (matcher *Matcher) IsIgnored(filepath string, isDir bool) (bool, error) {
+	relativePath, err := Path.Rel(matcher.Root, filepath)
+	if err != nil || relativePath == "." || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(Path.Separator)) {
+		return false, nil
+	}
+
+	segments := strings.Split(Path.ToSlash(relativePath), "/")
+	if err := matcher.loadDir(""); err != nil {
+		return false, err
+	}
+
+	for idx := 1; idx <= len(segments); idx++ {
+		subpath := strings.Join(segments[:idx], "/")
+		subpathIsDir := idx < len(segments) || isDir
+
+		if matcher.matchPath(subpath, subpathIsDir) {
+			// Paths inside an ignored directory are ignored as well.
+			return true, nil
+		}
+		if subpathIsDir {
+			if err := matcher.loadDir(subpath); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,114 @@
+package ignores
+
+import (
+	Path "path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestParsePattern(t *testing.T) {
+	assert.Nil(t, ParsePattern("", ""))
+	assert.Nil(t, ParsePattern("", "   "))
+	assert.Nil(t, ParsePattern("", "# comment"))
+	assert.Equal(t, ParsePattern("", "\\#file"), &Pattern{Glob: "#file"})
+	assert.Equal(t, ParsePattern("", "\\!file"), &Pattern{Glob: "!file"})
+	assert.Equal(t, ParsePattern("", "*.log  "), &Pattern{Glob: "*.log"})
+	assert.Equal(t, ParsePattern("", "!important.log"), &Pattern{Glob: "important.log", Negated: true})
+	assert.Equal(t, ParsePattern("", "build/"), &Pattern{Glob: "build", DirOnly: true})
+	assert.Equal(t, ParsePattern("", "/build"), &Pattern{Glob: "build", Anchored: true})
+	assert.Equal(t, ParsePattern("a", "b/*.txt"), &Pattern{Base: "a", Glob: "b/*.txt", Anchored: true})
+}
+
+func TestMatchGlob(t *testing.T) {
+	assert.True(t, MatchGlob("*.go", "main.go"))
+	assert.False(t, MatchGlob("*.go", "app/main.go"))
+	assert.True(t, MatchGlob("**/*.go", "main.go"))
+	assert.True(t, MatchGlob("**/*.go", "app/cmd/main.go"))
+	assert.True(t, MatchGlob("app/**", "app/cmd/main.go"))
+	assert.True(t, MatchGlob("app/**/main.go", "app/main.go"))
+	assert.True(t, MatchGlob("app/**/main.go", "app/a/b/main.go"))
+	assert.False(t, MatchGlob("app/**/main.go", "lib/main.go"))
+	assert.True(t, MatchGlob("file?.[ch]", "file1.c"))
+	assert.False(t, MatchGlob("file?.[ch]", "file12.c"))
+}
+
+func TestPatternMatch(t *testing.T) {
+	// Unanchored patterns match at any depth
+	pattern := ParsePattern("", "*.swp")
+	assert.True(t, pattern.Match(".main.go.swp", false))
+	assert.True(t, pattern.Match("app/.main.go.swp", false))
+
+	// Anchored patterns match relative to their base
+	pattern = ParsePattern("", "/build")
+	assert.True(t, pattern.Match("build", true))
+	assert.False(t, pattern.Match("app/build", true))
+
+	pattern = ParsePattern("app", "build")
+	assert.True(t, pattern.Match("app/build", true))
+	assert.True(t, pattern.Match("app/cmd/build", true))
+	assert.False(t, pattern.Match("build", true))
+
+	// Directory patterns only match directories
+	pattern = ParsePattern("", "node_modules/")
+	assert.True(t, pattern.Match("node_modules", true))
+	assert.False(t, pattern.Match("node_modules", false))
+}
+
+func TestIsIgnored(t *testing.T) {
+	dir := fs.NewDir(
+		t,
+		"project",
+		fs.WithFile(IGNORE_FILE_NAME, "*.log\nnode_modules/\n/build\n!keep.log\n"),
+		fs.WithFile("exclude", "*.tmp\n*.bak\n"),
+		fs.WithDir(
+			"app",
+			fs.WithFile(IGNORE_FILE_NAME, "!debug.log\n*.bak\n!*.bak\n"),
+		),
+		fs.WithDir(
+			"lib",
+			fs.WithFile(IGNORE_FILE_NAME, "/generated/\n"),
+		),
+	)
+	defer dir.Remove()
+
+	matcher, err := Open(dir.Path(), dir.Join("exclude"))
+	assert.Nil(t, err)
+
+	isIgnored := func(filepath string, isDir bool) bool {
+		ignored, err := matcher.IsIgnored(filepath, isDir)
+		assert.Nil(t, err)
+
+		return ignored
+	}
+
+	// Root patterns
+	assert.True(t, isIgnored(dir.Join("error.log"), false))
+	assert.True(t, isIgnored(dir.Join("app", "error.log"), false))
+	assert.False(t, isIgnored(dir.Join("keep.log"), false))
+	assert.True(t, isIgnored(dir.Join("build"), true))
+	assert.True(t, isIgnored(dir.Join("build", "main"), false))
+	assert.False(t, isIgnored(dir.Join("app", "build"), true))
+	assert.True(t, isIgnored(dir.Join("node_modules"), true))
+	assert.True(t, isIgnored(dir.Join("app", "node_modules", "lib", "index.js"), false))
+	assert.False(t, isIgnored(dir.Join("main.go"), false))
+	assert.False(t, isIgnored(dir.Path(), true))
+	assert.False(t, isIgnored(Path.Join(dir.Path(), "..", "error.log"), false))
+	assert.True(t, isIgnored(dir.Join("..error.log"), false))
+
+	// Exclude file patterns
+	assert.True(t, isIgnored(dir.Join("a.tmp"), false))
+	assert.True(t, isIgnored(dir.Join("a.bak"), false))
+
+	// Nested patterns take precedence
+	assert.False(t, isIgnored(dir.Join("app", "debug.log"), false))
+	assert.True(t, isIgnored(dir.Join("debug.log"), false))
+	assert.False(t, isIgnored(dir.Join("app", "a.bak"), false))
+	assert.True(t, isIgnored(dir.Join("lib", "generated"), true))
+	assert.True(t, isIgnored(dir.Join("lib", "generated", "a.go"), false))
+	assert.False(t, isIgnored(dir.Join("lib", "a", "generated"), true))
+
+	// Ignored directories content cannot be re-included
+	assert.True(t, isIgnored(dir.Join("node_modules", "keep.log"), false))
+}
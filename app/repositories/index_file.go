@@ -1,9 +1,11 @@
 package repositories
 
 import (
+	"fmt"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
+	Path "path/filepath"
 	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
 	"slices"
 )
 
@@ -16,14 +18,48 @@ func (repository *Repository) IndexFile(filepath string) error {
 	if err != nil {
 		return &ValidationError{err.Error()}
 	}
+	path, err := Path.Rel(repository.fs.Root, filepath)
+	if err != nil {
+		return err
+	}
+	if !filesystems.IsValidName(path) {
+		return &ValidationError{fmt.Sprintf("path %q has a tab or a line break, it cannot be indexed.", Path.ToSlash(path))}
+	}
+
+	ignored, err := repository.ignore.IsIgnored(filepath, false)
+	if err != nil {
+		return err
+	}
+	if ignored && !repository.isTracked(filepath) {
+		return &ValidationError{"path is ignored."}
+	}
 
 	file, err := os.Open(filepath)
-	errors.Check(err)
-	defer errors.CheckFn(file.Close)
+	if os.IsNotExist(err) && repository.isTrackedFile(filepath) {
+		// Deleted tracked files are staged for removal
+		repository.stageRemoval(filepath)
+
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	object, err := repository.fs.WriteObject(filepath, file)
+	if err != nil {
+		return err
+	}
+
+	repository.stageFile(object)
 
-	object := repository.fs.WriteObject(filepath, file)
-	stagedChangeIdx := repository.findStagedChangeIdx(filepath)
-	savedObject := repository.findSavedFile(filepath)
+	return nil
+}
+
+// Replace the index entry of a file path by the file object, unless it matches the saved file.
+func (repository *Repository) stageFile(object *directories.File) {
+	stagedChangeIdx := repository.findStagedChangeIdx(object.Filepath)
+	savedObject := repository.findSavedFile(object.Filepath)
 	var ChangeType directories.ChangeType
 
 	if savedObject != nil {
@@ -32,42 +68,96 @@ func (repository *Repository) IndexFile(filepath string) error {
 		ChangeType = directories.Creation
 	}
 
-	if savedObject != nil && savedObject.ObjectName == object.ObjectName {
-		// No changes at all
+	// Replaced objects are not removed, they may be referenced by saves or other index entries.
+	// Unreachable objects are pruned by CollectGarbage.
+	if stagedChangeIdx != -1 {
+		// Undo index existing change
+		repository.index = slices.Delete(repository.index, stagedChangeIdx, stagedChangeIdx+1)
+	}
+	if savedObject == nil || savedObject.ObjectName != object.ObjectName {
+		// Index change
+		repository.index = append(repository.index, &directories.Change{ChangeType: ChangeType, File: object})
+	}
+}
+
+type IndexOptions struct {
+	// Index every working directory change, including untracked files.
+	All bool
+	// Index only the changes of tracked files.
+	Update bool
+}
+
+// Index the working directory changes matched by the pathspecs.
+//
+// Modified files are indexed, deleted tracked files are staged for removal and untracked files are
+// indexed unless Update is set. Without pathspecs, All or Update must be set and the whole working
+// directory is indexed.
+func (repository *Repository) IndexFiles(specs []string, options *IndexOptions) error {
+	if repository.isDetachedMode() {
+		return &ValidationError{"cannot make changes in detached mode."}
+	}
+	if len(specs) == 0 && !options.All && !options.Update {
+		return &ValidationError{"nothing specified, nothing added."}
+	}
+
+	pathspecs, err := repository.parsePathspecs(specs)
+	if err != nil {
+		return err
+	}
 
-		if stagedChangeIdx != -1 {
-			if repository.index[stagedChangeIdx].GetHash() != object.ObjectName {
-				// Remove change file object
-				repository.fs.RemoveObject(repository.index[stagedChangeIdx].GetHash())
+	candidateFilepaths, err := repository.getCandidateFilepaths()
+	if err != nil {
+		return err
+	}
+
+	for idx, pathspec := range pathspecs {
+		if pathspec.isGlob() {
+			if !slices.ContainsFunc(candidateFilepaths, func(filepath string) bool {
+				return pathspec.match(repository.fs.Root, filepath)
+			}) {
+				return &ValidationError{fmt.Sprintf("pathspec \"%s\" did not match any files.", specs[idx])}
 			}
 
-			// Undo index existing change
-			repository.index = slices.Delete(repository.index, stagedChangeIdx, stagedChangeIdx+1)
-		}
-	} else if stagedChangeIdx != -1 {
-		stagedChange := repository.index[stagedChangeIdx]
-
-		if stagedChange.ChangeType == directories.Conflict &&
-			stagedChange.GetHash() != object.ObjectName &&
-			stagedChange.Conflict.IsObjectTemporary() {
-			// Remove conflicted temp file object
-			repository.fs.RemoveObject(stagedChange.GetHash())
+			continue
 		}
 
-		if (stagedChange.ChangeType == directories.Creation || stagedChange.ChangeType == directories.Modification) &&
-			stagedChange.GetHash() != object.ObjectName {
-			// Remove change file object
-			repository.fs.RemoveObject(stagedChange.GetHash())
+		info, err := os.Stat(pathspec.filepath)
+		if err != nil && !repository.isTracked(pathspec.filepath) {
+			return &ValidationError{fmt.Sprintf("pathspec \"%s\" did not match any files.", specs[idx])}
+		}
+		if err == nil && !info.IsDir() && !options.Update {
+			// Literal files are indexed directly, so ignored files are reported.
+			if err := repository.IndexFile(pathspec.filepath); err != nil {
+				return err
+			}
 		}
+	}
 
-		// Undo index existing change
-		repository.index = slices.Delete(repository.index, stagedChangeIdx, stagedChangeIdx+1)
-		// Index change
-		repository.index = append(repository.index, &directories.Change{ChangeType: ChangeType, File: object})
+	status, err := repository.GetStatus()
+	if err != nil {
+		return err
+	}
 
-	} else {
-		// Index change
-		repository.index = append(repository.index, &directories.Change{ChangeType: ChangeType, File: object})
+	if !options.Update {
+		for _, filepath := range status.WorkingDir.UntrackedFilePaths {
+			if matchPathspecs(pathspecs, repository.fs.Root, filepath) {
+				if err := repository.IndexFile(filepath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, filepath := range status.WorkingDir.ModifiedFilePaths {
+		if matchPathspecs(pathspecs, repository.fs.Root, filepath) {
+			if err := repository.IndexFile(filepath); err != nil {
+				return err
+			}
+		}
+	}
+	for _, filepath := range status.WorkingDir.RemovedFilePaths {
+		if matchPathspecs(pathspecs, repository.fs.Root, filepath) {
+			repository.stageRemoval(filepath)
+		}
 	}
 
 	return nil
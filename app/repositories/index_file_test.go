@@ -7,11 +7,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	path "path/filepath"
 	"saymow/version-manager/app/pkg/collections"
 	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/pkg/fixtures"
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
+	"saymow/version-manager/app/repositories/ignores"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +45,7 @@ func TestIndexFile(t *testing.T) {
 				dir.Join(filesystems.REPOSITORY_FOLDER_NAME),
 				fs.Expected(
 					t,
+					fs.WithMode(0755|os.ModeDir),
 					fs.WithFile(filesystems.REFS_FILE_NAME, fmt.Sprintf("Refs:\n\n%s\n\n", filesystems.INITIAL_REF_NAME)),
 					fs.WithFile(filesystems.HEAD_FILE_NAME, filesystems.INITIAL_REF_NAME),
 					fs.WithFile(filesystems.INDEX_FILE_NAME, "Tracked files:\r\n\r\n"),
@@ -79,6 +83,7 @@ func TestIndexFile(t *testing.T) {
 					dir.Join(filesystems.REPOSITORY_FOLDER_NAME),
 					fs.Expected(
 						t,
+						fs.WithMode(0755|os.ModeDir),
 						fs.WithFile(filesystems.REFS_FILE_NAME, fmt.Sprintf("Refs:\n\n%s\n\n", filesystems.INITIAL_REF_NAME)),
 						fs.WithFile(filesystems.HEAD_FILE_NAME, filesystems.INITIAL_REF_NAME),
 						fs.WithFile(filesystems.INDEX_FILE_NAME, "Tracked files:\r\n\r\n"),
@@ -119,11 +124,20 @@ func TestIndexFile(t *testing.T) {
 		repository.IndexFile("1.txt")
 
 		hasher := sha256.New()
+		hasher.Write([]byte("1 content"))
+		previousFileHash := hex.EncodeToString(hasher.Sum(nil))
+
+		var previousBuffer bytes.Buffer
+		compressor := gzip.NewWriter(&previousBuffer)
+		compressor.Write([]byte("1 content"))
+		compressor.Close()
+
+		hasher = sha256.New()
 		hasher.Write([]byte("1 new content"))
 		fileHash := hex.EncodeToString(hasher.Sum(nil))
 
 		var buffer bytes.Buffer
-		compressor := gzip.NewWriter(&buffer)
+		compressor = gzip.NewWriter(&buffer)
 		compressor.Write([]byte("1 new content"))
 		compressor.Close()
 
@@ -133,12 +147,15 @@ func TestIndexFile(t *testing.T) {
 				dir.Join(filesystems.REPOSITORY_FOLDER_NAME),
 				fs.Expected(
 					t,
+					fs.WithMode(0755|os.ModeDir),
 					fs.WithFile(filesystems.REFS_FILE_NAME, fmt.Sprintf("Refs:\n\n%s\n\n", filesystems.INITIAL_REF_NAME)),
 					fs.WithFile(filesystems.HEAD_FILE_NAME, filesystems.INITIAL_REF_NAME),
 					fs.WithFile(filesystems.INDEX_FILE_NAME, "Tracked files:\r\n\r\n"),
 					fs.WithDir(filesystems.SAVES_FOLDER_NAME),
 					fs.WithDir(
 						filesystems.OBJECTS_FOLDER_NAME,
+						// The previous object is kept until garbage collected
+						fs.WithFile(previousFileHash, previousBuffer.String()),
 						fs.WithFile(fileHash, buffer.String()),
 					),
 				),
@@ -205,7 +222,7 @@ func TestIndexFileComplexCases(t *testing.T) {
 		}
 
 		// When updating the file to the tree file content, IndexFile should be used to remove
-		// existing index. It should also remove the object from the fs.
+		// existing index. The object is kept until garbage collected.
 		{
 			file, err := os.OpenFile(dir.Join("1.txt"), os.O_WRONLY|os.O_TRUNC, 0644)
 			errors.Check(err)
@@ -221,7 +238,7 @@ func TestIndexFileComplexCases(t *testing.T) {
 			})
 
 			assert.Equal(t, changeIdx, -1)
-			assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, change.File.ObjectName)))
+			assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, change.File.ObjectName)))
 		}
 	}
 }
@@ -288,7 +305,7 @@ func TestIndexConflictedFile(t *testing.T) {
 		assert.Equal(t, repository.index[0].ChangeType, directories.Creation)
 		assert.Equal(t, repository.index[0].File.Filepath, dir.Join("a.txt"))
 		assert.NotEqual(t, repository.index[0].File.ObjectName, tempObjectName)
-		assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, tempObjectName)))
+		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, tempObjectName)))
 	}
 
 	// Test permanent object (part of the history and therefore MUST NOT BE removed if the content changes)
@@ -321,3 +338,115 @@ func TestIndexConflictedFile(t *testing.T) {
 		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, permanentObjectName)))
 	}
 }
+
+func TestIndexIgnoredFile(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join(ignores.IGNORE_FILE_NAME), []byte("1.txt\nb/\n"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.EqualError(t, repository.IndexFile("1.txt"), "Validation Error: path is ignored.")
+	assert.EqualError(t, repository.IndexFile(dir.Join("a", "b", "6.txt")), "Validation Error: path is ignored.")
+	assert.Nil(t, repository.IndexFile("2.txt"))
+	assert.Equal(t, len(repository.index), 1)
+}
+
+func TestIndexInvalidName(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join("a", "7\t.txt"), []byte("7 content"))
+	fixtures.WriteFile(dir.Join("8\n.txt"), []byte("8 content"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.EqualError(t, repository.IndexFile(dir.Join("a", "7\t.txt")), "Validation Error: path \"a/7\\t.txt\" has a tab or a line break, it cannot be indexed.")
+	assert.EqualError(t, repository.IndexFile("8\n.txt"), "Validation Error: path \"8\\n.txt\" has a tab or a line break, it cannot be indexed.")
+	assert.Equal(t, len(repository.index), 0)
+}
+
+func TestIndexFiles(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	getIndexPaths := func() []string {
+		paths := []string{}
+
+		for _, change := range repository.index {
+			paths = append(paths, change.GetPath())
+		}
+
+		slices.Sort(paths)
+
+		return paths
+	}
+
+	assert.EqualError(t, repository.IndexFiles([]string{}, &IndexOptions{}), "Validation Error: nothing specified, nothing added.")
+	assert.EqualError(t, repository.IndexFiles([]string{"10.txt"}, &IndexOptions{}), "Validation Error: pathspec \"10.txt\" did not match any files.")
+	assert.EqualError(t, repository.IndexFiles([]string{"**/*.go"}, &IndexOptions{}), "Validation Error: pathspec \"**/*.go\" did not match any files.")
+
+	// Check directories are indexed recursively
+	{
+		assert.Nil(t, repository.IndexFiles([]string{"a"}, &IndexOptions{}))
+		assert.Equal(
+			t,
+			getIndexPaths(),
+			[]string{dir.Join("a", "4.txt"), dir.Join("a", "5.txt"), dir.Join("a", "b", "6.txt"), dir.Join("a", "b", "7.txt")},
+		)
+	}
+
+	// Check globs are matched relative to the root
+	{
+		assert.Nil(t, repository.IndexFiles([]string{"*.txt", "c/9.*"}, &IndexOptions{}))
+		assert.Equal(
+			t,
+			getIndexPaths(),
+			[]string{
+				dir.Join("1.txt"),
+				dir.Join("2.txt"),
+				dir.Join("3.txt"),
+				dir.Join("a", "4.txt"),
+				dir.Join("a", "5.txt"),
+				dir.Join("a", "b", "6.txt"),
+				dir.Join("a", "b", "7.txt"),
+				dir.Join("c", "9.txt"),
+			},
+		)
+	}
+
+	// Check all indexes every change
+	{
+		assert.Nil(t, repository.IndexFiles([]string{}, &IndexOptions{All: true}))
+		assert.Equal(t, len(repository.index), 9)
+
+		repository.SaveIndex()
+		repository.CreateSave("s0")
+
+		repository = fixtureGetRepository(t, dir.Path())
+	}
+
+	// Check update only indexes tracked files and stages removals
+	{
+		fixtures.WriteFile(dir.Join("1.txt"), []byte("1 modified content"))
+		fixtures.WriteFile(dir.Join("c", "10.txt"), []byte("10 content"))
+		fixtures.RemoveFile(dir.Join("a", "b", "6.txt"))
+
+		assert.Nil(t, repository.IndexFiles([]string{}, &IndexOptions{Update: true}))
+		assert.Equal(t, getIndexPaths(), []string{dir.Join("1.txt"), dir.Join("a", "b", "6.txt")})
+		assert.Equal(t, repository.findStagedChange(dir.Join("1.txt")).ChangeType, directories.Modification)
+		assert.Equal(t, repository.findStagedChange(dir.Join("a", "b", "6.txt")).ChangeType, directories.Removal)
+
+		// Removed tracked files can be added by path
+		fixtures.RemoveFile(dir.Join("a", "b", "7.txt"))
+
+		assert.Nil(t, repository.IndexFiles([]string{path.Join("a", "b", "7.txt"), "c"}, &IndexOptions{}))
+		assert.Equal(
+			t,
+			getIndexPaths(),
+			[]string{dir.Join("1.txt"), dir.Join("a", "b", "6.txt"), dir.Join("a", "b", "7.txt"), dir.Join("c", "10.txt")},
+		)
+		assert.Equal(t, repository.findStagedChange(dir.Join("a", "b", "7.txt")).ChangeType, directories.Removal)
+	}
+}
@@ -1,29 +1,54 @@
 package repositories
 
+import "fmt"
+
 func (repository *Repository) Load(ref string) error {
-	save := repository.getSave(ref)
-	if save == nil {
+	if err := repository.checkMergeInProgress(); err != nil {
+		return err
+	}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return err
+	}
+
+	checkpoint, err := repository.getCheckpoint(ref)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
 		return &ValidationError{"invalid ref."}
 	}
 
-	workingDir := repository.GetStatus().WorkingDir
+	status, err := repository.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	workingDir := status.WorkingDir
 	if len(workingDir.ModifiedFilePaths)+len(workingDir.RemovedFilePaths)+len(workingDir.UntrackedFilePaths) > 0 {
 		return &ValidationError{"unsaved changes."}
 	}
 
-	dir := buildDir(repository.fs.Root, save)
+	dir, err := repository.buildDir(checkpoint.Id)
+	if err != nil {
+		return err
+	}
 
-	nodes := dir.PreOrderTraversal()
-	// if we are traversing the root dir, the root-dir-file should be removed from the response.
-	nodes = nodes[1:]
+	if err := repository.applyDir(dir); err != nil {
+		return err
+	}
 
-	repository.fs.SafeRemoveWorkingDir(dir.Path)
+	reason := fmt.Sprintf("load: moving from %s to %s", repository.head, ref)
 
-	for _, node := range nodes {
-		repository.fs.CreateNode(node)
+	if _, ok := (*repository.refs)[ref]; ok {
+		return repository.setHead(ref, reason)
+	}
+	if ref == "HEAD" {
+		return nil
 	}
 
-	repository.setHead(ref)
-
-	return nil
+	// Other revisions detach HEAD at the resolved save
+	return repository.setHead(checkpoint.Id, reason)
 }
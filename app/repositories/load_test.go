@@ -3,6 +3,7 @@ package repositories
 import (
 	"saymow/version-manager/app/pkg/fixtures"
 	"saymow/version-manager/app/repositories/filesystems"
+	"saymow/version-manager/app/repositories/ignores"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -59,7 +60,7 @@ func TestLoad(t *testing.T) {
 
 	// Save 1
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 updated content."))
 	fixtures.MakeDirs(dir.Join("c"))
@@ -79,7 +80,7 @@ func TestLoad(t *testing.T) {
 
 	// Save 2
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 updated content."))
 	fixtures.MakeDirs(dir.Join("d"))
@@ -99,7 +100,7 @@ func TestLoad(t *testing.T) {
 
 	// Save 2
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("3.txt"), []byte("3 updated content."))
 	fixtures.MakeDirs(dir.Join("e"))
@@ -122,7 +123,7 @@ func TestLoad(t *testing.T) {
 	// Load Save 0
 	{
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Load(save0.Id)
 
 		assert.Equal(t, repository.head, save0.Id)
@@ -157,7 +158,7 @@ func TestLoad(t *testing.T) {
 	// Load Save 1
 	{
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Load(save1.Id)
 
 		assert.Equal(t, repository.head, save1.Id)
@@ -192,7 +193,7 @@ func TestLoad(t *testing.T) {
 	// Load Save 2
 	{
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Load(save2.Id)
 
 		assert.Equal(t, repository.head, save2.Id)
@@ -227,7 +228,7 @@ func TestLoad(t *testing.T) {
 	// Load Save 3 (using ref)
 	{
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Load(filesystems.INITIAL_REF_NAME)
 
 		assert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
@@ -259,3 +260,100 @@ func TestLoad(t *testing.T) {
 		)
 	}
 }
+
+func TestLoadKeepsIgnoredFiles(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join(ignores.IGNORE_FILE_NAME), []byte("node_modules/\n*.swp\n"))
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+
+	repository.IndexFile(ignores.IGNORE_FILE_NAME)
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save0, _ := repository.CreateSave("save0")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	repository.CreateSave("save1")
+
+	fixtures.WriteFile(dir.Join(".1.txt.swp"), []byte("swap."))
+	fixtures.MakeDirs(dir.Join("node_modules"))
+	fixtures.WriteFile(dir.Join("node_modules", "index.js"), []byte("module."))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Nil(t, repository.Load(save0.Id))
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile(ignores.IGNORE_FILE_NAME, "node_modules/\n*.swp\n"),
+				fs.WithFile("1.txt", "1 content."),
+				fs.WithFile(".1.txt.swp", "swap."),
+				fs.WithDir(
+					"node_modules",
+					fs.WithFile("index.js", "module."),
+				),
+			),
+		),
+	)
+}
+
+func TestLoadRevision(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save0, _ := repository.CreateSave("save0")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 updated content."))
+
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("save1")
+
+	// Test
+
+	// Loading HEAD keeps the current ref
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Nil(t, repository.Load("HEAD"))
+	assert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
+
+	// Other revisions detach HEAD at the resolved save
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Nil(t, repository.Load("HEAD~1"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.head, save0.Id)
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile("1.txt", "1 content."),
+			),
+		),
+	)
+
+	assert.Nil(t, repository.Load(filesystems.INITIAL_REF_NAME))
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
+}
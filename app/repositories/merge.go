@@ -1,217 +1,457 @@
 package repositories
 
 import (
-	"bytes"
-	"compress/gzip"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"os"
-	Path "path/filepath"
 	"saymow/version-manager/app/pkg/collections"
-	"saymow/version-manager/app/pkg/errors"
+	"saymow/version-manager/app/repositories/attributes"
+	"saymow/version-manager/app/repositories/diffs"
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
 	"time"
 )
 
-func (repository *Repository) createConflictFile(refFile *directories.File, incomingFile *directories.File, refName, incomingName string) *directories.FileConflict {
-	refFileContent := repository.fs.ReadDirFile(refFile)
-	incomingFileContent := repository.fs.ReadDirFile(incomingFile)
+const BASE_CONFLICT_LABEL = "base"
 
-	var buffer bytes.Buffer
+type MergeStrategy string
 
-	_, err := buffer.Write([]byte(fmt.Sprintf("<%s>\n", refName)))
-	errors.Check(err)
+const (
+	// Resolve the files changed on both sides with the ref side file.
+	MERGE_STRATEGY_OURS MergeStrategy = attributes.MERGE_OURS
+	// Resolve the files changed on both sides with the incoming side file.
+	MERGE_STRATEGY_THEIRS MergeStrategy = attributes.MERGE_THEIRS
+)
 
-	_, err = buffer.Write(refFileContent.Bytes())
-	errors.Check(err)
+type MergeOptions struct {
+	// Resolve every file changed on both sides in favor of one side, instead of merging its lines.
+	// Files with a merge driver declared in the .vcsattributes file keep their driver.
+	Strategy MergeStrategy
+}
 
-	_, err = buffer.Write([]byte(fmt.Sprintf("\n</%s>\n", refName)))
-	errors.Check(err)
+type mergeSides struct {
+	base   map[string]*directories.File
+	ours   map[string]*directories.File
+	theirs map[string]*directories.File
+}
 
-	_, err = buffer.Write([]byte(fmt.Sprintf("<%s>\n", incomingName)))
-	errors.Check(err)
+// Check whether a merge is in progress, other commands changing the current ref must wait for it.
+func (repository *Repository) checkMergeInProgress() error {
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return err
+	}
+	if mergeState != nil {
+		return &ValidationError{"a merge is in progress, continue or abort it first."}
+	}
 
-	_, err = buffer.Write(incomingFileContent.Bytes())
-	errors.Check(err)
+	return nil
+}
 
-	_, err = buffer.Write([]byte(fmt.Sprintf("\n</%s>\n", incomingName)))
-	errors.Check(err)
+func getDirFilesMap(dir *directories.Dir) map[string]*directories.File {
+	return collections.ToMap(dir.CollectAllFiles(), func(file *directories.File, _ int) string {
+		return file.Filepath
+	})
+}
 
-	hasher := sha256.New()
-	_, err = hasher.Write(buffer.Bytes())
-	errors.Check(err)
-	hash := hasher.Sum(nil)
+// Read the files contents, an empty content is used for missing files.
+func (repository *Repository) readFilesContents(files ...*directories.File) ([]string, error) {
+	contents := []string{}
 
-	objectName := hex.EncodeToString(hash)
-	objectFile, err := os.Create(Path.Join(repository.fs.Root, filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, objectName))
-	errors.Check(err)
-	defer objectFile.Close()
+	for _, file := range files {
+		if file == nil {
+			contents = append(contents, "")
+			continue
+		}
 
-	compressor := gzip.NewWriter(objectFile)
-	_, err = compressor.Write(buffer.Bytes())
-	errors.Check(err)
-	errors.Check(compressor.Close())
+		content, err := repository.fs.ReadDirFile(file)
+		if err != nil {
+			return nil, err
+		}
 
-	return &directories.FileConflict{
-		Filepath:   refFile.Filepath,
-		ObjectName: objectName,
-		Message:    "Conflict.",
+		contents = append(contents, content.String())
 	}
+
+	return contents, nil
 }
 
-func (repository *Repository) handleMergeSave(refSave *filesystems.Save, incomingSave *filesystems.Save, ref, incoming string) *filesystems.Save {
-	commonCheckpoint := refSave.FindFirstCommonCheckpointParent(incomingSave)
-	ancestorSave := repository.getSave(commonCheckpoint.Id)
-	dir := buildDir(repository.fs.Root, ancestorSave)
-	refCommonAncestorIdx := collections.FindIndex(refSave.Checkpoints, func(checkpoint *filesystems.Checkpoint, _ int) bool {
-		return checkpoint.Id == commonCheckpoint.Id
-	})
-	incomingAncestorIdx := collections.FindIndex(incomingSave.Checkpoints, func(checkpoint *filesystems.Checkpoint, _ int) bool {
-		return checkpoint.Id == commonCheckpoint.Id
-	})
+// Binary files are not merged, the ref side file is kept in the working directory and the incoming side
+// file is kept in the incoming save.
+func getBinaryConflictChange(refFile *directories.File, refName, incomingName string) *directories.Change {
+	return &directories.Change{
+		ChangeType: directories.Conflict,
+		Conflict: &directories.FileConflict{
+			Filepath:   refFile.Filepath,
+			ObjectName: refFile.ObjectName,
+			Message:    fmt.Sprintf("Binary file modified at \"%s\" and \"%s\".", refName, incomingName),
+		},
+	}
+}
+
+// Three-way merge a file changed on both sides.
+//
+// Non-overlapping changes are merged, otherwise a temporary conflicted object is created. With union, both sides
+// of overlapping changes are kept. Binary contents are not merged.
+func (repository *Repository) mergeFile(baseFile, refFile, incomingFile *directories.File, refName, incomingName string, union bool) (*directories.Change, error) {
+	contents, err := repository.readFilesContents(baseFile, refFile, incomingFile)
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(contents, diffs.IsBinary) {
+		return getBinaryConflictChange(refFile, refName, incomingName), nil
+	}
+
+	result := diffs.Merge3(
+		diffs.SplitLines(contents[0]),
+		diffs.SplitLines(contents[1]),
+		diffs.SplitLines(contents[2]),
+		&diffs.MergeOptions{OursLabel: refName, BaseLabel: BASE_CONFLICT_LABEL, TheirsLabel: incomingName, Union: union},
+	)
+	object, err := repository.fs.WriteObjectContent(refFile.Filepath, []byte(result.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Conflicts == 0 {
+		return &directories.Change{ChangeType: directories.Modification, File: object}, nil
+	}
+
+	return &directories.Change{
+		ChangeType: directories.Conflict,
+		Conflict: &directories.FileConflict{
+			Filepath:   object.Filepath,
+			ObjectName: object.ObjectName,
+			Message:    "Conflict.",
+		},
+	}, nil
+}
 
-	refChangesMap := make(map[string]*directories.Change)
+// The change keeping a side file, nil when the side removed it.
+func getSideChange(file *directories.File) *directories.Change {
+	if file == nil {
+		return nil
+	}
 
-	for _, checkpoint := range refSave.Checkpoints[refCommonAncestorIdx+1:] {
-		for _, change := range checkpoint.Changes {
-			refChangesMap[change.GetPath()] = change
+	return &directories.Change{ChangeType: directories.Creation, File: file}
+}
 
-			normalizedPath, err := dir.NormalizePath(change.GetPath())
-			errors.Check(err)
+// Three-way merge the "ref" and "incoming" file trees against their common ancestor file tree.
+//
+// Files changed on both sides are merged by their .vcsattributes merge driver, or the merge strategy.
+// The merged file tree is returned along with the conflicted changes.
+func (repository *Repository) mergeDirs(baseDir, refDir, incomingDir *directories.Dir, ref, incoming string, options *MergeOptions) (*directories.Dir, []*directories.Change, error) {
+	mergeAttributes, err := repository.fs.OpenAttributes()
+	if err != nil {
+		return nil, nil, err
+	}
 
-			dir.AddNode(normalizedPath, change)
+	sides := mergeSides{
+		base:   getDirFilesMap(baseDir),
+		ours:   getDirFilesMap(refDir),
+		theirs: getDirFilesMap(incomingDir),
+	}
+	seenFilepaths := make(map[string]bool)
+	filepaths := []string{}
+
+	for _, files := range []map[string]*directories.File{sides.base, sides.ours, sides.theirs} {
+		for filepath := range files {
+			if !seenFilepaths[filepath] {
+				seenFilepaths[filepath] = true
+				filepaths = append(filepaths, filepath)
+			}
 		}
 	}
 
-	conflictedChanges := []*directories.Change{}
+	slices.Sort(filepaths)
 
-	for _, checkpoint := range incomingSave.Checkpoints[incomingAncestorIdx+1:] {
-		for _, incomingChange := range checkpoint.Changes {
-			normalizedPath, err := dir.NormalizePath(incomingChange.GetPath())
-			errors.Check(err)
+	dir := &directories.Dir{Path: repository.fs.Root, Children: make(map[string]*directories.Node)}
+	conflictedChanges := []*directories.Change{}
 
-			refChange, ok := refChangesMap[incomingChange.GetPath()]
+	for _, filepath := range filepaths {
+		baseFile, refFile, incomingFile := sides.base[filepath], sides.ours[filepath], sides.theirs[filepath]
+		baseHash, refHash, incomingHash := getFileHash(baseFile), getFileHash(refFile), getFileHash(incomingFile)
 
-			if !ok || !refChange.Conflicts(incomingChange) {
-				// No conflict found, go ahead
+		driver := mergeAttributes.MergeDriver(filepath)
+		if driver == attributes.MERGE_TEXT && options.Strategy != "" {
+			driver = string(options.Strategy)
+		}
 
-				dir.AddNode(normalizedPath, incomingChange)
-				continue
+		var change *directories.Change
+		var err error
+
+		switch {
+		case refHash == incomingHash || incomingHash == baseHash:
+			change = getSideChange(refFile)
+		case refHash == baseHash:
+			change = getSideChange(incomingFile)
+		// Changed on both sides
+		case driver == attributes.MERGE_OURS:
+			change = getSideChange(refFile)
+		case driver == attributes.MERGE_THEIRS:
+			change = getSideChange(incomingFile)
+		case refFile == nil:
+			change = &directories.Change{
+				ChangeType: directories.Conflict,
+				Conflict: &directories.FileConflict{
+					Filepath:   incomingFile.Filepath,
+					ObjectName: incomingFile.ObjectName,
+					Message:    fmt.Sprintf("Removed at \"%s\" but modified at \"%s\".", ref, incoming),
+				},
+			}
+		case incomingFile == nil:
+			change = &directories.Change{
+				ChangeType: directories.Conflict,
+				Conflict: &directories.FileConflict{
+					Filepath:   refFile.Filepath,
+					ObjectName: refFile.ObjectName,
+					Message:    fmt.Sprintf("Removed at \"%s\" but modified at \"%s\".", incoming, ref),
+				},
 			}
-			// Otherwise, create conflict change
-
-			var change *directories.Change
-
-			switch {
-			case refChange.ChangeType == directories.Removal:
-				change = &directories.Change{
-					ChangeType: directories.Conflict,
-					Conflict: &directories.FileConflict{
-						Filepath:   incomingChange.File.Filepath,
-						ObjectName: incomingChange.File.ObjectName,
-						Message:    fmt.Sprintf("Removed at \"%s\" but modified at \"%s\".", ref, incoming),
-					},
-				}
-			case incomingChange.ChangeType == directories.Removal:
-				change = &directories.Change{
-					ChangeType: directories.Conflict,
-					Conflict: &directories.FileConflict{
-						Filepath:   refChange.File.Filepath,
-						ObjectName: refChange.File.ObjectName,
-						Message:    fmt.Sprintf("Removed at \"%s\" but modified at \"%s\".", incoming, ref),
-					},
-				}
-			default:
-				change = &directories.Change{
-					ChangeType: directories.Conflict,
-					Conflict:   repository.createConflictFile(refChange.File, incomingChange.File, ref, incoming),
-				}
+		case driver == attributes.MERGE_BINARY:
+			change = getBinaryConflictChange(refFile, ref, incoming)
+		default:
+			change, err = repository.mergeFile(baseFile, refFile, incomingFile, ref, incoming, driver == attributes.MERGE_UNION)
+			if err != nil {
+				return nil, nil, err
 			}
+		}
 
+		if change == nil {
+			// Removed
+			continue
+		}
+		if change.ChangeType == directories.Conflict {
 			conflictedChanges = append(conflictedChanges, change)
-			dir.AddNode(normalizedPath, change)
+		}
+
+		normalizedPath, err := dir.NormalizePath(filepath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := dir.AddNode(normalizedPath, change); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	// Apply changes on the working directory
-	repository.applyDir(dir)
+	return dir, conflictedChanges, nil
+}
+
+func getFileHash(file *directories.File) string {
+	if file == nil {
+		return ""
+	}
 
-	// Append the incoming Checkpoints to the end of the refSave, to keep the incoming save history correct
-	incomingCheckpoints := incomingSave.Checkpoints[incomingAncestorIdx+1:]
-	leafCheckpointId := refSave.Id
+	return file.ObjectName
+}
 
-	for len(incomingCheckpoints) > 0 {
-		// Rebuild each checkpoint accordingly
+// Build the file tree of the merge base of two saves, empty when they have no common history.
+func (repository *Repository) buildMergeBaseDir(refSave *filesystems.Save, incomingSave *filesystems.Save) (*directories.Dir, error) {
+	baseCheckpoint := refSave.FindFirstCommonCheckpointParent(incomingSave)
+	if baseCheckpoint == nil {
+		return &directories.Dir{Path: repository.fs.Root, Children: make(map[string]*directories.Node)}, nil
+	}
 
-		incomingCheckpoint := incomingCheckpoints[0]
+	return repository.buildDir(baseCheckpoint.Id)
+}
 
-		checkpoint := filesystems.Checkpoint{
-			Parent:    leafCheckpointId,
-			Message:   incomingCheckpoint.Message,
-			CreatedAt: time.Now(),
-			Changes:   incomingCheckpoint.Changes,
-		}
-		leafCheckpointId = repository.fs.WriteCheckpoint(&checkpoint)
+// Merge the incoming save into the ref save, against their merge base.
+//
+// Without conflicts, a merge save with the ref and incoming saves as parents is created. Otherwise the
+// index is populated with the merge changes and the merge state is recorded, until the merge is
+// continued or aborted.
+func (repository *Repository) handleMergeSave(refSave *filesystems.Save, incomingSave *filesystems.Save, ref, incoming string, options *MergeOptions) (*filesystems.Save, error) {
+	baseDir, err := repository.buildMergeBaseDir(refSave, incomingSave)
+	if err != nil {
+		return nil, err
+	}
+	refDir, err := repository.buildDir(refSave.Id)
+	if err != nil {
+		return nil, err
+	}
+	incomingDir, err := repository.buildDir(incomingSave.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedDir, conflictedChanges, err := repository.mergeDirs(baseDir, refDir, incomingDir, ref, incoming, options)
+	if err != nil {
+		return nil, err
+	}
 
-		incomingCheckpoints = incomingCheckpoints[1:]
+	// Apply changes on the working directory
+	if err := repository.applyDir(mergedDir); err != nil {
+		return nil, err
 	}
 
+	// The merge changes are relative to the first parent, the ref save.
+	mergeChanges := diffDirs(refDir, mergedDir)
+
 	if len(conflictedChanges) > 0 {
 		// Then populate the index with conflicting changes and let the user resolve the merge.
 
-		repository.setRef(repository.head, leafCheckpointId)
-		repository.index = conflictedChanges
-		repository.SaveIndex()
+		// Conflicts keeping the ref side content are not part of the merge changes, so they are added apart.
+		repository.index = collections.Filter(mergeChanges, func(change *directories.Change, _ int) bool {
+			return !slices.ContainsFunc(conflictedChanges, func(conflictedChange *directories.Change) bool {
+				return conflictedChange.GetPath() == change.GetPath()
+			})
+		})
+		repository.index = append(repository.index, conflictedChanges...)
+		if err := repository.SaveIndex(); err != nil {
+			return nil, err
+		}
+
+		mergeState := &filesystems.MergeState{
+			Ref:          ref,
+			RefSave:      refSave.Id,
+			Incoming:     incoming,
+			IncomingSave: incomingSave.Id,
+			ConflictedPaths: collections.Map(conflictedChanges, func(change *directories.Change, _ int) string {
+				return change.GetPath()
+			}),
+		}
+		if err := repository.fs.WriteMergeState(mergeState); err != nil {
+			return nil, err
+		}
+
+		return refSave, nil
+	}
 
-		return repository.getSave(leafCheckpointId)
+	// Otherwise, create the merge save
+	tree, err := repository.fs.WriteTree(mergedDir)
+	if err != nil {
+		return nil, err
 	}
 
-	// Otherwise, append merge checkpoint at the end
 	checkpoint := filesystems.Checkpoint{
-		Message:   fmt.Sprintf("Merge \"%s\" at \"%s\".", incoming, ref),
-		Parent:    leafCheckpointId,
+		Message:   getMergeMessage(ref, incoming),
+		Parents:   []string{refSave.Id, incomingSave.Id},
+		Tree:      tree,
 		CreatedAt: time.Now(),
-		Changes:   []*directories.Change{},
+		Changes:   mergeChanges,
+	}
+	checkpoint.Id, err = repository.fs.WriteCheckpoint(&checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.setRef(repository.head, checkpoint.Id, fmt.Sprintf("merge %s: merge save", incoming)); err != nil {
+		return nil, err
 	}
-	checkpoint.Id = repository.fs.WriteCheckpoint(&checkpoint)
-	repository.setRef(repository.head, checkpoint.Id)
 
 	return repository.getSave(checkpoint.Id)
 }
 
-func (repository *Repository) Merge(ref string) (*filesystems.Save, error) {
+func (repository *Repository) Merge(ref string, options *MergeOptions) (*filesystems.Save, error) {
 	if repository.isDetachedMode() {
 		return nil, &ValidationError{"cannot make changes in detached mode."}
 	}
 
-	if len(repository.index) > 0 {
-		return nil, &ValidationError{"unsaved changes."}
+	if options.Strategy != "" && options.Strategy != MERGE_STRATEGY_OURS && options.Strategy != MERGE_STRATEGY_THEIRS {
+		return nil, &ValidationError{"invalid merge strategy."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, err
 	}
 
-	workingDirStatus := repository.GetStatus().WorkingDir
-	if len(workingDirStatus.ModifiedFilePaths)+len(workingDirStatus.RemovedFilePaths)+len(workingDirStatus.UntrackedFilePaths) > 0 {
-		return nil, &ValidationError{"unsaved changes."}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkUnsavedChanges(); err != nil {
+		return nil, err
 	}
 
-	refSave := repository.getSave(repository.getCurrentSaveName())
-	incomingSave := repository.getSave(ref)
+	refSave, err := repository.getSave(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, err
+	}
+	incomingSave, err := repository.getSave(ref)
+	if err != nil {
+		return nil, err
+	}
 	if incomingSave == nil {
 		return nil, &ValidationError{"invalid ref."}
 	}
 
-	if incomingSave.Contains(refSave) {
+	if refSave != nil && refSave.Contains(incomingSave) {
+		// Already merged
+
+		return refSave, nil
+	}
+
+	if refSave == nil || incomingSave.Contains(refSave) {
 		// Fast forward
 
-		dir := buildDir(repository.fs.Root, incomingSave)
+		dir, err := repository.buildDir(incomingSave.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := repository.applyDir(dir); err != nil {
+			return nil, err
+		}
+		if err := repository.setRef(repository.head, incomingSave.Id, fmt.Sprintf("merge %s: fast-forward", ref)); err != nil {
+			return nil, err
+		}
 
-		repository.applyDir(dir)
-		repository.setRef(repository.head, incomingSave.Id)
 		return incomingSave, nil
 	}
 
-	save := repository.handleMergeSave(refSave, incomingSave, repository.head, ref)
+	return repository.handleMergeSave(refSave, incomingSave, repository.head, ref, options)
+}
+
+func getMergeMessage(ref, incoming string) string {
+	return fmt.Sprintf("Merge \"%s\" at \"%s\".", incoming, ref)
+}
+
+// Create the merge save of a merge stopped by conflicts, once they are resolved.
+func (repository *Repository) ContinueMerge() (*filesystems.Save, error) {
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if mergeState == nil {
+		return nil, &ValidationError{"no merge in progress."}
+	}
+	if repository.head != mergeState.Ref {
+		return nil, &ValidationError{fmt.Sprintf("the merge in progress is on \"%s\", not on HEAD.", mergeState.Ref)}
+	}
+
+	checkpoint, err := repository.CreateSave(getMergeMessage(mergeState.Ref, mergeState.Incoming))
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.getSave(checkpoint.Id)
+}
+
+// Cancel a merge stopped by conflicts, the ref, the index and the working directory are restored
+// as they were before the merge.
+func (repository *Repository) AbortMerge() error {
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return err
+	}
+	if mergeState == nil {
+		return &ValidationError{"no merge in progress."}
+	}
+
+	dir, err := repository.buildDir(mergeState.RefSave)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.applyDir(dir); err != nil {
+		return err
+	}
+	if err := repository.clearIndex(); err != nil {
+		return err
+	}
+	if err := repository.setRef(mergeState.Ref, mergeState.RefSave, "merge: abort"); err != nil {
+		return err
+	}
 
-	return save, nil
+	return repository.fs.RemoveMergeState()
 }
@@ -2,9 +2,11 @@ package repositories
 
 import (
 	"fmt"
+	"os"
 	Path "path/filepath"
 	"saymow/version-manager/app/pkg/collections"
 	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/attributes"
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
 	"testing"
@@ -33,7 +35,7 @@ func makeBaseRepository(t *testing.T) (*fs.Dir, *Repository, *BaseRepositoryMeta
 	s0, _ := repository.CreateSave("s0")
 	repository.CreateRef("ref")
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.MakeDirs(dir.Join("a"))
 	fixtures.WriteFile(dir.Join("a", "a.txt"), []byte("a/a.txt content."))
@@ -42,7 +44,7 @@ func makeBaseRepository(t *testing.T) (*fs.Dir, *Repository, *BaseRepositoryMeta
 	repository.SaveIndex()
 	s1, _ := repository.CreateSave("s1")
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("b.txt"), []byte("b.txt updated content."))
 	fixtures.WriteFile(dir.Join("a", "b.txt"), []byte("b/b.txt content."))
@@ -54,33 +56,74 @@ func makeBaseRepository(t *testing.T) (*fs.Dir, *Repository, *BaseRepositoryMeta
 	s2, _ := repository.CreateSave("s1")
 
 	return dir,
-		GetRepository(dir.Path()),
+		fixtureGetRepository(t, dir.Path()),
 		&BaseRepositoryMeta{s0: s0, s1: s1, s2: s2, refName: "ref"}
 }
 
+// Make "ref" and "incoming" refs changing "a.txt" lines on both sides and "b.txt", removed at "incoming".
+func makeDivergedRepository(t *testing.T) (*fs.Dir, *Repository) {
+	dir, repository := fixtureGetNewProject(t)
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\n2\n3\n"))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("b.txt content."))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository.CreateRef("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CreateRef("incoming")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\ntwo incoming\n3\n"))
+
+	repository.IndexFile("a.txt")
+	repository.RemoveFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Load("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\ntwo ref\n3\n"))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("b.txt ref content."))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1'")
+
+	return dir, fixtureGetRepository(t, dir.Path())
+}
+
 func TestInvalidMerge(t *testing.T) {
 	dir, repository, meta := makeBaseRepository(t)
 	defer dir.Remove()
 
 	repository.Load(meta.s0.Id)
 
-	_, err := repository.Merge(meta.refName)
+	_, err := repository.Merge(meta.refName, &MergeOptions{})
 	assert.Error(t, err, "Validaton Error: cannot make changes in detached mode.")
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 	repository.Load(filesystems.INITIAL_REF_NAME)
 
-	_, err = repository.Merge("undefined")
+	_, err = repository.Merge("undefined", &MergeOptions{})
 	assert.Error(t, err, "Validaton Error: invalid ref.")
 
 	fixtures.WriteFile(dir.Join("new_file.txt"), []byte("new file original content."))
 
-	_, err = repository.Merge(meta.refName)
+	_, err = repository.Merge(meta.refName, &MergeOptions{})
 	assert.Error(t, err, "Validaton Error: unsaved changes.")
 
 	repository.IndexFile(dir.Join("new_file.txt"))
 
-	_, err = repository.Merge(meta.refName)
+	_, err = repository.Merge(meta.refName, &MergeOptions{})
 	assert.Error(t, err, "Validaton Error: unsaved changes.")
 }
 
@@ -90,9 +133,9 @@ func TestFastForwardMerge(t *testing.T) {
 
 	repository.Load(filesystems.INITIAL_REF_NAME)
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
-	save, err := repository.Merge(meta.refName)
+	save, err := repository.Merge(meta.refName, &MergeOptions{})
 	refs := repository.GetRefs().Refs
 
 	assert.Nil(t, err)
@@ -138,7 +181,7 @@ func TestNewSaveMerge(t *testing.T) {
 
 	// s2
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("a", "c.txt"), []byte("a/c.txt incoming content."))
 	fixtures.WriteFile(dir.Join("a", "b.txt"), []byte("a/b.txt incoming updated content."))
@@ -155,7 +198,7 @@ func TestNewSaveMerge(t *testing.T) {
 
 	// s3
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.MakeDirs(dir.Join("c"))
 	fixtures.WriteFile(dir.Join("c", "a.txt"), []byte("c/a.txt incoming content."))
@@ -166,13 +209,13 @@ func TestNewSaveMerge(t *testing.T) {
 
 	// Load ref
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	repository.Load(meta.refName)
 
 	// s1'
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt ref content."))
 	fixtures.WriteFile(dir.Join("b.txt"), []byte("b.txt ref updated content."))
@@ -182,21 +225,25 @@ func TestNewSaveMerge(t *testing.T) {
 	repository.IndexFile(dir.Join("b.txt"))
 	repository.IndexFile(dir.Join("a", "a.txt"))
 	repository.SaveIndex()
-	repository.CreateSave("s1'")
+	refS1, _ := repository.CreateSave("s1'")
 
 	// Test
 
-	repository = GetRepository(dir.Path())
-	save, err := repository.Merge(incoming)
+	repository = fixtureGetRepository(t, dir.Path())
+	save, err := repository.Merge(incoming, &MergeOptions{})
 	refs := repository.GetRefs().Refs
 
 	assert.Nil(t, err)
 	assert.Equal(t, save.Checkpoint().Message, fmt.Sprintf("Merge \"%s\" at \"%s\".", incoming, meta.refName))
-	assert.Equal(t, len(save.Checkpoint().Changes), 0)
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-2].Message, "s3")
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-3].Message, "s2")
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-4].Message, "s1")
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-5].Message, "s1'")
+	// The incoming saves are kept as they are, the merge save changes are relative to the ref save
+	assert.Equal(t, save.Checkpoint().Parents, []string{refS1.Id, s3.Id})
+	assert.Equal(t, len(save.Checkpoint().Changes), 6)
+	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-2].Message, "s1'")
+	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-3].Message, "s3")
+	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-4].Message, "s2")
+	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-5].Message, "s1")
+	assert.Equal(t, save.Checkpoints[0].Id, meta.s0.Id)
+	assert.Equal(t, len(save.Checkpoints), 8)
 	assert.Equal(t, repository.head, meta.refName)
 	assert.Equal(t, refs[incoming], s3.Id)
 	assert.Equal(t, refs[meta.refName], save.Checkpoint().Id)
@@ -231,7 +278,7 @@ func TestNewSaveMerge(t *testing.T) {
 
 	// Check if the file tree is not corrupted
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		// Load older versions
 		repository.Load(meta.s0.Id)
@@ -249,7 +296,7 @@ func TestNewSaveMerge(t *testing.T) {
 			),
 		)
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		// Load merge save
 		repository.Load(save.Checkpoint().Id)
@@ -294,7 +341,7 @@ func TestIndexConflictsMerge(t *testing.T) {
 
 	// s1
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt incoming content."))
 	fixtures.WriteFile(dir.Join("c.txt"), []byte("c.txt incoming content."))
@@ -307,7 +354,7 @@ func TestIndexConflictsMerge(t *testing.T) {
 
 	// s2
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("a", "b.txt"), []byte("a/b.txt incoming updated content."))
 	fixtures.WriteFile(dir.Join("a", "c.txt"), []byte("a/c.txt incoming content."))
@@ -325,24 +372,24 @@ func TestIndexConflictsMerge(t *testing.T) {
 
 	// s3
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.MakeDirs(dir.Join("c"))
 	fixtures.WriteFile(dir.Join("c", "a.txt"), []byte("c/a.txt incoming content."))
 
 	repository.IndexFile(dir.Join("c", "a.txt"))
 	repository.SaveIndex()
-	repository.CreateSave("s3")
+	s3, _ := repository.CreateSave("s3")
 
 	// Load ref
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	repository.Load(meta.refName)
 
 	// s1'
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt ref content."))
 	fixtures.WriteFile(dir.Join("b.txt"), []byte("b.txt ref updated content."))
@@ -358,7 +405,7 @@ func TestIndexConflictsMerge(t *testing.T) {
 
 	// s2'
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	fixtures.MakeDirs(dir.Join("c"))
 	fixtures.WriteFile(dir.Join("c", "a.txt"), []byte("c/a.txt ref content."))
@@ -367,13 +414,13 @@ func TestIndexConflictsMerge(t *testing.T) {
 	repository.IndexFile(dir.Join("c", "a.txt"))
 	repository.IndexFile(dir.Join("c", "b.txt"))
 	repository.SaveIndex()
-	repository.CreateSave("s2'")
+	refS2, _ := repository.CreateSave("s2'")
 
 	// Test
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
-	save, err := repository.Merge(incoming)
+	save, err := repository.Merge(incoming, &MergeOptions{})
 
 	changesMap := collections.ToMap(repository.index, func(change *directories.Change, _ int) string {
 		return change.GetPath()
@@ -430,11 +477,19 @@ func TestIndexConflictsMerge(t *testing.T) {
 		"Conflict.",
 	)
 	assert.Nil(t, err)
-	assert.Equal(t, (*repository.refs)[repository.head], save.Checkpoint().Id)
-	assert.Equal(t, save.Checkpoint().Message, "s3")
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-2].Message, "s2")
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-3].Message, "s1")
-	assert.Equal(t, save.Checkpoints[len(save.Checkpoints)-4].Message, "s2'")
+	// The ref does not move until the conflicts are resolved
+	assert.Equal(t, (*repository.refs)[repository.head], refS2.Id)
+	assert.Equal(t, save.Checkpoint().Id, refS2.Id)
+	mergeState, _ := repository.fs.ReadMergeState()
+	assert.Equal(t, mergeState.Ref, meta.refName)
+	assert.Equal(t, mergeState.RefSave, refS2.Id)
+	assert.Equal(t, mergeState.Incoming, incoming)
+	assert.Equal(t, mergeState.IncomingSave, s3.Id)
+	assert.ElementsMatch(
+		t,
+		mergeState.ConflictedPaths,
+		[]string{dir.Join("a.txt"), dir.Join("b.txt"), dir.Join("c.txt"), dir.Join("a", "b.txt"), dir.Join("c", "a.txt")},
+	)
 	fsAssert.Assert(
 		t,
 		fs.Equal(
@@ -442,12 +497,12 @@ func TestIndexConflictsMerge(t *testing.T) {
 			fs.Expected(
 				t,
 				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
-				fs.WithFile("a.txt", "<ref>\na.txt ref content.\n</ref>\n<incoming>\na.txt incoming content.\n</incoming>\n"),
+				fs.WithFile("a.txt", "<<<<<<< ref\na.txt ref content.\n||||||| base\n=======\na.txt incoming content.\n>>>>>>> incoming\n"),
 				fs.WithFile("b.txt", "b.txt ref updated content."),
-				fs.WithFile("c.txt", "<ref>\nc.txt ref content.\n</ref>\n<incoming>\nc.txt incoming content.\n</incoming>\n"),
+				fs.WithFile("c.txt", "<<<<<<< ref\nc.txt ref content.\n||||||| base\n=======\nc.txt incoming content.\n>>>>>>> incoming\n"),
 				fs.WithDir(
 					"a",
-					fs.WithFile("b.txt", "<ref>\na/b.txt ref updated content.\n</ref>\n<incoming>\na/b.txt incoming updated content.\n</incoming>\n"),
+					fs.WithFile("b.txt", "<<<<<<< ref\na/b.txt ref updated content.\n||||||| base\nb/b.txt content.\n=======\na/b.txt incoming updated content.\n>>>>>>> incoming\n"),
 					fs.WithFile("c.txt", "a/c.txt incoming content."),
 					fs.WithDir(
 						"b",
@@ -457,10 +512,398 @@ func TestIndexConflictsMerge(t *testing.T) {
 				),
 				fs.WithDir(
 					"c",
-					fs.WithFile("a.txt", "<ref>\nc/a.txt ref content.\n</ref>\n<incoming>\nc/a.txt incoming content.\n</incoming>\n"),
+					fs.WithFile("a.txt", "<<<<<<< ref\nc/a.txt ref content.\n||||||| base\n=======\nc/a.txt incoming content.\n>>>>>>> incoming\n"),
 					fs.WithFile("b.txt", "c/b.txt ref content."),
 				),
 			),
 		),
 	)
+
+	// Check the merge is continued once the conflicts are resolved
+
+	_, err = repository.ContinueMerge()
+	assert.Error(t, err, "Validation Error: index is conflicted.")
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt resolved content."))
+	fixtures.WriteFile(dir.Join("c.txt"), []byte("c.txt resolved content."))
+	fixtures.WriteFile(dir.Join("a", "b.txt"), []byte("a/b.txt resolved content."))
+	fixtures.WriteFile(dir.Join("c", "a.txt"), []byte("c/a.txt resolved content."))
+
+	repository.IndexFile(dir.Join("a.txt"))
+	repository.IndexFile(dir.Join("b.txt"))
+	repository.IndexFile(dir.Join("c.txt"))
+	repository.IndexFile(dir.Join("a", "b.txt"))
+	repository.IndexFile(dir.Join("c", "a.txt"))
+	repository.SaveIndex()
+	save, err = repository.ContinueMerge()
+
+	assert.Nil(t, err)
+	assert.Equal(t, save.Checkpoint().Message, "Merge \"incoming\" at \"ref\".")
+	assert.Equal(t, save.Checkpoint().Parents, []string{refS2.Id, s3.Id})
+	assert.Equal(t, (*repository.refs)[meta.refName], save.Id)
+
+	mergeState, _ = repository.fs.ReadMergeState()
+	assert.Nil(t, mergeState)
+
+	_, err = repository.ContinueMerge()
+	assert.Error(t, err, "Validation Error: no merge in progress.")
+}
+
+func TestThreeWayMerge(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+	incoming := "incoming"
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\n2\n3\n4\n5\n6\n7\n"))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("1\n2\n3\n"))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository.CreateRef("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CreateRef(incoming)
+
+	// incoming s1
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("1\n2\n3\n4\n5\n6\nseven\n"))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("1\ntwo\n3\n"))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1")
+
+	// ref s1'
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Load("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("one\n2\n3\n4\n5\n6\n7\n"))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("1\nTWO\n3\n"))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1'")
+
+	// Test
+
+	repository = fixtureGetRepository(t, dir.Path())
+	_, err := repository.Merge(incoming, &MergeOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(repository.index), 2)
+
+	changesMap := collections.ToMap(repository.index, func(change *directories.Change, _ int) string {
+		return change.GetPath()
+	})
+
+	// Non-overlapping changes are merged
+	assert.Equal(t, changesMap[dir.Join("a.txt")].ChangeType, directories.Modification)
+	// Overlapping changes are conflicted
+	assert.Equal(t, changesMap[dir.Join("b.txt")].ChangeType, directories.Conflict)
+	assert.Equal(t, changesMap[dir.Join("b.txt")].Conflict.Message, "Conflict.")
+
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile("a.txt", "one\n2\n3\n4\n5\n6\nseven\n"),
+				fs.WithFile("b.txt", "1\n<<<<<<< ref\nTWO\n||||||| base\n2\n=======\ntwo\n>>>>>>> incoming\n3\n"),
+			),
+		),
+	)
+
+	// Resolve the conflict and save
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("1\nTwo\n3\n"))
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	save, err := repository.CreateSave("merge")
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(save.Changes), 2)
+}
+
+func TestAbortMerge(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+	incoming := "incoming"
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt content."))
+	fixtures.WriteFile(dir.Join("b.txt"), []byte("b.txt content."))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("b.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository.CreateRef("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CreateRef(incoming)
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt incoming content."))
+	fixtures.WriteFile(dir.Join("c.txt"), []byte("c.txt incoming content."))
+
+	repository.IndexFile("a.txt")
+	repository.IndexFile("c.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s1")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Load("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt ref content."))
+
+	repository.IndexFile("a.txt")
+	repository.SaveIndex()
+	refS1, _ := repository.CreateSave("s1'")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Check there is no merge to abort
+	assert.Error(t, repository.AbortMerge(), "Validation Error: no merge in progress.")
+
+	repository.Merge(incoming, &MergeOptions{})
+
+	// Check a merge cannot start while another one is in progress
+	{
+		repository = fixtureGetRepository(t, dir.Path())
+
+		_, err := repository.Merge(incoming, &MergeOptions{})
+		assert.Error(t, err, "Validation Error: a merge is in progress, continue or abort it first.")
+		assert.EqualError(t, repository.Load(incoming), "Validation Error: a merge is in progress, continue or abort it first.")
+		assert.EqualError(t, repository.CreateRef("other"), "Validation Error: a merge is in progress, continue or abort it first.")
+
+		status, _ := repository.GetStatus()
+		assert.Equal(t, status.Merge.Incoming, incoming)
+		assert.Equal(t, repository.head, "ref")
+		assert.NotContains(t, *repository.refs, "other")
+	}
+
+	// Check the merge cannot be saved on another ref than the merged one
+	{
+		repository = fixtureGetRepository(t, dir.Path())
+		mergeState, _ := repository.fs.ReadMergeState()
+		incomingSaveName := (*repository.refs)[incoming]
+		repository.head = incoming
+
+		_, err := repository.ContinueMerge()
+		assert.EqualError(t, err, "Validation Error: the merge in progress is on \"ref\", not on HEAD.")
+		_, err = repository.CreateSave("merge save")
+		assert.EqualError(t, err, "Validation Error: the merge in progress is on \"ref\", not on HEAD.")
+
+		repository = fixtureGetRepository(t, dir.Path())
+		currentMergeState, _ := repository.fs.ReadMergeState()
+		assert.Equal(t, currentMergeState, mergeState)
+		assert.Equal(t, (*repository.refs)[incoming], incomingSaveName)
+	}
+
+	fixtures.WriteFile(dir.Join("a.txt"), []byte("a.txt half resolved content."))
+	repository.IndexFile("a.txt")
+	repository.SaveIndex()
+
+	// Test
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Nil(t, repository.AbortMerge())
+
+	repository = fixtureGetRepository(t, dir.Path())
+	mergeState, _ := repository.fs.ReadMergeState()
+
+	assert.Nil(t, mergeState)
+	assert.Equal(t, len(repository.index), 0)
+	assert.Equal(t, repository.head, "ref")
+	assert.Equal(t, (*repository.refs)["ref"], refS1.Id)
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile("a.txt", "a.txt ref content."),
+				fs.WithFile("b.txt", "b.txt content."),
+			),
+		),
+	)
+}
+
+func TestStrategyMerge(t *testing.T) {
+	dir, repository := makeDivergedRepository(t)
+	defer dir.Remove()
+
+	_, err := repository.Merge("incoming", &MergeOptions{Strategy: "mine"})
+	assert.Error(t, err, "Validation Error: invalid merge strategy.")
+
+	// Test
+
+	save, err := repository.Merge("incoming", &MergeOptions{Strategy: MERGE_STRATEGY_THEIRS})
+	assert.Nil(t, err)
+	assert.Equal(t, len(save.Checkpoint().Parents), 2)
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Equal(t, len(repository.index), 0)
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile("a.txt", "1\ntwo incoming\n3\n"),
+			),
+		),
+	)
+
+	dir, repository = makeDivergedRepository(t)
+	defer dir.Remove()
+
+	_, err = repository.Merge("incoming", &MergeOptions{Strategy: MERGE_STRATEGY_OURS})
+	assert.Nil(t, err)
+
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile("a.txt", "1\ntwo ref\n3\n"),
+				fs.WithFile("b.txt", "b.txt ref content."),
+			),
+		),
+	)
+}
+
+func TestAttributesMerge(t *testing.T) {
+	dir, repository := makeDivergedRepository(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join(attributes.ATTRIBUTES_FILE_NAME), []byte("*.txt merge=ours\na.txt merge=union\n"))
+
+	repository.IndexFile(attributes.ATTRIBUTES_FILE_NAME)
+	repository.SaveIndex()
+	repository.CreateSave("attributes")
+
+	// Test
+
+	// Declared merge drivers take precedence over the strategy
+	repository = fixtureGetRepository(t, dir.Path())
+	_, err := repository.Merge("incoming", &MergeOptions{Strategy: MERGE_STRATEGY_THEIRS})
+	assert.Nil(t, err)
+
+	fsAssert.Assert(
+		t,
+		fs.Equal(
+			dir.Path(),
+			fs.Expected(
+				t,
+				fs.WithDir(filesystems.REPOSITORY_FOLDER_NAME, fs.MatchExtraFiles),
+				fs.WithFile(attributes.ATTRIBUTES_FILE_NAME, "*.txt merge=ours\na.txt merge=union\n"),
+				fs.WithFile("a.txt", "1\ntwo ref\ntwo incoming\n3\n"),
+				fs.WithFile("b.txt", "b.txt ref content."),
+			),
+		),
+	)
+
+	// Check binary files are not merged
+	dir, repository = makeDivergedRepository(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join(attributes.ATTRIBUTES_FILE_NAME), []byte("a.txt binary\n"))
+
+	repository.IndexFile(attributes.ATTRIBUTES_FILE_NAME)
+	repository.SaveIndex()
+	repository.CreateSave("attributes")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Merge("incoming", &MergeOptions{})
+
+	repository = fixtureGetRepository(t, dir.Path())
+	change := repository.findStagedChange(dir.Join("a.txt"))
+
+	assert.Equal(t, change.ChangeType, directories.Conflict)
+	assert.Equal(t, change.Conflict.Message, "Binary file modified at \"ref\" and \"incoming\".")
+	assert.False(t, change.Conflict.IsObjectTemporary())
+
+	content, _ := os.ReadFile(dir.Join("a.txt"))
+	assert.Equal(t, string(content), "1\ntwo ref\n3\n")
+}
+
+func TestBinaryMerge(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("image.png"), []byte("\x89PNG\x00base"))
+
+	repository.IndexFile("image.png")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository.CreateRef("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CreateRef("incoming")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("image.png"), []byte("\x89PNG\x00incoming"))
+
+	repository.IndexFile("image.png")
+	repository.SaveIndex()
+	repository.CreateSave("s1")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Load("ref")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("image.png"), []byte("\x89PNG\x00ref"))
+
+	repository.IndexFile("image.png")
+	repository.SaveIndex()
+	repository.CreateSave("s1'")
+
+	// Test
+
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Merge("incoming", &MergeOptions{})
+
+	repository = fixtureGetRepository(t, dir.Path())
+	change := repository.findStagedChange(dir.Join("image.png"))
+
+	assert.Equal(t, change.ChangeType, directories.Conflict)
+	assert.Equal(t, change.Conflict.Message, "Binary file modified at \"ref\" and \"incoming\".")
+	assert.False(t, change.Conflict.IsObjectTemporary())
+
+	// The ref side is kept in the working directory, without conflict markers
+	content, _ := os.ReadFile(dir.Join("image.png"))
+	assert.Equal(t, string(content), "\x89PNG\x00ref")
+
+	assert.Error(t, repository.ResolveFile("image.png", RESOLVE_UNION), "Validation Error: cannot union a binary file, use ours or theirs.")
+	assert.Nil(t, repository.ResolveFile("image.png", RESOLVE_THEIRS))
+
+	content, _ = os.ReadFile(dir.Join("image.png"))
+	assert.Equal(t, string(content), "\x89PNG\x00incoming")
 }
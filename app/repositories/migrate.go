@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"fmt"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"strings"
+)
+
+func isRepositoryPath(root string, filepath string) bool {
+	relativePath, err := Path.Rel(root, filepath)
+
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(Path.Separator))
+}
+
+func validateMigrationChanges(root string, changes []*directories.Change) error {
+	for _, change := range changes {
+		if !isRepositoryPath(root, change.GetPath()) {
+			return &ValidationError{fmt.Sprintf("path \"%s\" is outside of the repository, use the directory the repository was created in.", change.GetPath())}
+		}
+	}
+
+	return nil
+}
+
+// MigrateRepository rewrites the saves and the index with root relative paths.
+//
+// Repositories created before paths were stored relative to the root keep absolute paths, which
+// break once the repository directory is moved. oldRoot is the directory the repository was
+// created in, if omitted root is used. Saves written before trees were stored get their tree objects
+// written as well. Saves names are content hashes, so the migrated saves are renamed and the refs,
+// HEAD, reflogs and stashes are updated accordingly, before the old saves are removed. The operations cannot
+// be undone anymore, their snapshots are removed. Repositories with a merge, cherry-pick or rebase in progress
+// are not migrated.
+//
+// It returns the number of migrated saves.
+func MigrateRepository(root string, oldRoot string) (int, error) {
+	fileSystem, err := filesystems.Open(root)
+	if err != nil {
+		return 0, err
+	}
+	if err := fileSystem.Lock(); err != nil {
+		return 0, err
+	}
+	defer fileSystem.Unlock()
+
+	// The merge, cherry-pick and rebase states point to saves that are renamed
+	mergeState, err := fileSystem.ReadMergeState()
+	if err != nil {
+		return 0, err
+	}
+	if mergeState != nil {
+		return 0, &ValidationError{"a merge is in progress, continue or abort it before migrating."}
+	}
+	cherryPickState, err := fileSystem.ReadCherryPickState()
+	if err != nil {
+		return 0, err
+	}
+	if cherryPickState != nil {
+		return 0, &ValidationError{"a cherry-pick is in progress, continue or abort it before migrating."}
+	}
+	rebaseState, err := fileSystem.ReadRebaseState()
+	if err != nil {
+		return 0, err
+	}
+	if rebaseState != nil {
+		return 0, &ValidationError{"a rebase is in progress, continue or abort it before migrating."}
+	}
+
+	if oldRoot == "" {
+		oldRoot = root
+	}
+	fileSystem.LegacyRoot = oldRoot
+
+	ids, err := fileSystem.ListCheckpoints()
+	if err != nil {
+		return 0, err
+	}
+
+	checkpoints := make(map[string]*filesystems.Checkpoint)
+
+	for _, id := range ids {
+		checkpoint, err := fileSystem.ReadCheckpoint(id)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := validateMigrationChanges(root, checkpoint.Changes); err != nil {
+			return 0, err
+		}
+
+		checkpoints[id] = checkpoint
+	}
+
+	index, err := fileSystem.ReadIndex()
+	if err != nil {
+		return 0, err
+	}
+	if err := validateMigrationChanges(root, index); err != nil {
+		return 0, err
+	}
+
+	// Parents are migrated first, since their new names are written in their children.
+	names := make(map[string]string)
+	var migrate func(id string) (string, error)
+	migrate = func(id string) (string, error) {
+		if id == "" {
+			return "", nil
+		}
+		if name, ok := names[id]; ok {
+			return name, nil
+		}
+
+		checkpoint, ok := checkpoints[id]
+		if !ok {
+			return "", &filesystems.MissingSaveError{Id: id}
+		}
+
+		for idx, parent := range checkpoint.Parents {
+			name, err := migrate(parent)
+			if err != nil {
+				return "", err
+			}
+
+			checkpoint.Parents[idx] = name
+		}
+
+		if checkpoint.Tree == "" {
+			dir, err := fileSystem.ReadDir(checkpoint.FirstParent())
+			if err != nil {
+				return "", err
+			}
+
+			for _, change := range checkpoint.Changes {
+				normalizedPath, err := dir.NormalizePath(change.GetPath())
+				if err != nil {
+					return "", &filesystems.CorruptSaveError{Id: id, Err: err}
+				}
+
+				if err := dir.AddNode(normalizedPath, change); err != nil {
+					return "", &filesystems.CorruptSaveError{Id: id, Err: err}
+				}
+			}
+
+			if checkpoint.Tree, err = fileSystem.WriteTree(&dir); err != nil {
+				return "", err
+			}
+		}
+
+		name, err := fileSystem.WriteCheckpoint(checkpoint)
+		if err != nil {
+			return "", err
+		}
+
+		names[id] = name
+
+		return name, nil
+	}
+
+	for id := range checkpoints {
+		if _, err := migrate(id); err != nil {
+			return 0, err
+		}
+	}
+
+	refs, err := fileSystem.ReadRefs()
+	if err != nil {
+		return 0, err
+	}
+	head, err := fileSystem.ReadHead()
+	if err != nil {
+		return 0, err
+	}
+
+	for ref, saveName := range *refs {
+		if saveName != "" {
+			(*refs)[ref] = names[saveName]
+		}
+	}
+	if err := fileSystem.WriteRefs(refs); err != nil {
+		return 0, err
+	}
+
+	if _, ok := (*refs)[head]; !ok && head != "" {
+		// Detached HEAD points to a save
+		if err := fileSystem.WriteHead(names[head]); err != nil {
+			return 0, err
+		}
+	}
+
+	reflogNames, err := fileSystem.ListReflogs()
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range reflogNames {
+		entries, err := fileSystem.ReadReflog(name)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, entry := range entries {
+			if newName, ok := names[entry.OldId]; ok {
+				entry.OldId = newName
+			}
+			if newName, ok := names[entry.NewId]; ok {
+				entry.NewId = newName
+			}
+		}
+
+		if err := fileSystem.WriteReflog(name, entries); err != nil {
+			return 0, err
+		}
+	}
+
+	stashes, err := fileSystem.ReadStashes()
+	if err != nil {
+		return 0, err
+	}
+	for idx, saveName := range stashes {
+		if newName, ok := names[saveName]; ok {
+			stashes[idx] = newName
+		}
+	}
+	if err := fileSystem.WriteStashes(stashes); err != nil {
+		return 0, err
+	}
+
+	if err := fileSystem.RemoveOperations(); err != nil {
+		return 0, err
+	}
+	if err := fileSystem.SaveIndex(index); err != nil {
+		return 0, err
+	}
+
+	// The old saves are removed last, so an interrupted migration leaves nothing pointing to missing saves
+	newNames := make(map[string]bool)
+	for _, name := range names {
+		newNames[name] = true
+	}
+
+	migrated := 0
+	for id, name := range names {
+		if id == name {
+			continue
+		}
+
+		migrated++
+		if !newNames[id] {
+			if err := fileSystem.RemoveCheckpoint(id); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+const legacyRoot = "/old/project"
+
+func fixtureMakeLegacyRepositoryFs(dir *fs.Dir) fs.PathOp {
+	return fs.WithDir(
+		filesystems.REPOSITORY_FOLDER_NAME,
+		fs.WithFile(filesystems.REFS_FILE_NAME, "Refs:\n\nmaster\ns1\n"),
+		fs.WithFile(filesystems.HEAD_FILE_NAME, "master"),
+		fs.WithFile(filesystems.INDEX_FILE_NAME, fmt.Sprintf("Tracked files:\n\n%s/3.txt\t(created)\n3.txt-object\n", legacyRoot)),
+		fs.WithDir(
+			filesystems.SAVES_FOLDER_NAME,
+			fs.WithFile(
+				"s0",
+				fmt.Sprintf(
+					"s0\n\n01/02 03:04:05PM '06 -0700\n\nPlease do not edit the lines below.\n\n\nFiles:\n\n%s/1.txt\t(created)\n1.txt-object\n%s/a/4.txt\t(created)\n4.txt-object\n",
+					legacyRoot,
+					legacyRoot,
+				),
+			),
+			fs.WithFile(
+				"s1",
+				fmt.Sprintf(
+					"s1\ns0\n01/02 03:04:05PM '06 -0700\n\nPlease do not edit the lines below.\n\n\nFiles:\n\n%s/1.txt\t(modified)\n1.txt-object-v2\n%s/a/4.txt\t(removed)\n",
+					legacyRoot,
+					legacyRoot,
+				),
+			),
+		),
+		fs.WithDir(filesystems.OBJECTS_FOLDER_NAME),
+	)
+}
+
+func TestMigrateRepository(t *testing.T) {
+	dir := fs.NewDir(t, "project")
+	defer dir.Remove()
+
+	fs.Apply(t, dir, fixtureMakeLegacyRepositoryFs(dir))
+
+	// Check paths from another root are rejected
+	{
+		_, err := MigrateRepository(dir.Path(), "")
+		assert.EqualError(t, err, fmt.Sprintf("Validation Error: path \"%s/1.txt\" is outside of the repository, use the directory the repository was created in.", legacyRoot))
+	}
+
+	// Check repositories with a merge in progress are not migrated
+	{
+		fileSystem, err := filesystems.Open(dir.Path())
+		assert.Nil(t, err)
+		assert.Nil(t, fileSystem.WriteMergeState(&filesystems.MergeState{Ref: "master", RefSave: "s1", Incoming: "s0", IncomingSave: "s0"}))
+
+		_, err = MigrateRepository(dir.Path(), legacyRoot)
+		assert.EqualError(t, err, "Validation Error: a merge is in progress, continue or abort it before migrating.")
+		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME, "s0")))
+
+		assert.Nil(t, fileSystem.RemoveMergeState())
+	}
+
+	// Check migration
+	{
+		migrated, err := MigrateRepository(dir.Path(), legacyRoot)
+		assert.Nil(t, err)
+		assert.Equal(t, migrated, 2)
+
+		assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME, "s0")))
+		assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME, "s1")))
+		assert.Equal(
+			t,
+			fixtures.ReadFile(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.INDEX_FILE_NAME)),
+			"Tracked files:\n\n3.txt\t(created)\n3.txt-object\n",
+		)
+
+		repository := fixtureGetRepository(t, dir.Path())
+		save, _ := repository.getSave("master")
+
+		assert.Equal(t, len(save.Checkpoints), 2)
+		assert.Equal(t, save.Checkpoints[0].Message, "s0")
+		assert.Equal(t, save.Checkpoints[1].Parents, []string{save.Checkpoints[0].Id})
+		// Trees are written for legacy saves
+		assert.NotEqual(t, save.Checkpoints[0].Tree, "")
+		assert.Equal(t, repository.dir.Hash, save.Checkpoints[1].Tree)
+		assert.EqualValues(
+			t,
+			repository.dir.CollectAllFiles(),
+			[]*directories.File{{Filepath: dir.Join("1.txt"), ObjectName: "1.txt-object-v2"}},
+		)
+		assert.Equal(t, repository.index[0].File.Filepath, dir.Join("3.txt"))
+		assert.Contains(
+			t,
+			fixtures.ReadFile(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME, save.Id)),
+			"Files:\n\n1.txt\t(modified)\n1.txt-object-v2\na/4.txt\t(removed)\n",
+		)
+	}
+
+	// Check migration is idempotent
+	{
+		migrated, err := MigrateRepository(dir.Path(), "")
+		assert.Nil(t, err)
+		assert.Equal(t, migrated, 0)
+	}
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"maps"
+	"os"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+type pendingOperation struct {
+	command string
+	before  *filesystems.Snapshot
+	// Working directory files the command overwrites or removes, as they were before it.
+	workingFiles map[string]*directories.Change
+}
+
+// Write a working directory file object.
+//
+// The file is hashed first, the object of a file already stored is not written again.
+func (repository *Repository) storeWorkingFile(filepath string) (*directories.File, error) {
+	objectName, err := hashWorkingFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !repository.fs.ObjectExists(objectName) {
+		file, err := os.Open(filepath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		object, err := repository.fs.WriteObject(filepath, file)
+		if err != nil {
+			return nil, err
+		}
+
+		// The file may have changed since it was hashed
+		objectName = object.ObjectName
+	}
+
+	return &directories.File{Filepath: filepath, ObjectName: objectName}, nil
+}
+
+// Write the working directory files objects, so the working directory can be restored from the returned file tree.
+func (repository *Repository) writeWorkingDir() (*directories.Dir, error) {
+	dir := &directories.Dir{Path: repository.fs.Root, Children: make(map[string]*directories.Node)}
+
+	err := repository.walkWorkingDir(func(filepath string) error {
+		file, err := repository.storeWorkingFile(filepath)
+		if err != nil {
+			return err
+		}
+
+		normalizedPath, err := dir.NormalizePath(filepath)
+		if err != nil {
+			return err
+		}
+
+		return dir.AddNode(normalizedPath, &directories.Change{ChangeType: directories.Creation, File: file})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dir, nil
+}
+
+// Write a working directory file object and return it as a creation, or as a removal when the file is missing.
+func (repository *Repository) storeWorkingChange(filepath string) (*directories.Change, error) {
+	file, err := repository.storeWorkingFile(filepath)
+	if os.IsNotExist(err) {
+		return &directories.Change{ChangeType: directories.Removal, Removal: &directories.FileRemoval{Filepath: filepath}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &directories.Change{ChangeType: directories.Creation, File: file}, nil
+}
+
+// Record the working directory files the running operation is about to overwrite or remove, so undoing
+// it can write them back. Only the content a file had before the operation first touched it is kept.
+func (repository *Repository) recordWorkingFiles(filepaths ...string) error {
+	operation := repository.operation
+	if operation == nil {
+		return nil
+	}
+
+	for _, filepath := range filepaths {
+		if _, ok := operation.workingFiles[filepath]; ok {
+			continue
+		}
+
+		change, err := repository.storeWorkingChange(filepath)
+		if err != nil {
+			return err
+		}
+
+		operation.workingFiles[filepath] = change
+	}
+
+	return nil
+}
+
+// Snapshot HEAD, the refs, the index, the merge, cherry-pick or rebase in progress and the stashes.
+func (repository *Repository) takeSnapshot() (*filesystems.Snapshot, error) {
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return nil, err
+	}
+
+	cherryPickState, err := repository.fs.ReadCherryPickState()
+	if err != nil {
+		return nil, err
+	}
+
+	rebaseState, err := repository.fs.ReadRebaseState()
+	if err != nil {
+		return nil, err
+	}
+
+	stashes, err := repository.fs.ReadStashes()
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository index and refs are changed in place by the command
+	refs := maps.Clone(*repository.refs)
+
+	return &filesystems.Snapshot{
+		Head:            repository.head,
+		Refs:            &refs,
+		Index:           slices.Clone(repository.index),
+		MergeState:      mergeState,
+		CherryPickState: cherryPickState,
+		RebaseState:     rebaseState,
+		Stashes:         stashes,
+		WorkingFiles:    []*directories.Change{},
+	}, nil
+}
+
+// Read the snapshots of the recorded operations, both sides.
+func (repository *Repository) readOperationsSnapshots() ([]*filesystems.Snapshot, error) {
+	operations, err := repository.fs.ReadOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []*filesystems.Snapshot{}
+	for _, operation := range operations {
+		for _, side := range []filesystems.SnapshotSide{filesystems.SNAPSHOT_BEFORE, filesystems.SNAPSHOT_AFTER} {
+			snapshot, err := repository.fs.ReadSnapshot(operation.Id, side)
+			if err != nil {
+				return nil, err
+			}
+
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// BeginOperation snapshots the repository before a command, so it can be undone once recorded by EndOperation.
+func (repository *Repository) BeginOperation(command string) error {
+	before, err := repository.takeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	repository.operation = &pendingOperation{command: command, before: before, workingFiles: make(map[string]*directories.Change)}
+
+	return nil
+}
+
+// CancelOperation drops the command started by BeginOperation, so it is not recorded.
+func (repository *Repository) CancelOperation() {
+	repository.operation = nil
+}
+
+// EndOperation records the command started by BeginOperation, unless it did not change the repository.
+//
+// The repository is read again, since the command may have changed it through another instance.
+func (repository *Repository) EndOperation() error {
+	operation := repository.operation
+	if operation == nil {
+		return nil
+	}
+
+	repository.operation = nil
+
+	current, err := loadRepository(repository.fs)
+	if err != nil {
+		return err
+	}
+
+	after, err := current.takeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	// Only the files the command actually changed are kept
+	filepaths := slices.Sorted(maps.Keys(operation.workingFiles))
+	for _, filepath := range filepaths {
+		change, err := current.storeWorkingChange(filepath)
+		if err != nil {
+			return err
+		}
+
+		if beforeChange := operation.workingFiles[filepath]; beforeChange.GetHash() != change.GetHash() {
+			operation.before.WorkingFiles = append(operation.before.WorkingFiles, beforeChange)
+			after.WorkingFiles = append(after.WorkingFiles, change)
+		}
+	}
+
+	if after.SameState(operation.before) && len(after.WorkingFiles) == 0 {
+		return nil
+	}
+
+	_, err = repository.fs.AppendOperation(operation.command, operation.before, after)
+
+	return err
+}
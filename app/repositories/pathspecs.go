@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/ignores"
+	"slices"
+	"strings"
+)
+
+// A pathspec is either a path (file or directory) or a glob relative to the repository root.
+type pathspec struct {
+	filepath string
+	glob     string
+}
+
+func (repository *Repository) parsePathspec(spec string) (*pathspec, error) {
+	filepath, err := repository.dir.AbsPath(spec)
+	if err != nil {
+		return nil, &ValidationError{err.Error()}
+	}
+
+	if !strings.ContainsAny(spec, "*?[") {
+		return &pathspec{filepath: filepath}, nil
+	}
+
+	normalizedPath, err := repository.dir.NormalizePath(filepath)
+	if err != nil {
+		return nil, &ValidationError{err.Error()}
+	}
+
+	return &pathspec{filepath: filepath, glob: Path.ToSlash(normalizedPath)}, nil
+}
+
+func (repository *Repository) parsePathspecs(specs []string) ([]*pathspec, error) {
+	pathspecs := []*pathspec{}
+
+	for _, spec := range specs {
+		pathspec, err := repository.parsePathspec(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		pathspecs = append(pathspecs, pathspec)
+	}
+
+	return pathspecs, nil
+}
+
+func (pathspec *pathspec) isGlob() bool {
+	return pathspec.glob != ""
+}
+
+// Check whether an absolute file path is matched by the pathspec.
+//
+// Paths match themselves and any file inside them, globs match the path relative to the root.
+func (pathspec *pathspec) match(root string, filepath string) bool {
+	if !pathspec.isGlob() {
+		return filepath == pathspec.filepath || strings.HasPrefix(filepath, pathspec.filepath+string(Path.Separator)) || pathspec.filepath == root
+	}
+
+	relativePath, err := Path.Rel(root, filepath)
+	if err != nil {
+		return false
+	}
+
+	return ignores.MatchGlob(pathspec.glob, Path.ToSlash(relativePath))
+}
+
+func matchPathspecs(pathspecs []*pathspec, root string, filepath string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+
+	for _, pathspec := range pathspecs {
+		if pathspec.match(root, filepath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Collect the tracked file paths, from the HEAD file tree and the index.
+func (repository *Repository) getTrackedFilepaths() []string {
+	seen := make(map[string]bool)
+	filepaths := []string{}
+
+	for _, file := range repository.dir.CollectAllFiles() {
+		seen[file.Filepath] = true
+		filepaths = append(filepaths, file.Filepath)
+	}
+	for _, change := range repository.index {
+		if !seen[change.GetPath()] {
+			seen[change.GetPath()] = true
+			filepaths = append(filepaths, change.GetPath())
+		}
+	}
+
+	return filepaths
+}
+
+// Collect the file paths pathspecs can match: the working directory files and the tracked files.
+func (repository *Repository) getCandidateFilepaths() ([]string, error) {
+	filepaths := repository.getTrackedFilepaths()
+	seen := make(map[string]bool)
+
+	for _, filepath := range filepaths {
+		seen[filepath] = true
+	}
+
+	err := repository.walkWorkingDir(func(filepath string) error {
+		if !seen[filepath] {
+			filepaths = append(filepaths, filepath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(filepaths)
+
+	return filepaths, nil
+}
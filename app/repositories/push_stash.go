@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+	"time"
+)
+
+// PushStash records the index and the working directory changes, untracked files included, in a stash
+// and restores the index and the working directory files as HEAD has them.
+//
+// Two saves are written outside of the refs: the index save, with the HEAD save as parent, and the stash
+// save, with the working directory file tree and the HEAD save and the index save as parents. The stash
+// is "stash@{0}" afterwards, the previous stashes are shifted.
+func (repository *Repository) PushStash(message string) (*filesystems.Checkpoint, error) {
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, err
+	}
+	if repository.isIndexConflicted() {
+		return nil, &ValidationError{"index is conflicted."}
+	}
+	if repository.hasEmptySaveHistory() {
+		return nil, &ValidationError{"cannot stash without saves history."}
+	}
+
+	headSaveName := repository.getCurrentSaveName()
+	headDir, err := repository.fs.ReadDir(headSaveName)
+	if err != nil {
+		return nil, err
+	}
+	workingDir, err := repository.writeWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+
+	workingChanges := diffDirs(&headDir, workingDir)
+	if len(repository.index) == 0 && len(workingChanges) == 0 {
+		return nil, &ValidationError{"no local changes to stash."}
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("WIP on %s", repository.head)
+	}
+
+	stagedDir, err := repository.getStagedDir()
+	if err != nil {
+		return nil, err
+	}
+	stagedTree, err := repository.fs.WriteTree(stagedDir)
+	if err != nil {
+		return nil, err
+	}
+
+	indexCheckpoint := filesystems.Checkpoint{
+		Message:   fmt.Sprintf("index on %s", message),
+		Parents:   []string{headSaveName},
+		Tree:      stagedTree,
+		Changes:   repository.index,
+		CreatedAt: time.Now(),
+	}
+	if indexCheckpoint.Id, err = repository.fs.WriteCheckpoint(&indexCheckpoint); err != nil {
+		return nil, err
+	}
+
+	workingTree, err := repository.fs.WriteTree(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := filesystems.Checkpoint{
+		Message:   message,
+		Parents:   []string{headSaveName, indexCheckpoint.Id},
+		Tree:      workingTree,
+		Changes:   workingChanges,
+		CreatedAt: time.Now(),
+	}
+	if checkpoint.Id, err = repository.fs.WriteCheckpoint(&checkpoint); err != nil {
+		return nil, err
+	}
+
+	ids, err := repository.fs.ReadStashes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.fs.WriteStashes(slices.Insert(ids, 0, checkpoint.Id)); err != nil {
+		return nil, err
+	}
+
+	// Restore the stashed files as HEAD has them, the stashed untracked files are removed
+	headFiles := getDirFilesMap(&headDir)
+	for _, change := range workingChanges {
+		filepath := change.GetPath()
+
+		if err := repository.writeWorkingFile(filepath, headFiles[filepath]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repository.clearIndex(); err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidPushStash(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+
+	_, err := repository.PushStash("")
+	assert.EqualError(t, err, "Validation Error: cannot stash without saves history.")
+
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	_, err = repository.PushStash("")
+	assert.EqualError(t, err, "Validation Error: no local changes to stash.")
+}
+
+func TestPushStash(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+	fixtures.WriteFile(dir.Join("3.txt"), []byte("3 content."))
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.IndexFile("3.txt")
+	repository.SaveIndex()
+	save, _ := repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 staged content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 working content."))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 working content."))
+	fixtures.RemoveFile(dir.Join("3.txt"))
+	fixtures.MakeDirs(dir.Join("a"))
+	fixtures.WriteFile(dir.Join("a", "4.txt"), []byte("4 untracked content."))
+
+	// Test
+	stash, err := repository.PushStash("")
+	assert.Nil(t, err)
+	assert.Equal(t, stash.Message, "WIP on master")
+	assert.Equal(t, stash.Parents[0], save.Id)
+
+	// Check the working directory and the index are restored as HEAD has them
+	for _, file := range [][]string{{"1.txt", "1 content."}, {"2.txt", "2 content."}, {"3.txt", "3 content."}} {
+		content, _ := os.ReadFile(dir.Join(file[0]))
+		assert.Equal(t, string(content), file[1])
+	}
+	assert.NoDirExists(t, dir.Join("a"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, len(repository.index), 0)
+
+	status, _ := repository.GetStatus()
+	assert.False(t, status.HasChanges())
+
+	// Check the stash saves
+	stashes, _ := repository.fs.ReadStashes()
+	assert.Equal(t, stashes, []string{stash.Id})
+
+	stashDir, _ := repository.fs.ReadDir(stash.Id)
+	stashFiles := getDirFilesMap(&stashDir)
+	assert.Equal(t, len(stashFiles), 3)
+	content, _ := repository.fs.ReadDirFile(stashFiles[dir.Join("a", "4.txt")])
+	assert.Equal(t, content.String(), "4 untracked content.")
+
+	indexDir, _ := repository.fs.ReadDir(stash.Parents[1])
+	content, _ = repository.fs.ReadDirFile(getDirFilesMap(&indexDir)[dir.Join("1.txt")])
+	assert.Equal(t, content.String(), "1 staged content.")
+
+	// Check the newest stash is first
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 other content."))
+
+	otherStash, err := repository.PushStash("other")
+	assert.Nil(t, err)
+	assert.Equal(t, otherStash.Message, "other")
+	assert.Equal(t, otherStash.Changes[0].ChangeType, directories.Modification)
+
+	stashes, _ = repository.fs.ReadStashes()
+	assert.Equal(t, stashes, []string{otherStash.Id, stash.Id})
+
+	// Check stashes are resolved as revisions
+	repository = fixtureGetRepository(t, dir.Path())
+	for revision, saveName := range map[string]string{"stash": otherStash.Id, "stash@{1}": stash.Id, "stash@{1}^": save.Id, "stash@{2}": ""} {
+		resolved, err := repository.resolveRevision(revision)
+		assert.Nil(t, err)
+		assert.Equal(t, resolved, saveName)
+	}
+
+	// Check the stashes saves are kept by the garbage collection
+	_, err = repository.CollectGarbage(&GarbageCollectionOptions{})
+	assert.Nil(t, err)
+
+	report, _ := Fsck(dir.Path())
+	assert.False(t, report.HasErrors())
+	assert.Equal(t, report.Issues, []*FsckIssue{})
+}
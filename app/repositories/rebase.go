@@ -0,0 +1,355 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+// The saves a rebase in progress refers to.
+func getRebaseSaves(state *filesystems.RebaseState) []string {
+	saveNames := []string{state.RefSave, state.Onto}
+	if state.Current != nil {
+		saveNames = append(saveNames, state.Current.Id)
+	}
+	for _, step := range state.Pending {
+		saveNames = append(saveNames, step.Id)
+	}
+
+	return saveNames
+}
+
+// Check whether a rebase is in progress, other commands changing the current ref must wait for it.
+func (repository *Repository) checkRebaseInProgress() error {
+	state, err := repository.fs.ReadRebaseState()
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		return &ValidationError{"a rebase is in progress, continue or abort it first."}
+	}
+
+	return nil
+}
+
+// GetRebasePlan lists the saves of the current ref a rebase onto a revision replays, as pick steps.
+//
+// The saves are the ones not reachable from the revision, parents first. Merge saves are left out,
+// the saves of both their sides being replayed instead.
+func (repository *Repository) GetRebasePlan(onto string) ([]*filesystems.RebaseStep, error) {
+	if repository.hasEmptySaveHistory() {
+		return nil, &ValidationError{"cannot rebase without saves history."}
+	}
+
+	save, err := repository.getSave(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, err
+	}
+	ontoSave, err := repository.getSave(onto)
+	if err != nil {
+		return nil, err
+	}
+	if ontoSave == nil {
+		return nil, &ValidationError{"invalid ref."}
+	}
+
+	ontoIds := make(map[string]bool)
+	for _, checkpoint := range ontoSave.Checkpoints {
+		ontoIds[checkpoint.Id] = true
+	}
+
+	steps := []*filesystems.RebaseStep{}
+	for _, checkpoint := range save.Checkpoints {
+		if ontoIds[checkpoint.Id] || len(checkpoint.Parents) > 1 {
+			continue
+		}
+
+		steps = append(steps, &filesystems.RebaseStep{Action: filesystems.REBASE_PICK, Id: checkpoint.Id, Message: checkpoint.Subject()})
+	}
+
+	return steps, nil
+}
+
+// Resolve the plan steps revisions to saves names, dropped steps are left out.
+func (repository *Repository) resolveRebasePlan(plan []*filesystems.RebaseStep) ([]*filesystems.RebaseStep, error) {
+	steps := []*filesystems.RebaseStep{}
+
+	for _, step := range plan {
+		if step.Action == filesystems.REBASE_DROP {
+			continue
+		}
+		if step.Action == filesystems.REBASE_SQUASH && len(steps) == 0 {
+			return nil, &ValidationError{"cannot squash without a previous save."}
+		}
+		if step.Action == filesystems.REBASE_REWORD && step.Message == "" {
+			return nil, &ValidationError{fmt.Sprintf("\"reword %s\" is missing the new message.", step.Id)}
+		}
+
+		id, err := repository.resolveRevision(step.Id)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return nil, &ValidationError{fmt.Sprintf("invalid save \"%s\" in the rebase plan.", step.Id)}
+		}
+		if _, err := repository.fs.ReadCheckpoint(id); err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, &filesystems.RebaseStep{Action: step.Action, Id: id, Message: step.Message})
+	}
+
+	return steps, nil
+}
+
+// Save the changes of a step, applied on HEAD, as the step action says.
+//
+// Squashed changes replace the previous replayed save with a save having both changes and messages,
+// separated by a blank line, squashing with no previous replayed save picks the changes instead. Nothing is saved without changes.
+func (repository *Repository) replayStep(state *filesystems.RebaseState, step *filesystems.RebaseStep, checkpoint *filesystems.Checkpoint, dir *directories.Dir, changes []*directories.Change) (*filesystems.Checkpoint, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	headSaveName := repository.getCurrentSaveName()
+
+	if step.Action == filesystems.REBASE_SQUASH && headSaveName != state.Onto {
+		headCheckpoint, err := repository.fs.ReadCheckpoint(headSaveName)
+		if err != nil {
+			return nil, err
+		}
+		parentDir, err := repository.fs.ReadDir(headCheckpoint.FirstParent())
+		if err != nil {
+			return nil, err
+		}
+
+		message := fmt.Sprintf("%s\n\n%s", headCheckpoint.Message, checkpoint.Message)
+
+		return repository.writeAppliedSave(headCheckpoint.Parents, dir, diffDirs(&parentDir, dir), message, fmt.Sprintf("rebase %s: %s", step.Action, message))
+	}
+
+	message := checkpoint.Message
+	if step.Action == filesystems.REBASE_REWORD {
+		message = step.Message
+	}
+
+	return repository.createAppliedSave(dir, changes, message, fmt.Sprintf("rebase %s: %s", step.Action, message))
+}
+
+// Add a replayed save to the list, a squashed save replaces the save it was squashed into.
+func appendReplayedSave(replayed []*filesystems.Checkpoint, save *filesystems.Checkpoint) []*filesystems.Checkpoint {
+	if len(replayed) > 0 && slices.Equal(replayed[len(replayed)-1].Parents, save.Parents) {
+		replayed = replayed[:len(replayed)-1]
+	}
+
+	return append(replayed, save)
+}
+
+// Replay the pending steps of a rebase in order on the current ref.
+//
+// On conflicts, the rebase state is recorded and the replay stops. The replayed saves are returned along
+// with the conflicted changes.
+func (repository *Repository) replaySteps(state *filesystems.RebaseState, replayed []*filesystems.Checkpoint) ([]*filesystems.Checkpoint, []*directories.Change, error) {
+	for len(state.Pending) > 0 {
+		step := state.Pending[0]
+		state.Pending = state.Pending[1:]
+
+		checkpoint, err := repository.fs.ReadCheckpoint(step.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		parentDir, err := repository.fs.ReadDir(checkpoint.FirstParent())
+		if err != nil {
+			return nil, nil, err
+		}
+		saveDir, err := repository.fs.ReadDir(checkpoint.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergedDir, changes, conflictedChanges, err := repository.applyChanges(&parentDir, &saveDir, checkpoint.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(conflictedChanges) > 0 {
+			state.Current = step
+
+			return replayed, conflictedChanges, repository.fs.WriteRebaseState(state)
+		}
+
+		save, err := repository.replayStep(state, step, checkpoint, mergedDir, changes)
+		if err != nil {
+			return nil, nil, err
+		}
+		if save != nil {
+			replayed = appendReplayedSave(replayed, save)
+		}
+	}
+
+	return replayed, nil, repository.fs.RemoveRebaseState()
+}
+
+// Rebase replays the saves of the current ref onto another revision, so its history continues from it.
+//
+// Without a plan, the saves returned by GetRebasePlan are picked. A plan picks, squashes, rewords or
+// drops saves in its own order, saves left out of the plan are dropped. Each save changes are three-way
+// merged with HEAD, saves whose changes HEAD already has are skipped. When a save conflicts, the rebase
+// stops: the conflicts are written to the working directory and the index, to be resolved by adding the
+// files. The rebase is then continued with ContinueRebase or cancelled with AbortRebase.
+//
+// The replayed saves are returned along with the conflicted changes.
+func (repository *Repository) Rebase(onto string, plan []*filesystems.RebaseStep) ([]*filesystems.Checkpoint, []*directories.Change, error) {
+	if repository.isDetachedMode() {
+		return nil, nil, &ValidationError{"cannot make changes in detached mode."}
+	}
+	if repository.hasEmptySaveHistory() {
+		return nil, nil, &ValidationError{"cannot rebase without saves history."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkUnsavedChanges(); err != nil {
+		return nil, nil, err
+	}
+
+	save, err := repository.getSave(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, nil, err
+	}
+	ontoSave, err := repository.getSave(onto)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ontoSave == nil {
+		return nil, nil, &ValidationError{"invalid ref."}
+	}
+
+	if plan == nil {
+		if save.Contains(ontoSave) {
+			return nil, nil, &ValidationError{"current ref is up to date."}
+		}
+
+		if plan, err = repository.GetRebasePlan(onto); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	steps, err := repository.resolveRebasePlan(plan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ontoDir, err := repository.buildDir(ontoSave.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := repository.applyDir(ontoDir); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.setRef(repository.head, ontoSave.Id, fmt.Sprintf("rebase: onto %s", onto)); err != nil {
+		return nil, nil, err
+	}
+	repository.dir = *ontoDir
+
+	return repository.replaySteps(&filesystems.RebaseState{
+		Ref:     repository.head,
+		RefSave: save.Id,
+		Onto:    ontoSave.Id,
+		Pending: steps,
+	}, []*filesystems.Checkpoint{})
+}
+
+// Save the resolved changes of the step the rebase stopped at and replay the remaining steps.
+//
+// Nothing is saved when the index is empty, e.g. when the conflicts were resolved keeping HEAD files.
+func (repository *Repository) ContinueRebase() ([]*filesystems.Checkpoint, []*directories.Change, error) {
+	state, err := repository.fs.ReadRebaseState()
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, &ValidationError{"no rebase in progress."}
+	}
+	if repository.head != state.Ref {
+		return nil, nil, &ValidationError{fmt.Sprintf("the rebase in progress is on \"%s\", not on HEAD.", state.Ref)}
+	}
+	if repository.isIndexConflicted() {
+		return nil, nil, &ValidationError{"index is conflicted."}
+	}
+
+	replayed := []*filesystems.Checkpoint{}
+
+	if state.Current != nil && len(repository.index) > 0 {
+		checkpoint, err := repository.fs.ReadCheckpoint(state.Current.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dir, err := repository.getStagedDir()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		save, err := repository.replayStep(state, state.Current, checkpoint, dir, slices.Clone(repository.index))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := repository.clearIndex(); err != nil {
+			return nil, nil, err
+		}
+		if save != nil {
+			replayed = appendReplayedSave(replayed, save)
+		}
+	}
+
+	// The current step is done, even if the remaining steps cannot be replayed yet
+	state.Current = nil
+	if err := repository.fs.WriteRebaseState(state); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkUnsavedChanges(); err != nil {
+		return nil, nil, err
+	}
+
+	return repository.replaySteps(state, replayed)
+}
+
+// Cancel a rebase stopped by conflicts, the ref, the index and the working directory are restored
+// as they were before the rebase.
+func (repository *Repository) AbortRebase() error {
+	state, err := repository.fs.ReadRebaseState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return &ValidationError{"no rebase in progress."}
+	}
+
+	dir, err := repository.fs.ReadDir(state.RefSave)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.applyDir(&dir); err != nil {
+		return err
+	}
+	if err := repository.clearIndex(); err != nil {
+		return err
+	}
+	if err := repository.setRef(state.Ref, state.RefSave, "rebase: abort"); err != nil {
+		return err
+	}
+
+	return repository.fs.RemoveRebaseState()
+}
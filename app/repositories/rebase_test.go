@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidRebase(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	_, _, err := repository.Rebase("HEAD", nil)
+	assert.EqualError(t, err, "Validation Error: cannot rebase without saves history.")
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, _, err = repository.Rebase("undefined", nil)
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+	_, _, err = repository.Rebase("HEAD", nil)
+	assert.EqualError(t, err, "Validation Error: current ref is up to date.")
+
+	_, _, err = repository.Rebase("HEAD", []*filesystems.RebaseStep{{Action: filesystems.REBASE_SQUASH, Id: "HEAD"}})
+	assert.EqualError(t, err, "Validation Error: cannot squash without a previous save.")
+	_, _, err = repository.Rebase("HEAD", []*filesystems.RebaseStep{{Action: filesystems.REBASE_REWORD, Id: "HEAD"}})
+	assert.EqualError(t, err, "Validation Error: \"reword HEAD\" is missing the new message.")
+	_, _, err = repository.Rebase("HEAD", []*filesystems.RebaseStep{{Action: filesystems.REBASE_PICK, Id: "undefined"}})
+	assert.EqualError(t, err, "Validation Error: invalid save \"undefined\" in the rebase plan.")
+
+	_, _, err = repository.ContinueRebase()
+	assert.EqualError(t, err, "Validation Error: no rebase in progress.")
+	assert.EqualError(t, repository.AbortRebase(), "Validation Error: no rebase in progress.")
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 unsaved content."))
+
+	_, _, err = repository.Rebase("HEAD", nil)
+	assert.EqualError(t, err, "Validation Error: unsaved changes.")
+}
+
+func TestRebase(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1 (master)")
+	defer dir.Remove()
+
+	masterSaveName := repository.getCurrentSaveName()
+	repository.Load("feature")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Check the default plan picks the saves past the merge base
+	plan, err := repository.GetRebasePlan(filesystems.INITIAL_REF_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, plan, []*filesystems.RebaseStep{
+		{Action: filesystems.REBASE_PICK, Id: saves[0].Id, Message: "feature 1.txt"},
+		{Action: filesystems.REBASE_PICK, Id: saves[1].Id, Message: "feature 2.txt"},
+		{Action: filesystems.REBASE_PICK, Id: saves[2].Id, Message: "feature 1.txt"},
+	})
+
+	// Check saves left out are dropped and squashed saves are melded
+	replayed, conflicts, err := repository.Rebase(filesystems.INITIAL_REF_NAME, []*filesystems.RebaseStep{
+		{Action: filesystems.REBASE_PICK, Id: saves[1].Id},
+		{Action: filesystems.REBASE_SQUASH, Id: saves[2].Id},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(replayed), 1)
+	assert.Equal(t, replayed[0].Message, "feature 2.txt\n\nfeature 1.txt")
+	assert.Equal(t, replayed[0].Parents, []string{masterSaveName})
+	assert.Equal(t, len(replayed[0].Changes), 2)
+
+	// Check the squashed message is stored with its lines
+	checkpoint, err := repository.fs.ReadCheckpoint(replayed[0].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, checkpoint.Message, "feature 2.txt\n\nfeature 1.txt")
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "line 1 (master)\nline 2\nline 3 (feature)\n")
+	assert.FileExists(t, dir.Join("2.txt"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), replayed[0].Id)
+
+	reflog, _ := repository.GetReflog("feature")
+	assert.Equal(t, reflog.Entries[0].Command, "rebase squash: feature 2.txt")
+
+	plan, err = repository.GetRebasePlan("HEAD~")
+	assert.Nil(t, err)
+	assert.Equal(t, plan, []*filesystems.RebaseStep{{Action: filesystems.REBASE_PICK, Id: replayed[0].Id, Message: "feature 2.txt"}})
+
+	// Check reworded saves
+	replayed, conflicts, err = repository.Rebase("HEAD~", []*filesystems.RebaseStep{
+		{Action: filesystems.REBASE_REWORD, Id: "HEAD", Message: "Feature files."},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(replayed), 1)
+	assert.Equal(t, replayed[0].Message, "Feature files.")
+	assert.Equal(t, replayed[0].Parents, []string{masterSaveName})
+
+	status, _ := repository.GetStatus()
+	assert.Nil(t, status.Rebase)
+	assert.False(t, status.HasChanges())
+}
+
+func TestContinueRebase(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1 (master)")
+	defer dir.Remove()
+
+	masterSaveName := repository.getCurrentSaveName()
+	repository.Load("feature")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	replayed, conflicts, err := repository.Rebase(filesystems.INITIAL_REF_NAME, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, len(replayed), 0)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].GetPath(), dir.Join("1.txt"))
+
+	// Check the rebase state is recorded
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), masterSaveName)
+
+	status, _ := repository.GetStatus()
+	assert.Equal(t, status.Rebase, &filesystems.RebaseState{
+		Ref:     "feature",
+		RefSave: saves[2].Id,
+		Onto:    masterSaveName,
+		Current: &filesystems.RebaseStep{Action: filesystems.REBASE_PICK, Id: saves[0].Id, Message: "feature 1.txt"},
+		Pending: []*filesystems.RebaseStep{
+			{Action: filesystems.REBASE_PICK, Id: saves[1].Id, Message: "feature 2.txt"},
+			{Action: filesystems.REBASE_PICK, Id: saves[2].Id, Message: "feature 1.txt"},
+		},
+	})
+
+	_, _, err = repository.ContinueRebase()
+	assert.EqualError(t, err, "Validation Error: index is conflicted.")
+	_, _, err = repository.CherryPick([]string{saves[0].Id})
+	assert.EqualError(t, err, "Validation Error: a rebase is in progress, continue or abort it first.")
+	assert.EqualError(t, repository.Load(filesystems.INITIAL_REF_NAME), "Validation Error: a rebase is in progress, continue or abort it first.")
+	assert.EqualError(t, repository.CreateRef("other"), "Validation Error: a rebase is in progress, continue or abort it first.")
+
+	repository.head = filesystems.INITIAL_REF_NAME
+	_, _, err = repository.ContinueRebase()
+	assert.EqualError(t, err, "Validation Error: the rebase in progress is on \"feature\", not on HEAD.")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.head, "feature")
+	assert.NotContains(t, *repository.refs, "other")
+
+	// Check the resolved save and the remaining saves are replayed
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (master and feature)\nline 2\nline 3\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+
+	replayed, conflicts, err = repository.ContinueRebase()
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(replayed), 3)
+	assert.Equal(t, replayed[0].Message, "feature 1.txt")
+	assert.Equal(t, replayed[0].Parents, []string{masterSaveName})
+	assert.Equal(t, replayed[2].Parents, []string{replayed[1].Id})
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "line 1 (master and feature)\nline 2\nline 3 (feature)\n")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), replayed[2].Id)
+
+	status, _ = repository.GetStatus()
+	assert.Nil(t, status.Rebase)
+	assert.False(t, status.HasChanges())
+}
+
+func TestContinueRebaseSquash(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1 (master)")
+	defer dir.Remove()
+
+	masterSaveName := repository.getCurrentSaveName()
+	repository.Load("feature")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// The squashed save conflicts
+	replayed, conflicts, err := repository.Rebase(filesystems.INITIAL_REF_NAME, []*filesystems.RebaseStep{
+		{Action: filesystems.REBASE_PICK, Id: saves[1].Id},
+		{Action: filesystems.REBASE_SQUASH, Id: saves[0].Id},
+		{Action: filesystems.REBASE_SQUASH, Id: saves[2].Id},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(replayed), 1)
+	assert.Equal(t, len(conflicts), 1)
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (master and feature)\nline 2\nline 3\n"))
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+
+	// Check the saves squashed on continue are returned once
+	replayed, conflicts, err = repository.ContinueRebase()
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, len(replayed), 1)
+	assert.Equal(t, replayed[0].Message, "feature 2.txt\n\nfeature 1.txt\n\nfeature 1.txt")
+	assert.Equal(t, replayed[0].Parents, []string{masterSaveName})
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), replayed[0].Id)
+}
+
+func TestAbortRebase(t *testing.T) {
+	dir, repository, saves := fixtureCherryPickRefs(t, "line 1 (master)")
+	defer dir.Remove()
+
+	repository.Load("feature")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// The first save is replayed, the second one conflicts
+	replayed, conflicts, err := repository.Rebase(filesystems.INITIAL_REF_NAME, []*filesystems.RebaseStep{
+		{Action: filesystems.REBASE_PICK, Id: saves[1].Id},
+		{Action: filesystems.REBASE_PICK, Id: saves[0].Id},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(replayed), 1)
+	assert.Equal(t, len(conflicts), 1)
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Nil(t, repository.AbortRebase())
+
+	// Check the ref, the index and the working directory are restored
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), saves[2].Id)
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "line 1 (feature)\nline 2\nline 3 (feature)\n")
+	assert.FileExists(t, dir.Join("2.txt"))
+
+	status, _ := repository.GetStatus()
+	assert.Nil(t, status.Rebase)
+	assert.False(t, status.HasChanges())
+}
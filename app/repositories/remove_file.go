@@ -1,8 +1,9 @@
 package repositories
 
 import (
+	"fmt"
 	"os"
-	"saymow/version-manager/app/pkg/errors"
+	Path "path/filepath"
 	"saymow/version-manager/app/repositories/directories"
 	"slices"
 )
@@ -18,24 +19,29 @@ func (repository *Repository) RemoveFile(filepath string) error {
 	}
 
 	// Remove from working dir
+	if err := repository.recordWorkingFiles(filepath); err != nil {
+		return err
+	}
+
 	err = os.Remove(filepath)
 	if err != nil && !os.IsNotExist(err) {
-		errors.Error(err.Error())
+		return err
 	}
 
+	repository.stageRemoval(filepath)
+
+	return nil
+}
+
+// Replace the index entry of a path by a removal entry, if the path is saved.
+func (repository *Repository) stageRemoval(filepath string) {
 	stagedChangeIdx := repository.findStagedChangeIdx(filepath)
 	savedObject := repository.findSavedFile(filepath)
 
 	if stagedChangeIdx != -1 {
 		if repository.index[stagedChangeIdx].ChangeType == directories.Removal {
 			// Index entry is already meant for removal
-			return nil
-		}
-
-		if !(repository.index[stagedChangeIdx].ChangeType == directories.Conflict && !repository.index[stagedChangeIdx].Conflict.IsObjectTemporary()) {
-			// Remove change object unless it is a conflict permanent object.
-
-			repository.fs.RemoveObject(repository.index[stagedChangeIdx].GetHash())
+			return
 		}
 		// Remove existing change from the index
 		repository.index = slices.Delete(repository.index, stagedChangeIdx, stagedChangeIdx+1)
@@ -45,6 +51,97 @@ func (repository *Repository) RemoveFile(filepath string) error {
 		// Create Index file removal entry
 		repository.index = append(repository.index, &directories.Change{ChangeType: directories.Removal, Removal: &directories.FileRemoval{Filepath: filepath}})
 	}
+}
+
+// Remove the tracked files matched by the pathspecs from the index and working directory.
+//
+// Directories are only removed when recursive is set. Untracked and ignored files are kept.
+func (repository *Repository) RemoveFiles(specs []string, recursive bool) error {
+	if repository.isDetachedMode() {
+		return &ValidationError{"cannot make changes in detached mode."}
+	}
+
+	pathspecs, err := repository.parsePathspecs(specs)
+	if err != nil {
+		return err
+	}
+
+	// Only tracked files are matched, so untracked files are never deleted
+	trackedFilepaths := repository.getTrackedFilepaths()
+	slices.Sort(trackedFilepaths)
+
+	removedFilepaths := []string{}
+
+	for idx, pathspec := range pathspecs {
+		info, err := os.Stat(pathspec.filepath)
+		isDir := (err == nil && info.IsDir()) || (err != nil && !repository.isTrackedFile(pathspec.filepath) && repository.isTracked(pathspec.filepath))
+
+		if !pathspec.isGlob() && !isDir {
+			if !repository.isTrackedFile(pathspec.filepath) {
+				return &ValidationError{fmt.Sprintf("pathspec \"%s\" did not match any files.", specs[idx])}
+			}
+
+			if err := repository.RemoveFile(pathspec.filepath); err != nil {
+				return err
+			}
+
+			removedFilepaths = append(removedFilepaths, pathspec.filepath)
+			continue
+		}
+
+		if !pathspec.isGlob() && !recursive {
+			return &ValidationError{fmt.Sprintf("not removing \"%s\" recursively without -r.", specs[idx])}
+		}
+
+		matched := false
+
+		for _, filepath := range trackedFilepaths {
+			if !pathspec.match(repository.fs.Root, filepath) {
+				continue
+			}
+
+			matched = true
+
+			if err := repository.RemoveFile(filepath); err != nil {
+				return err
+			}
+
+			removedFilepaths = append(removedFilepaths, filepath)
+		}
+
+		if !matched {
+			return &ValidationError{fmt.Sprintf("pathspec \"%s\" did not match any files.", specs[idx])}
+		}
+	}
+
+	for _, filepath := range removedFilepaths {
+		if err := repository.removeEmptyParentDirs(filepath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove the parent directories of a removed file, as long as they are empty.
+func (repository *Repository) removeEmptyParentDirs(filepath string) error {
+	for dir := Path.Dir(filepath); dir != repository.fs.Root && dir != Path.Dir(dir); dir = Path.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			return nil
+		}
+
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
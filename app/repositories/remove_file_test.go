@@ -69,8 +69,8 @@ func TestRemoveFile(t *testing.T) {
 		)
 		// Check file is deleted
 		assert.False(t, fixtures.FileExists(dir.Join("a", "4.txt")))
-		// Check object is deleted
-		assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, creationChange.File.ObjectName)))
+		// Check object is kept until garbage collected
+		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, creationChange.File.ObjectName)))
 	}
 
 	// Check remove file existing on the index, working filesystem.dir and tree
@@ -99,8 +99,8 @@ func TestRemoveFile(t *testing.T) {
 		)
 		// Check file is deleted
 		assert.False(t, fixtures.FileExists(dir.Join("3.txt")))
-		// Check object is deleted
-		assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, modificationChange.File.ObjectName)))
+		// Check object is kept until garbage collected
+		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, modificationChange.File.ObjectName)))
 		// Check removal change is added to the index
 		assert.NotEqual(
 			t,
@@ -130,7 +130,7 @@ func TestRemoveConflictedFile(t *testing.T) {
 		repository.SaveIndex()
 		repository.CreateSave("s0")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		// Mock a merge conflict
 
@@ -158,7 +158,7 @@ func TestRemoveConflictedFile(t *testing.T) {
 		assert.Equal(t, repository.index[0].ChangeType, directories.Removal)
 		assert.Equal(t, repository.index[0].Removal.Filepath, dir.Join("a.txt"))
 		assert.False(t, fixtures.FileExists(dir.Join("a.txt")))
-		assert.False(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, tempObjectName)))
+		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, tempObjectName)))
 	}
 
 	// Test permanent conflict object (MUST NOT BE REMOVED)
@@ -194,3 +194,54 @@ func TestRemoveConflictedFile(t *testing.T) {
 		assert.True(t, fixtures.FileExists(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, tempObjectName)))
 	}
 }
+
+func TestRemoveFiles(t *testing.T) {
+	dir, repository := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	repository.IndexFiles([]string{}, &IndexOptions{All: true})
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.EqualError(t, repository.RemoveFiles([]string{"a"}, false), "Validation Error: not removing \"a\" recursively without -r.")
+	assert.EqualError(t, repository.RemoveFiles([]string{"**/*.go"}, false), "Validation Error: pathspec \"**/*.go\" did not match any files.")
+
+	// Check untracked files are kept
+	{
+		fixtures.MakeDirs(dir.Join("d"))
+		fixtures.WriteFile(dir.Join("d", "untracked.txt"), []byte("untracked content."))
+		fixtures.WriteFile(dir.Join("a", "untracked.txt"), []byte("untracked content."))
+		fixtures.WriteFile(dir.Join("c", "untracked.txt"), []byte("untracked content."))
+		fixtures.WriteFile(dir.Join("untracked.txt"), []byte("untracked content."))
+
+		assert.EqualError(t, repository.RemoveFiles([]string{"untracked.txt"}, false), "Validation Error: pathspec \"untracked.txt\" did not match any files.")
+		assert.EqualError(t, repository.RemoveFiles([]string{"d"}, true), "Validation Error: pathspec \"d\" did not match any files.")
+		assert.EqualError(t, repository.RemoveFiles([]string{"*.md"}, false), "Validation Error: pathspec \"*.md\" did not match any files.")
+		assert.True(t, fixtures.FileExists(dir.Join("untracked.txt")))
+		assert.True(t, fixtures.FileExists(dir.Join("d", "untracked.txt")))
+	}
+
+	// Check globs
+	{
+		assert.Nil(t, repository.RemoveFiles([]string{"c/*.txt"}, false))
+		assert.False(t, fixtures.FileExists(dir.Join("c", "8.txt")))
+		assert.False(t, fixtures.FileExists(dir.Join("c", "9.txt")))
+		assert.True(t, fixtures.FileExists(dir.Join("c", "untracked.txt")))
+		assert.Equal(t, len(repository.index), 2)
+	}
+
+	// Check directories are removed recursively
+	{
+		assert.Nil(t, repository.RemoveFiles([]string{"a"}, true))
+		assert.False(t, fixtures.FileExists(dir.Join("a", "4.txt")))
+		assert.True(t, fixtures.FileExists(dir.Join("a", "untracked.txt")))
+		assert.True(t, fixtures.FileExists(dir.Join("1.txt")))
+		assert.Equal(t, len(repository.index), 6)
+
+		for _, change := range repository.index {
+			assert.Equal(t, change.ChangeType, directories.Removal)
+		}
+	}
+}
@@ -1,20 +1,29 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	Path "path/filepath"
 	"saymow/version-manager/app/pkg/collections"
-	"saymow/version-manager/app/pkg/errors"
+	"slices"
+	"strings"
+	"time"
 
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
+	"saymow/version-manager/app/repositories/ignores"
 )
 
 type Repository struct {
-	fs    *filesystems.FileSystem
-	refs  *filesystems.Refs
-	head  string
-	index []*directories.Change
-	dir   directories.Dir
+	fs     *filesystems.FileSystem
+	refs   *filesystems.Refs
+	head   string
+	index  []*directories.Change
+	dir    directories.Dir
+	ignore *ignores.Matcher
+	// Command being recorded, see BeginOperation.
+	operation *pendingOperation
 }
 
 type SaveLog struct {
@@ -39,6 +48,12 @@ type Status struct {
 		UntrackedFilePaths []string
 		RemovedFilePaths   []string
 	}
+	// Merge stopped by conflicts, nil when there is no merge in progress.
+	Merge *filesystems.MergeState
+	// Cherry-pick stopped by conflicts, nil when there is no cherry-pick in progress.
+	CherryPick *filesystems.CherryPickState
+	// Rebase stopped by conflicts, nil when there is no rebase in progress.
+	Rebase *filesystems.RebaseState
 }
 
 type ValidationError struct {
@@ -49,16 +64,25 @@ func (err *ValidationError) Error() string {
 	return fmt.Sprintf("Validation Error: %s", err.Message)
 }
 
-func CreateRepository(root string) *Repository {
-	fileSystem := filesystems.Create(root)
+func CreateRepository(root string) (*Repository, error) {
+	fileSystem, err := filesystems.Create(root)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Repository{
-		fs:    fileSystem,
-		refs:  &filesystems.Refs{filesystems.INITIAL_REF_NAME: ""},
-		head:  filesystems.INITIAL_REF_NAME,
-		index: []*directories.Change{},
-		dir:   directories.Dir{Path: root, Children: make(map[string]*directories.Node)},
+	ignore, err := fileSystem.OpenIgnore()
+	if err != nil {
+		return nil, err
 	}
+
+	return &Repository{
+		fs:     fileSystem,
+		refs:   &filesystems.Refs{filesystems.INITIAL_REF_NAME: ""},
+		head:   filesystems.INITIAL_REF_NAME,
+		index:  []*directories.Change{},
+		dir:    directories.Dir{Path: root, Children: make(map[string]*directories.Node)},
+		ignore: ignore,
+	}, nil
 }
 
 func (status *Status) HasChanges() bool {
@@ -71,16 +95,68 @@ func (status *Status) HasChanges() bool {
 		len(status.WorkingDir.RemovedFilePaths) > 0
 }
 
-func GetRepository(root string) *Repository {
-	repository := &Repository{}
+// GetRepository loads the repository at root.
+//
+// A *filesystems.NotRepositoryError is returned when root has no repository, corrupt or missing
+// repository files are reported with the filesystems typed errors.
+func GetRepository(root string) (*Repository, error) {
+	fileSystem, err := filesystems.Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadRepository(fileSystem)
+}
+
+// LockRepository acquires the repository lock and loads the repository.
+//
+// Commands that change the repository must hold the lock, it is released with Unlock.
+// A *filesystems.LockHeldError is returned when another running process holds the lock.
+func LockRepository(root string) (*Repository, error) {
+	fileSystem, err := filesystems.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileSystem.Lock(); err != nil {
+		return nil, err
+	}
+
+	repository, err := loadRepository(fileSystem)
+	if err != nil {
+		fileSystem.Unlock()
+
+		return nil, err
+	}
+
+	return repository, nil
+}
+
+// Release the repository lock acquired by LockRepository.
+func (repository *Repository) Unlock() error {
+	return repository.fs.Unlock()
+}
+
+func loadRepository(fileSystem *filesystems.FileSystem) (*Repository, error) {
+	var err error
+	repository := &Repository{fs: fileSystem}
 
-	repository.fs = filesystems.Open(root)
-	repository.index = repository.fs.ReadIndex()
-	repository.refs = repository.fs.ReadRefs()
-	repository.head = repository.fs.ReadHead()
-	repository.dir = repository.fs.ReadDir(repository.getCurrentSaveName())
+	if repository.index, err = repository.fs.ReadIndex(); err != nil {
+		return nil, err
+	}
+	if repository.refs, err = repository.fs.ReadRefs(); err != nil {
+		return nil, err
+	}
+	if repository.head, err = repository.fs.ReadHead(); err != nil {
+		return nil, err
+	}
+	if repository.dir, err = repository.fs.ReadDir(repository.getCurrentSaveName()); err != nil {
+		return nil, err
+	}
+	if repository.ignore, err = repository.fs.OpenIgnore(); err != nil {
+		return nil, err
+	}
 
-	return repository
+	return repository, nil
 }
 
 func (repository *Repository) getCurrentSaveName() string {
@@ -106,19 +182,57 @@ func (repository *Repository) isDetachedMode() bool {
 	return true
 }
 
-func (repository *Repository) clearIndex() {
+func (repository *Repository) clearIndex() error {
 	repository.index = []*directories.Change{}
-	repository.fs.SaveIndex(repository.index)
+
+	return repository.fs.SaveIndex(repository.index)
 }
 
-func (repository *Repository) setRef(name, saveName string) {
+// Record a movement in a reflog, reason is the command that made it.
+func (repository *Repository) appendReflog(name, oldId, newId, reason string) error {
+	// Reflog entries are single lines, multi-line saves messages are cut to their first line
+	reason, _, _ = strings.Cut(reason, "\n")
+
+	return repository.fs.AppendReflog(name, &filesystems.ReflogEntry{
+		OldId:     oldId,
+		NewId:     newId,
+		Command:   reason,
+		CreatedAt: time.Now(),
+	})
+}
+
+// Point a ref to a save, the movement is recorded in the ref reflog and, for the current ref, in the HEAD reflog.
+func (repository *Repository) setRef(name, saveName, reason string) error {
+	oldSaveName := (*repository.refs)[name]
 	(*repository.refs)[name] = saveName
-	repository.fs.WriteRefs(repository.refs)
+
+	if err := repository.fs.WriteRefs(repository.refs); err != nil {
+		return err
+	}
+	if oldSaveName == saveName {
+		return nil
+	}
+
+	if err := repository.appendReflog(name, oldSaveName, saveName, reason); err != nil {
+		return err
+	}
+	if name == repository.head {
+		return repository.appendReflog(filesystems.HEAD_REFLOG_NAME, oldSaveName, saveName, reason)
+	}
+
+	return nil
 }
 
-func (repository *Repository) setHead(newHead string) {
+// Point HEAD to a ref or a save, the movement is recorded in the HEAD reflog.
+func (repository *Repository) setHead(newHead, reason string) error {
+	oldSaveName := repository.getCurrentSaveName()
 	repository.head = newHead
-	repository.fs.WriteHead(repository.head)
+
+	if err := repository.fs.WriteHead(repository.head); err != nil {
+		return err
+	}
+
+	return repository.appendReflog(filesystems.HEAD_REFLOG_NAME, oldSaveName, repository.getCurrentSaveName(), reason)
 }
 
 func (repository *Repository) isIndexConflicted() bool {
@@ -146,7 +260,9 @@ func (repository *Repository) findStagedChange(filepath string) *directories.Cha
 
 func (repository *Repository) findSavedFile(filepath string) *directories.File {
 	normalizedPath, err := repository.dir.NormalizePath(filepath)
-	errors.Check(err)
+	if err != nil {
+		return nil
+	}
 
 	node := repository.dir.FindNode(normalizedPath)
 	if node == nil || node.NodeType != directories.FileType {
@@ -156,27 +272,82 @@ func (repository *Repository) findSavedFile(filepath string) *directories.File {
 	return node.File
 }
 
-func (repository *Repository) getSave(ref string) *filesystems.Save {
+// Check whether a file is in the HEAD file tree or in the index.
+func (repository *Repository) isTrackedFile(filepath string) bool {
+	return repository.findSavedFile(filepath) != nil || repository.findStagedChange(filepath) != nil
+}
+
+// Check whether a path is in the HEAD file tree or in the index.
+//
+// For directories, any tracked file inside makes the directory tracked.
+func (repository *Repository) isTracked(filepath string) bool {
+	normalizedPath, err := repository.dir.NormalizePath(filepath)
+	if err != nil {
+		return false
+	}
+	if repository.dir.FindNode(normalizedPath) != nil {
+		return true
+	}
+
+	idx := collections.FindIndex(repository.index, func(change *directories.Change, _ int) bool {
+		return change.GetPath() == filepath || strings.HasPrefix(change.GetPath(), filepath+string(Path.Separator))
+	})
+
+	return idx != -1
+}
+
+// Read the checkpoint of the save pointed by a revision, see resolveRevision. Its history is not read,
+// use getSave when it is needed.
+//
+// nil is returned when the revision does not point to a save.
+func (repository *Repository) getCheckpoint(ref string) (*filesystems.Checkpoint, error) {
 	if repository.hasEmptySaveHistory() {
-		return nil
+		return nil, nil
 	}
 	if ref == "" {
-		return nil
+		return nil, nil
 	}
 
-	if ref == "HEAD" {
-		ref = repository.head
+	checkpointId, err := repository.resolveRevision(ref)
+	if err != nil || checkpointId == "" {
+		return nil, err
 	}
 
-	var checkpointId string
+	checkpoint, err := repository.fs.ReadCheckpoint(checkpointId)
 
-	if saveName, ok := (*repository.refs)[ref]; ok {
-		checkpointId = saveName
-	} else {
-		checkpointId = ref
+	var missingSaveErr *filesystems.MissingSaveError
+	if errors.As(err, &missingSaveErr) && missingSaveErr.Id == checkpointId {
+		return nil, nil
 	}
 
-	return repository.fs.ReadSave(checkpointId)
+	return checkpoint, err
+}
+
+// Read the save pointed by a revision with its whole history, see resolveRevision. Reading a save
+// walks every save reachable from it, so it is only used where the history is needed.
+//
+// nil is returned when the revision does not point to a save.
+func (repository *Repository) getSave(ref string) (*filesystems.Save, error) {
+	if repository.hasEmptySaveHistory() {
+		return nil, nil
+	}
+	if ref == "" {
+		return nil, nil
+	}
+
+	checkpointId, err := repository.resolveRevision(ref)
+	if err != nil || checkpointId == "" {
+		return nil, err
+	}
+
+	save, err := repository.fs.ReadSave(checkpointId)
+
+	var missingSaveErr *filesystems.MissingSaveError
+	if errors.As(err, &missingSaveErr) && missingSaveErr.Id == checkpointId {
+		return nil, nil
+	}
+
+	return save, err
 }
 
 func (repository *Repository) resolvePath(path string) (string, error) {
@@ -189,22 +360,37 @@ func (repository *Repository) resolvePath(path string) (string, error) {
 	return normalizedPath, nil
 }
 
-func buildDir(root string, save *filesystems.Save) *directories.Dir {
-	dir := &directories.Dir{Path: root, Children: map[string]*directories.Node{}}
+// Read the file tree of a save.
+func (repository *Repository) buildDir(saveName string) (*directories.Dir, error) {
+	dir, err := repository.fs.ReadDir(saveName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dir, nil
+}
 
-	for _, checkpoint := range save.Checkpoints {
-		for _, change := range checkpoint.Changes {
-			normalizedPath, err := dir.NormalizePath(change.GetPath())
-			errors.Check(err)
+// Replace the working directory files of a directory by the dir files, ignored files are kept.
+func (repository *Repository) applyDir(dir *directories.Dir) error {
+	if repository.operation != nil {
+		filepaths := slices.Collect(maps.Keys(getDirFilesMap(dir)))
 
-			dir.AddNode(normalizedPath, change)
+		err := repository.walkWorkingDir(func(filepath string) error {
+			if dir.IsSubpath(filepath) {
+				filepaths = append(filepaths, filepath)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
 		}
-	}
 
-	return dir
-}
+		if err := repository.recordWorkingFiles(filepaths...); err != nil {
+			return err
+		}
+	}
 
-func (repository *Repository) applyDir(dir *directories.Dir) {
 	nodes := dir.PreOrderTraversal()
 
 	if dir.Path == repository.fs.Root {
@@ -213,9 +399,85 @@ func (repository *Repository) applyDir(dir *directories.Dir) {
 		nodes = nodes[1:]
 	}
 
-	repository.fs.SafeRemoveWorkingDir(dir.Path)
+	if err := repository.fs.SafeRemoveWorkingDir(dir.Path, repository.ignore); err != nil {
+		return err
+	}
 
 	for _, node := range nodes {
-		repository.fs.CreateNode(node)
+		if err := repository.fs.CreateNode(node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffDirsHelper(from *directories.Dir, to *directories.Dir, changes *[]*directories.Change, removedFilepaths *[]string) {
+	if from.Hash != "" && from.Hash == to.Hash {
+		// Identical trees, there is no need to look into them.
+		return
+	}
+
+	for name, toNode := range to.Children {
+		fromNode, ok := from.Children[name]
+
+		switch {
+		case ok && fromNode.NodeType == directories.DirType && toNode.NodeType == directories.DirType:
+			diffDirsHelper(fromNode.Dir, toNode.Dir, changes, removedFilepaths)
+		case ok && fromNode.NodeType == directories.FileType && toNode.NodeType == directories.FileType:
+			if fromNode.File.ObjectName != toNode.File.ObjectName {
+				*changes = append(*changes, &directories.Change{ChangeType: directories.Modification, File: toNode.File})
+			}
+		default:
+			if ok {
+				collectRemovedFilepaths(fromNode, removedFilepaths)
+			}
+
+			if toNode.NodeType == directories.FileType {
+				*changes = append(*changes, &directories.Change{ChangeType: directories.Creation, File: toNode.File})
+			} else {
+				for _, file := range toNode.Dir.CollectAllFiles() {
+					*changes = append(*changes, &directories.Change{ChangeType: directories.Creation, File: file})
+				}
+			}
+		}
+	}
+
+	for name, fromNode := range from.Children {
+		if _, ok := to.Children[name]; !ok {
+			collectRemovedFilepaths(fromNode, removedFilepaths)
+		}
 	}
 }
+
+func collectRemovedFilepaths(node *directories.Node, removedFilepaths *[]string) {
+	if node.NodeType == directories.FileType {
+		*removedFilepaths = append(*removedFilepaths, node.File.Filepath)
+		return
+	}
+
+	for _, file := range node.Dir.CollectAllFiles() {
+		*removedFilepaths = append(*removedFilepaths, file.Filepath)
+	}
+}
+
+// Compute the changes needed to transform the "from" file tree into the "to" file tree.
+//
+// Subtrees with the same tree object name on both sides are skipped.
+func diffDirs(from *directories.Dir, to *directories.Dir) []*directories.Change {
+	changes := []*directories.Change{}
+	removedFilepaths := []string{}
+
+	diffDirsHelper(from, to, &changes, &removedFilepaths)
+
+	slices.SortFunc(changes, func(a, b *directories.Change) int {
+		return strings.Compare(a.File.Filepath, b.File.Filepath)
+	})
+	slices.Sort(removedFilepaths)
+
+	for _, filepath := range removedFilepaths {
+		changes = append(changes, &directories.Change{ChangeType: directories.Removal, Removal: &directories.FileRemoval{Filepath: filepath}})
+	}
+
+	return changes
+}
@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"fmt"
+	"os"
 	Path "path/filepath"
+	"saymow/version-manager/app/pkg/fixtures"
 	"saymow/version-manager/app/repositories/directories"
 	"saymow/version-manager/app/repositories/filesystems"
 	"testing"
@@ -47,7 +49,7 @@ func TestGetRepository(t *testing.T) {
 		fixtureMakeBasicRepositoryFs(dir),
 	)
 
-	repository := GetRepository(dir.Path())
+	repository := fixtureGetRepository(t, dir.Path())
 
 	fsAssert.Equal(t, repository.fs.Root, dir.Path())
 	fsAssert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
@@ -114,3 +116,77 @@ func TestResolvePath(t *testing.T) {
 	_, err = repository.resolvePath(dir.Join(".."))
 	assert.Error(t, err, "invalid path.")
 }
+
+func TestGetRepositoryErrors(t *testing.T) {
+	// Check not a repository
+	{
+		dir := fs.NewDir(t, "project")
+		defer dir.Remove()
+
+		_, err := GetRepository(dir.Path())
+
+		var notRepositoryErr *filesystems.NotRepositoryError
+		assert.ErrorAs(t, err, &notRepositoryErr)
+		assert.Equal(t, notRepositoryErr.Root, dir.Path())
+	}
+
+	// Check corrupt save
+	{
+		dir := fs.NewDir(t, "project")
+		defer dir.Remove()
+
+		fs.Apply(t, dir, fixtureMakeBasicRepositoryFs(dir))
+		fixtures.WriteFile(
+			dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME, "9a35bd416196f27e40f4f9e4768496ef29c1922f0ab5e2651a218e4d4cb09688"),
+			[]byte("tampered"),
+		)
+
+		_, err := GetRepository(dir.Path())
+
+		var corruptSaveErr *filesystems.CorruptSaveError
+		assert.ErrorAs(t, err, &corruptSaveErr)
+		assert.Equal(t, corruptSaveErr.Id, "9a35bd416196f27e40f4f9e4768496ef29c1922f0ab5e2651a218e4d4cb09688")
+	}
+
+	// Check missing object
+	{
+		dir, repository := fixtureGetBaseProject(t)
+		defer dir.Remove()
+
+		repository.IndexFile("1.txt")
+		repository.SaveIndex()
+		save, _ := repository.CreateSave("s0")
+		repository = fixtureGetRepository(t, dir.Path())
+
+		objectName := save.Changes[0].File.ObjectName
+		assert.Nil(t, os.Remove(dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, objectName)))
+		assert.Nil(t, os.Remove(dir.Join("1.txt")))
+
+		err := repository.Restore("HEAD", "1.txt")
+
+		var missingObjectErr *filesystems.MissingObjectError
+		assert.ErrorAs(t, err, &missingObjectErr)
+		assert.Equal(t, missingObjectErr.Name, objectName)
+	}
+}
+
+func TestLockRepository(t *testing.T) {
+	dir, _ := fixtureGetBaseProject(t)
+	defer dir.Remove()
+
+	repository, err := LockRepository(dir.Path())
+	assert.Nil(t, err)
+
+	_, err = LockRepository(dir.Path())
+	assert.Equal(t, err, &filesystems.LockHeldError{Pid: os.Getpid()})
+
+	// Read only commands do not need the lock
+	_, err = GetRepository(dir.Path())
+	assert.Nil(t, err)
+
+	assert.Nil(t, repository.Unlock())
+
+	repository, err = LockRepository(dir.Path())
+	assert.Nil(t, err)
+	assert.Nil(t, repository.Unlock())
+}
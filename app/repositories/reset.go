@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"time"
+)
+
+type ResetMode string
+
+const (
+	// Keep the differences staged in the index.
+	RESET_SOFT ResetMode = "soft"
+	// Keep the differences in the working directory only.
+	RESET_MIXED ResetMode = "mixed"
+	// Discard the differences, tracked files get the save content.
+	RESET_HARD ResetMode = "hard"
+)
+
+// Write the files of a file tree over the tracked working directory files, tracked files missing from the
+// file tree are removed. Untracked files are kept, unless the file tree has them.
+func (repository *Repository) resetWorkingFiles(dir *directories.Dir) error {
+	headDir, err := repository.fs.ReadDir(repository.getCurrentSaveName())
+	if err != nil {
+		return err
+	}
+
+	files := getDirFilesMap(dir)
+	paths := []string{}
+	for filepath := range getDirFilesMap(&headDir) {
+		paths = append(paths, filepath)
+	}
+	for _, change := range repository.index {
+		paths = append(paths, change.GetPath())
+	}
+	for filepath := range files {
+		paths = append(paths, filepath)
+	}
+
+	for _, filepath := range paths {
+		// Files already matching are left untouched
+		if file := files[filepath]; file != nil {
+			if objectName, err := hashWorkingFile(filepath); err == nil && objectName == file.ObjectName {
+				continue
+			}
+		}
+
+		if err := repository.writeWorkingFile(filepath, files[filepath]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reset moves the current ref to a save, e.g. to drop the last saves.
+//
+// A soft reset stages the differences between the save and the index, a mixed reset clears the index, so
+// the differences are only left in the working directory, and a hard reset discards them. The previous
+// save stays in the reflog, the returned entry records the movement.
+func (repository *Repository) Reset(revision string, mode ResetMode) (*filesystems.ReflogEntry, error) {
+	if repository.isDetachedMode() {
+		return nil, &ValidationError{"cannot make changes in detached mode."}
+	}
+	if repository.hasEmptySaveHistory() {
+		return nil, &ValidationError{"cannot reset without saves history."}
+	}
+	if mode != RESET_SOFT && mode != RESET_MIXED && mode != RESET_HARD {
+		return nil, &ValidationError{"invalid reset mode."}
+	}
+	if mode == RESET_SOFT && repository.isIndexConflicted() {
+		return nil, &ValidationError{"index is conflicted."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := repository.getCheckpoint(revision)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, &ValidationError{"invalid ref."}
+	}
+
+	dir, err := repository.buildDir(checkpoint.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case RESET_SOFT:
+		stagedDir, err := repository.getStagedDir()
+		if err != nil {
+			return nil, err
+		}
+
+		repository.index = diffDirs(dir, stagedDir)
+		if err := repository.fs.SaveIndex(repository.index); err != nil {
+			return nil, err
+		}
+	case RESET_MIXED:
+		if err := repository.clearIndex(); err != nil {
+			return nil, err
+		}
+	case RESET_HARD:
+		if err := repository.resetWorkingFiles(dir); err != nil {
+			return nil, err
+		}
+		if err := repository.clearIndex(); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := &filesystems.ReflogEntry{
+		OldId:     repository.getCurrentSaveName(),
+		NewId:     checkpoint.Id,
+		Command:   fmt.Sprintf("reset (%s): moving to %s", mode, revision),
+		CreatedAt: time.Now(),
+	}
+	if err := repository.setRef(repository.head, entry.NewId, entry.Command); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
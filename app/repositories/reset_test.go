@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+// Create a project with a save of 1.txt and a save modifying 1.txt and creating 2.txt.
+func fixtureResetProject(t *testing.T) (*fs.Dir, *Repository, []*filesystems.Checkpoint) {
+	dir, repository := fixtureGetNewProject(t)
+
+	saves := []*filesystems.Checkpoint{}
+	for idx, files := range [][]string{{"1.txt"}, {"1.txt", "2.txt"}} {
+		for _, file := range files {
+			fixtures.WriteFile(dir.Join(file), []byte(fmt.Sprintf("%s content (v%d).", file, idx)))
+			repository.IndexFile(file)
+		}
+		repository.SaveIndex()
+		save, _ := repository.CreateSave(fmt.Sprintf("s%d", idx))
+		saves = append(saves, save)
+		repository = fixtureGetRepository(t, dir.Path())
+	}
+
+	fixtures.WriteFile(dir.Join("3.txt"), []byte("3 content."))
+
+	return dir, repository, saves
+}
+
+func TestInvalidReset(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	_, err := repository.Reset("HEAD", RESET_MIXED)
+	assert.EqualError(t, err, "Validation Error: cannot reset without saves history.")
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, err = repository.Reset("HEAD", "undefined")
+	assert.EqualError(t, err, "Validation Error: invalid reset mode.")
+	_, err = repository.Reset("undefined", RESET_MIXED)
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+}
+
+func TestReset(t *testing.T) {
+	dir, repository, saves := fixtureResetProject(t)
+	defer dir.Remove()
+
+	// Check a soft reset stages the differences
+	entry, err := repository.Reset("HEAD~", RESET_SOFT)
+	assert.Nil(t, err)
+	assert.Equal(t, entry.OldId, saves[1].Id)
+	assert.Equal(t, entry.NewId, saves[0].Id)
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), saves[0].Id)
+	assert.Equal(t, len(repository.index), 2)
+	assert.Equal(t, repository.findStagedChange(dir.Join("1.txt")).ChangeType, directories.Modification)
+	assert.Equal(t, repository.findStagedChange(dir.Join("2.txt")).ChangeType, directories.Creation)
+
+	reflog, _ := repository.GetReflog(filesystems.INITIAL_REF_NAME)
+	assert.Equal(t, reflog.Entries[0].Command, "reset (soft): moving to HEAD~")
+
+	// Check a mixed reset clears the index and keeps the working directory
+	repository.Reset(saves[1].Id, RESET_MIXED)
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Reset("HEAD~", RESET_MIXED)
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Equal(t, repository.getCurrentSaveName(), saves[0].Id)
+	assert.Equal(t, len(repository.index), 0)
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1.txt content (v1).")
+
+	status, _ := repository.GetStatus()
+	assert.Equal(t, status.WorkingDir.ModifiedFilePaths, []string{dir.Join("1.txt")})
+
+	// Check a hard reset discards the differences and keeps the untracked files
+	repository.Reset(saves[1].Id, RESET_MIXED)
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Reset("HEAD~", RESET_HARD)
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Equal(t, repository.getCurrentSaveName(), saves[0].Id)
+	assert.Equal(t, len(repository.index), 0)
+
+	content, _ = os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1.txt content (v0).")
+	assert.NoFileExists(t, dir.Join("2.txt"))
+	assert.FileExists(t, dir.Join("3.txt"))
+
+	// Check the previous save can be reset back from the reflog
+	_, err = repository.Reset("HEAD@{1}", RESET_HARD)
+	assert.Nil(t, err)
+
+	content, _ = os.ReadFile(dir.Join("2.txt"))
+	assert.Equal(t, string(content), "2.txt content (v1).")
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/repositories/diffs"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+type ResolveSide string
+
+const (
+	// The ref side, merged into.
+	RESOLVE_OURS ResolveSide = "ours"
+	// The incoming side.
+	RESOLVE_THEIRS ResolveSide = "theirs"
+	// The merge base, as neither side changed the file.
+	RESOLVE_BASE ResolveSide = "base"
+	// Both sides lines, "ours" lines first on conflicting regions.
+	RESOLVE_UNION ResolveSide = "union"
+)
+
+// Read the base, ref and incoming files of the merge in progress.
+func (repository *Repository) readMergeSides(mergeState *filesystems.MergeState) (*mergeSides, error) {
+	saves := []*filesystems.Save{}
+
+	for _, saveName := range []string{mergeState.RefSave, mergeState.IncomingSave} {
+		save, err := repository.getSave(saveName)
+		if err != nil {
+			return nil, err
+		}
+		if save == nil {
+			return nil, &filesystems.MissingSaveError{Id: saveName}
+		}
+
+		saves = append(saves, save)
+	}
+
+	baseDir, err := repository.buildMergeBaseDir(saves[0], saves[1])
+	if err != nil {
+		return nil, err
+	}
+	refDir, err := repository.buildDir(saves[0].Id)
+	if err != nil {
+		return nil, err
+	}
+	incomingDir, err := repository.buildDir(saves[1].Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergeSides{
+		base:   getDirFilesMap(baseDir),
+		ours:   getDirFilesMap(refDir),
+		theirs: getDirFilesMap(incomingDir),
+	}, nil
+}
+
+// Resolve a file conflicted by the merge in progress with the content of one side.
+//
+// The file is written to the working directory and its conflict index entry is replaced, the file
+// is deleted and staged for removal when the chosen side does not have it. Temporary conflicted
+// objects are removed, since only the conflict index entry references them.
+func (repository *Repository) ResolveFile(filepath string, side ResolveSide) error {
+	mergeState, err := repository.fs.ReadMergeState()
+	if err != nil {
+		return err
+	}
+	if mergeState == nil {
+		return &ValidationError{"no merge in progress."}
+	}
+
+	filepath, err = repository.dir.AbsPath(filepath)
+	if err != nil {
+		return &ValidationError{err.Error()}
+	}
+
+	change := repository.findStagedChange(filepath)
+	if change == nil || change.ChangeType != directories.Conflict {
+		return &ValidationError{"path is not conflicted."}
+	}
+
+	sides, err := repository.readMergeSides(mergeState)
+	if err != nil {
+		return err
+	}
+
+	var file *directories.File
+
+	switch side {
+	case RESOLVE_OURS:
+		file = sides.ours[filepath]
+	case RESOLVE_THEIRS:
+		file = sides.theirs[filepath]
+	case RESOLVE_BASE:
+		file = sides.base[filepath]
+	case RESOLVE_UNION:
+		if sides.ours[filepath] == nil || sides.theirs[filepath] == nil {
+			return &ValidationError{"cannot union a removed file, use ours or theirs."}
+		}
+
+		contents, err := repository.readFilesContents(sides.base[filepath], sides.ours[filepath], sides.theirs[filepath])
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(contents, diffs.IsBinary) {
+			return &ValidationError{"cannot union a binary file, use ours or theirs."}
+		}
+
+		result := diffs.Merge3(
+			diffs.SplitLines(contents[0]),
+			diffs.SplitLines(contents[1]),
+			diffs.SplitLines(contents[2]),
+			&diffs.MergeOptions{Union: true},
+		)
+		if file, err = repository.fs.WriteObjectContent(filepath, []byte(result.String())); err != nil {
+			return err
+		}
+	default:
+		return &ValidationError{"invalid side."}
+	}
+
+	if err := repository.recordWorkingFiles(filepath); err != nil {
+		return err
+	}
+
+	if file != nil {
+		if err := repository.fs.CreateNode(&directories.Node{NodeType: directories.FileType, File: file}); err != nil {
+			return err
+		}
+
+		repository.stageFile(file)
+	} else {
+		if err := os.Remove(filepath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		repository.stageRemoval(filepath)
+	}
+
+	if err := repository.SaveIndex(); err != nil {
+		return err
+	}
+
+	if change.Conflict.IsObjectTemporary() && !slices.ContainsFunc(repository.index, func(stagedChange *directories.Change) bool {
+		return stagedChange.GetHash() == change.Conflict.ObjectName
+	}) {
+		return repository.fs.RemoveObject(change.Conflict.ObjectName)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+// Make a merge stopped by a content conflict on "a.txt" and a removal conflict on "b.txt".
+func makeConflictedMerge(t *testing.T) (*fs.Dir, *Repository) {
+	dir, repository := makeDivergedRepository(t)
+
+	repository.Merge("incoming", &MergeOptions{})
+
+	return dir, fixtureGetRepository(t, dir.Path())
+}
+
+func TestInvalidResolveFile(t *testing.T) {
+	dir, repository := makeConflictedMerge(t)
+	defer dir.Remove()
+
+	assert.Error(t, repository.ResolveFile("c.txt", RESOLVE_OURS), "Validation Error: path is not conflicted.")
+	assert.Error(t, repository.ResolveFile("a.txt", "mine"), "Validation Error: invalid side.")
+	assert.Error(t, repository.ResolveFile("b.txt", RESOLVE_UNION), "Validation Error: cannot union a removed file, use ours or theirs.")
+
+	repository.AbortMerge()
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Error(t, repository.ResolveFile("a.txt", RESOLVE_OURS), "Validation Error: no merge in progress.")
+}
+
+func TestResolveFile(t *testing.T) {
+	dir, repository := makeConflictedMerge(t)
+	defer dir.Remove()
+
+	conflict := repository.findStagedChange(dir.Join("a.txt")).Conflict
+	assert.True(t, conflict.IsObjectTemporary())
+
+	// Test
+
+	assert.Nil(t, repository.ResolveFile("a.txt", RESOLVE_THEIRS))
+	assert.Nil(t, repository.ResolveFile("b.txt", RESOLVE_THEIRS))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	content, _ := os.ReadFile(dir.Join("a.txt"))
+	assert.Equal(t, string(content), "1\ntwo incoming\n3\n")
+	assert.NoFileExists(t, dir.Join("b.txt"))
+	assert.NoFileExists(t, dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.OBJECTS_FOLDER_NAME, conflict.ObjectName))
+
+	assert.Equal(t, repository.findStagedChange(dir.Join("a.txt")).ChangeType, directories.Modification)
+	assert.Equal(t, repository.findStagedChange(dir.Join("b.txt")).ChangeType, directories.Removal)
+	assert.False(t, repository.isIndexConflicted())
+
+	// Check resolved files cannot be resolved again
+	assert.Error(t, repository.ResolveFile("a.txt", RESOLVE_OURS), "Validation Error: path is not conflicted.")
+
+	save, err := repository.ContinueMerge()
+	assert.Nil(t, err)
+	assert.Equal(t, len(save.Checkpoint().Parents), 2)
+}
+
+func TestResolveFileSides(t *testing.T) {
+	dir, repository := makeConflictedMerge(t)
+	defer dir.Remove()
+
+	// Check the ref side leaves nothing to save
+	assert.Nil(t, repository.ResolveFile("a.txt", RESOLVE_OURS))
+	assert.Nil(t, repository.ResolveFile("b.txt", RESOLVE_OURS))
+
+	repository = fixtureGetRepository(t, dir.Path())
+
+	content, _ := os.ReadFile(dir.Join("a.txt"))
+	assert.Equal(t, string(content), "1\ntwo ref\n3\n")
+	content, _ = os.ReadFile(dir.Join("b.txt"))
+	assert.Equal(t, string(content), "b.txt ref content.")
+	assert.Equal(t, len(repository.index), 0)
+
+	repository.AbortMerge()
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Merge("incoming", &MergeOptions{})
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Check the merge base content
+	assert.Nil(t, repository.ResolveFile("a.txt", RESOLVE_BASE))
+	assert.Nil(t, repository.ResolveFile("b.txt", RESOLVE_BASE))
+
+	content, _ = os.ReadFile(dir.Join("a.txt"))
+	assert.Equal(t, string(content), "1\n2\n3\n")
+	content, _ = os.ReadFile(dir.Join("b.txt"))
+	assert.Equal(t, string(content), "b.txt content.")
+
+	repository.AbortMerge()
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.Merge("incoming", &MergeOptions{})
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Check both sides lines are kept
+	assert.Nil(t, repository.ResolveFile("a.txt", RESOLVE_UNION))
+
+	content, _ = os.ReadFile(dir.Join("a.txt"))
+	assert.Equal(t, string(content), "1\ntwo ref\ntwo incoming\n3\n")
+	assert.Equal(t, repository.findStagedChange(dir.Join("a.txt")).ChangeType, directories.Modification)
+}
@@ -2,12 +2,12 @@ package repositories
 
 import (
 	"saymow/version-manager/app/pkg/collections"
-	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
 	"slices"
 )
 
-func (repository *Repository) getIndexDir() *directories.Node {
+func (repository *Repository) getIndexDir() (*directories.Node, error) {
 	dir := directories.Dir{
 		Path:     repository.fs.Root,
 		Children: make(map[string]*directories.Node),
@@ -16,13 +16,17 @@ func (repository *Repository) getIndexDir() *directories.Node {
 	for _, change := range repository.index {
 		if change.ChangeType != directories.Removal {
 			normalizedPath, err := dir.NormalizePath(change.GetPath())
-			errors.Check(err)
+			if err != nil {
+				return nil, &filesystems.CorruptIndexError{Err: err}
+			}
 
-			dir.AddNode(normalizedPath, change)
+			if err := dir.AddNode(normalizedPath, change); err != nil {
+				return nil, &filesystems.CorruptIndexError{Err: err}
+			}
 		}
 	}
 
-	return &directories.Node{NodeType: directories.DirType, Dir: &dir}
+	return &directories.Node{NodeType: directories.DirType, Dir: &dir}, nil
 }
 
 // Restore cover 2 usecases:
@@ -51,18 +55,35 @@ func (repository *Repository) Restore(ref string, path string) error {
 	var node *directories.Node
 
 	if repository.hasEmptySaveHistory() {
-		node = repository.getIndexDir().Dir.FindNode(resolvedPath)
-	} else {
-		save := repository.getSave(ref)
+		indexDir, err := repository.getIndexDir()
+		if err != nil {
+			return err
+		}
 
-		if save == nil {
+		node = indexDir.Dir.FindNode(resolvedPath)
+	} else {
+		checkpoint, err := repository.getCheckpoint(ref)
+		if err != nil {
+			return err
+		}
+		if checkpoint == nil {
 			return &ValidationError{"invalid ref."}
 		}
 
-		dir := buildDir(repository.fs.Root, save)
+		dir, err := repository.buildDir(checkpoint.Id)
+		if err != nil {
+			return err
+		}
 
 		if ref == "HEAD" {
-			dir.Merge(repository.getIndexDir().Dir)
+			indexDir, err := repository.getIndexDir()
+			if err != nil {
+				return err
+			}
+
+			if err := dir.Merge(indexDir.Dir); err != nil {
+				return err
+			}
 		}
 
 		node = dir.FindNode(resolvedPath)
@@ -72,8 +93,6 @@ func (repository *Repository) Restore(ref string, path string) error {
 		return &ValidationError{"invalid path."}
 	}
 
-	filesRemovedFromIndex := []*directories.File{}
-
 	if ref == "HEAD" {
 		// Should correctly cleanup applied  index changes
 
@@ -83,18 +102,6 @@ func (repository *Repository) Restore(ref string, path string) error {
 
 			if stagedChangeIdx != -1 {
 				// If defined, we are restoring the index change
-
-				switch {
-				// Should remove the object
-				case repository.index[stagedChangeIdx].ChangeType == directories.Creation || repository.index[stagedChangeIdx].ChangeType == directories.Modification:
-					filesRemovedFromIndex = append(filesRemovedFromIndex, repository.index[stagedChangeIdx].File)
-				case repository.index[stagedChangeIdx].ChangeType == directories.Conflict && repository.index[stagedChangeIdx].Conflict.IsObjectTemporary():
-					filesRemovedFromIndex = append(filesRemovedFromIndex, &directories.File{
-						Filepath:   repository.index[stagedChangeIdx].Conflict.Filepath,
-						ObjectName: repository.index[stagedChangeIdx].Conflict.ObjectName,
-					})
-				}
-
 				repository.index = slices.Delete(repository.index, stagedChangeIdx, stagedChangeIdx+1)
 			}
 		} else {
@@ -108,34 +115,19 @@ func (repository *Repository) Restore(ref string, path string) error {
 				}
 
 				// Otherwise, we are restoring the index change
-
-				switch {
-				// Should remove the object
-				case change.ChangeType == directories.Creation || change.ChangeType == directories.Modification:
-					filesRemovedFromIndex = append(filesRemovedFromIndex, change.File)
-				case change.ChangeType == directories.Conflict && change.Conflict.IsObjectTemporary():
-					filesRemovedFromIndex = append(filesRemovedFromIndex, &directories.File{
-						Filepath:   change.Conflict.Filepath,
-						ObjectName: change.Conflict.ObjectName,
-					})
-				}
-
 				return false
 			})
 		}
 	}
 
 	if node.NodeType == directories.DirType {
-		repository.applyDir(node.Dir)
-	} else {
-		repository.fs.CreateNode(node)
+		err = repository.applyDir(node.Dir)
+	} else if err = repository.recordWorkingFiles(node.File.Filepath); err == nil {
+		err = repository.fs.CreateNode(node)
 	}
-
-	for _, fileRemoved := range filesRemovedFromIndex {
-		repository.fs.RemoveObject(fileRemoved.ObjectName)
+	if err != nil {
+		return err
 	}
 
-	repository.SaveIndex()
-
-	return nil
+	return repository.SaveIndex()
 }
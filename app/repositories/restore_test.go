@@ -3,6 +3,7 @@ package repositories
 import (
 	"bytes"
 	"compress/gzip"
+	"os"
 	path "path/filepath"
 	"saymow/version-manager/app/pkg/errors"
 	"saymow/version-manager/app/pkg/fixtures"
@@ -41,7 +42,7 @@ func TestRestoreHeadSingleFile(t *testing.T) {
 			repository.SaveIndex()
 			repository.CreateSave("initial save")
 
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			fixtures.WriteFile(dir.Join("1.txt"), []byte("not the original content. Saved on the index"))
 			repository.IndexFile("1.txt")
 			repository.SaveIndex()
@@ -52,7 +53,7 @@ func TestRestoreHeadSingleFile(t *testing.T) {
 		// Test
 		{
 			// 1) Ensure index priority (and remove files from it)
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			assert.Equal(t, len(repository.index), 1)
 			assert.Equal(t, repository.index[0].File.Filepath, dir.Join("1.txt"))
 			repository.Restore("HEAD", "1.txt")
@@ -62,7 +63,7 @@ func TestRestoreHeadSingleFile(t *testing.T) {
 			assert.Equal(t, fixtures.ReadFile(dir.Join("1.txt")), "not the original content. Saved on the index")
 
 			// 1) When no index files, use history file
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			// should be indempontent now
 			repository.Restore("HEAD", "1.txt")
 			repository.Restore("HEAD", "1.txt")
@@ -85,7 +86,7 @@ func TestRestoreHeadSingleFile(t *testing.T) {
 
 		// Test
 		{
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			repository.RemoveFile("2.txt")
 
 			assert.Equal(t, len(repository.index), 1)
@@ -120,7 +121,7 @@ func TestRestoreHeadDir(t *testing.T) {
 
 			fixtures.WriteFile(dir.Join("a", "4.txt"), []byte("file 4 updated content."))
 
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			repository.Restore("HEAD", "a")
 		}
 
@@ -133,6 +134,7 @@ func TestRestoreHeadDir(t *testing.T) {
 					dir.Join("a"),
 					fs.Expected(
 						t,
+						fs.WithMode(0755|os.ModeDir),
 						fs.WithFile("4.txt", "file 4 original content."),
 						fs.WithDir("b",
 							fs.WithFile("6.txt", "file 6 original content."),
@@ -165,12 +167,12 @@ func TestRestoreHeadDir(t *testing.T) {
 			fixtures.MakeDirs(dir.Join("dir1"), dir.Join("dir1", "dir2"), dir.Join("dir1", "dir2", "dir3"))
 			fixtures.WriteFile(dir.Join("dir1", "dir2", "dir3", "10.txt"), []byte("file 10 original content."))
 
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			repository.IndexFile(path.Join("dir1", "dir2", "dir3", "10.txt"))
 			repository.RemoveFile(dir.Join("c", "8.txt"))
 			repository.SaveIndex()
 
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 			repository.Restore("HEAD", ".")
 		}
 
@@ -225,7 +227,7 @@ func TestRestoreHeadNoHistory(t *testing.T) {
 	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 updated content"))
 	fixtures.WriteFile(dir.Join("a", "4.txt"), []byte("4 updated content"))
 
-	repository = GetRepository(dir.Path())
+	repository = fixtureGetRepository(t, dir.Path())
 
 	repository.Restore("HEAD", ".")
 
@@ -312,6 +314,7 @@ func TestRestoreConflictedIndexHead(t *testing.T) {
 					fs.WithDir(
 						filesystems.OBJECTS_FOLDER_NAME,
 						fs.WithFile(permanentObjectName, string(gzipHelper([]byte("content a.")))),
+						fs.WithFile(tempObjectName, string(gzipHelper([]byte("content b.")))),
 					),
 					fs.MatchExtraFiles,
 				),
@@ -348,7 +351,7 @@ func TestRestoreHistoryUnsavedChangesRootDir(t *testing.T) {
 
 		// SAVE 1
 		{
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 
 			fixtures.WriteFile(dir.Join("1.txt"), []byte("file 1 (SAVE 0) (SAVE 1)."))
 			fixtures.WriteFile(dir.Join("2.txt"), []byte("file 2 (SAVE 0) (SAVE 1)."))
@@ -369,7 +372,7 @@ func TestRestoreHistoryUnsavedChangesRootDir(t *testing.T) {
 		// delete
 		fixtures.RemoveFile(dir.Join("c", "8.txt"))
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		repository.IndexFile(dir.Join("9.txt"))
 		repository.IndexFile(dir.Join("2.txt"))
@@ -379,10 +382,10 @@ func TestRestoreHistoryUnsavedChangesRootDir(t *testing.T) {
 
 	// Test Save
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Restore(save.Id, ".")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fsAssert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
 		// should keep index changes, since we are not restoring HEAD.
@@ -444,7 +447,7 @@ func TestRestoreHistoryUnsavedChangesSubdir(t *testing.T) {
 
 		// SAVE 1
 		{
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 
 			fixtures.WriteFile(dir.Join("1.txt"), []byte("file 1 (SAVE 0) (SAVE 1)."))
 			fixtures.WriteFile(dir.Join("2.txt"), []byte("file 2 (SAVE 0) (SAVE 1)."))
@@ -485,7 +488,7 @@ func TestRestoreHistoryUnsavedChangesSubdir(t *testing.T) {
 		}
 
 		// Apply index
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		repository.IndexFile(dir.Join("9.txt"))
 		repository.IndexFile(dir.Join("2.txt"))
@@ -500,11 +503,11 @@ func TestRestoreHistoryUnsavedChangesSubdir(t *testing.T) {
 
 	// Test Restore
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		repository.Restore(save0.Id, "a")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fsAssert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
 		fsAssert.Equal(t, len(repository.index), 6)
@@ -556,7 +559,7 @@ func TestRestoreHistoryFileUnsavedChanges(t *testing.T) {
 
 		// SAVE 1
 		{
-			repository = GetRepository(dir.Path())
+			repository = fixtureGetRepository(t, dir.Path())
 
 			fixtures.WriteFile(dir.Join("0.txt"), []byte("file 0 (SAVE 0) (SAVE 1)."))
 			fixtures.WriteFile(dir.Join("1.txt"), []byte("file 1 (SAVE 0) (SAVE 1)."))
@@ -579,7 +582,7 @@ func TestRestoreHistoryFileUnsavedChanges(t *testing.T) {
 		fixtures.RemoveFile(dir.Join("0.txt"))
 
 		// Apply index
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.IndexFile(dir.Join("3.txt"))
 		repository.IndexFile(dir.Join("2.txt"))
 		repository.RemoveFile(dir.Join("1.txt"))
@@ -588,15 +591,15 @@ func TestRestoreHistoryFileUnsavedChanges(t *testing.T) {
 
 	// Test Save
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fsAssert.Equal(t, len(repository.index), 3)
 
-		GetRepository(dir.Path()).Restore(save.Id, "0.txt")
-		GetRepository(dir.Path()).Restore(save.Id, "2.txt")
-		GetRepository(dir.Path()).Restore(save.Id, "1.txt")
+		fixtureGetRepository(t, dir.Path()).Restore(save.Id, "0.txt")
+		fixtureGetRepository(t, dir.Path()).Restore(save.Id, "2.txt")
+		fixtureGetRepository(t, dir.Path()).Restore(save.Id, "1.txt")
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fsAssert.Equal(t, len(repository.index), 3)
 		fsAssert.Equal(t, repository.index[0].File.Filepath, dir.Join("3.txt"))
@@ -651,7 +654,7 @@ func TestRestoreHistory(t *testing.T) {
 
 		// Save 1 Changes
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("2.txt"), []byte("file 2 (SAVE 0) (SAVE 1)."))
 
@@ -661,7 +664,7 @@ func TestRestoreHistory(t *testing.T) {
 
 		// Save 2 Changes
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("a", "4.txt"), []byte("file 4 (SAVE 0) (SAVE 2)."))
 
@@ -671,7 +674,7 @@ func TestRestoreHistory(t *testing.T) {
 
 		// Save 3 Changes
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("2.txt"), []byte("file 2 (SAVE 0) (SAVE 1) (SAVE 3)."))
 		fixtures.MakeDirs(dir.Join("dir1"), dir.Join("dir1", "dir2"), dir.Join("dir1", "dir2", "dir3"), dir.Join("dir1", "dir2", "dir3", "dir4"))
@@ -690,7 +693,7 @@ func TestRestoreHistory(t *testing.T) {
 
 		// Save 4 Changes
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		fixtures.WriteFile(dir.Join("1.txt"), []byte("file 1 (SAVE 0) (SAVE 4)."))
 		fixtures.WriteFile(dir.Join("2.txt"), []byte("file 2 (SAVE 0) (SAVE 1) (SAVE 3) (SAVE 4)."))
@@ -712,7 +715,7 @@ func TestRestoreHistory(t *testing.T) {
 
 		// Save 5 Changes
 
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 
 		repository.RemoveFile(dir.Join("1.txt"))
 		repository.RemoveFile(dir.Join("2.txt"))
@@ -723,7 +726,7 @@ func TestRestoreHistory(t *testing.T) {
 
 	// Test Save 3
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Restore(save3.Id, ".")
 
 		fsAssert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
@@ -769,7 +772,7 @@ func TestRestoreHistory(t *testing.T) {
 
 	// Test Save 0
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Restore(save0.Id, ".")
 
 		fsAssert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
@@ -799,7 +802,7 @@ func TestRestoreHistory(t *testing.T) {
 
 	// Test Save 5
 	{
-		repository = GetRepository(dir.Path())
+		repository = fixtureGetRepository(t, dir.Path())
 		repository.Restore(save5.Id, ".")
 
 		fsAssert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
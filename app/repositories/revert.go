@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"fmt"
+	"saymow/version-manager/app/pkg/collections"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+	"time"
+)
+
+// Three-way merge the changes from the base file tree to the incoming file tree into the HEAD file tree.
+//
+// The merged files are written to the working directory. On conflicts, the index is populated with the
+// changes and the conflicts, so they can be resolved and saved. The merged file tree is returned along
+// with its changes from the HEAD file tree and the conflicted changes.
+func (repository *Repository) applyChanges(baseDir, incomingDir *directories.Dir, incoming string) (*directories.Dir, []*directories.Change, []*directories.Change, error) {
+	headDir, err := repository.fs.ReadDir(repository.getCurrentSaveName())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mergedDir, conflictedChanges, err := repository.mergeDirs(baseDir, &headDir, incomingDir, "HEAD", incoming, &MergeOptions{})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	changes := diffDirs(&headDir, mergedDir)
+	mergedFiles := getDirFilesMap(mergedDir)
+
+	for _, change := range changes {
+		if err := repository.writeWorkingFile(change.GetPath(), mergedFiles[change.GetPath()]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if len(conflictedChanges) > 0 {
+		// Conflicts keeping the HEAD side content are not part of the changes, so they are added apart.
+		repository.index = collections.Filter(changes, func(change *directories.Change, _ int) bool {
+			return !slices.ContainsFunc(conflictedChanges, func(conflictedChange *directories.Change) bool {
+				return conflictedChange.GetPath() == change.GetPath()
+			})
+		})
+		repository.index = append(repository.index, conflictedChanges...)
+		if err := repository.SaveIndex(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return mergedDir, changes, conflictedChanges, nil
+}
+
+// Create a save on the current ref with a file tree and its changes from the HEAD file tree.
+func (repository *Repository) createAppliedSave(dir *directories.Dir, changes []*directories.Change, message, reason string) (*filesystems.Checkpoint, error) {
+	parents := []string{}
+	if parent := repository.getCurrentSaveName(); parent != "" {
+		parents = append(parents, parent)
+	}
+
+	return repository.writeAppliedSave(parents, dir, changes, message, reason)
+}
+
+// Write a save with a file tree and its changes from the first parent file tree, the current ref is moved to it.
+func (repository *Repository) writeAppliedSave(parents []string, dir *directories.Dir, changes []*directories.Change, message, reason string) (*filesystems.Checkpoint, error) {
+	tree, err := repository.fs.WriteTree(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := filesystems.Checkpoint{
+		Message:   message,
+		Parents:   parents,
+		Tree:      tree,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	if checkpoint.Id, err = repository.fs.WriteCheckpoint(&checkpoint); err != nil {
+		return nil, err
+	}
+	if err := repository.setRef(repository.head, checkpoint.Id, reason); err != nil {
+		return nil, err
+	}
+
+	// Later changes are relative to the new HEAD file tree
+	if repository.dir, err = repository.fs.ReadDir(checkpoint.Id); err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
+
+// Check whether the index or the working directory have unsaved changes, untracked files included.
+func (repository *Repository) checkUnsavedChanges() error {
+	if len(repository.index) > 0 {
+		return &ValidationError{"unsaved changes."}
+	}
+
+	status, err := repository.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	workingDirStatus := status.WorkingDir
+	if len(workingDirStatus.ModifiedFilePaths)+len(workingDirStatus.RemovedFilePaths)+len(workingDirStatus.UntrackedFilePaths) > 0 {
+		return &ValidationError{"unsaved changes."}
+	}
+
+	return nil
+}
+
+func getRevertMessage(checkpoint *filesystems.Checkpoint) string {
+	return fmt.Sprintf("Revert \"%s\".", checkpoint.Subject())
+}
+
+// Revert creates a save on the current ref undoing the changes a save made to its first parent.
+//
+// Files created by the save are removed, removed files are created again and modified files get their
+// previous content back. The inverse changes are three-way merged with HEAD, so the files saves made
+// since changed as well are merged. On conflicts, no save is created: the conflicts are written to the
+// working directory and the index, along with the other inverse changes, to be resolved and saved.
+//
+// The revert save is returned, or the conflicted changes.
+func (repository *Repository) Revert(revision string) (*filesystems.Checkpoint, []*directories.Change, error) {
+	if repository.isDetachedMode() {
+		return nil, nil, &ValidationError{"cannot make changes in detached mode."}
+	}
+	if repository.hasEmptySaveHistory() {
+		return nil, nil, &ValidationError{"invalid ref."}
+	}
+
+	if err := repository.checkMergeInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkCherryPickInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkRebaseInProgress(); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.checkUnsavedChanges(); err != nil {
+		return nil, nil, err
+	}
+
+	checkpoint, err := repository.getCheckpoint(revision)
+	if err != nil {
+		return nil, nil, err
+	}
+	if checkpoint == nil {
+		return nil, nil, &ValidationError{"invalid ref."}
+	}
+
+	saveDir, err := repository.fs.ReadDir(checkpoint.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentDir, err := repository.fs.ReadDir(checkpoint.FirstParent())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The save is the base, so the files it changed get their parent content back
+	mergedDir, changes, conflictedChanges, err := repository.applyChanges(&saveDir, &parentDir, fmt.Sprintf("parent of %s", revision))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(conflictedChanges) > 0 {
+		return nil, conflictedChanges, nil
+	}
+	if len(changes) == 0 {
+		return nil, nil, &ValidationError{"nothing to revert, HEAD does not have the save changes."}
+	}
+
+	revertCheckpoint, err := repository.createAppliedSave(mergedDir, changes, getRevertMessage(checkpoint), fmt.Sprintf("revert: %s", getRevertMessage(checkpoint)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return revertCheckpoint, nil, nil
+}
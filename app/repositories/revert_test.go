@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidRevert(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	_, _, err := repository.Revert("HEAD")
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	_, _, err = repository.Revert("undefined")
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 unsaved content."))
+
+	_, _, err = repository.Revert("HEAD")
+	assert.EqualError(t, err, "Validation Error: unsaved changes.")
+}
+
+func TestRevert(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1\nline 2\nline 3\n"))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (s1)\nline 2\nline 3\n"))
+	fixtures.RemoveFile(dir.Join("2.txt"))
+	fixtures.MakeDirs(dir.Join("a"))
+	fixtures.WriteFile(dir.Join("a", "3.txt"), []byte("3 content."))
+	repository.IndexFiles([]string{"."}, &IndexOptions{All: true})
+	repository.SaveIndex()
+	save1, _ := repository.CreateSave("s1")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Later saves changing other lines of the same files are merged
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (s1)\nline 2\nline 3 (s2)\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save2, _ := repository.CreateSave("s2")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	checkpoint, conflicts, err := repository.Revert(save1.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	assert.Equal(t, checkpoint.Message, "Revert \"s1\".")
+	assert.Equal(t, checkpoint.Parents, []string{save2.Id})
+	assert.Equal(t, len(checkpoint.Changes), 3)
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "line 1\nline 2\nline 3 (s2)\n")
+	content, _ = os.ReadFile(dir.Join("2.txt"))
+	assert.Equal(t, string(content), "2 content.")
+	assert.NoDirExists(t, dir.Join("a"))
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), checkpoint.Id)
+
+	status, _ := repository.GetStatus()
+	assert.False(t, status.HasChanges())
+
+	reflog, _ := repository.GetReflog("HEAD")
+	assert.Equal(t, reflog.Entries[0].Command, "revert: Revert \"s1\".")
+
+	// Check reverted saves cannot be reverted again
+	_, _, err = repository.Revert(save1.Id)
+	assert.EqualError(t, err, "Validation Error: nothing to revert, HEAD does not have the save changes.")
+}
+
+func TestRevertConflict(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1\n"))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	repository.CreateSave("s0")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (s1)\n"))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content (s1)."))
+	repository.IndexFile("1.txt")
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+	save1, _ := repository.CreateSave("s1")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("line 1 (s2)\n"))
+	repository.IndexFile("1.txt")
+	repository.SaveIndex()
+	save2, _ := repository.CreateSave("s2")
+	repository = fixtureGetRepository(t, dir.Path())
+
+	// Test
+	checkpoint, conflicts, err := repository.Revert(save1.Id[:8])
+	assert.Nil(t, err)
+	assert.Nil(t, checkpoint)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].GetPath(), dir.Join("1.txt"))
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "<<<<<<< HEAD\nline 1 (s2)\n||||||| base\nline 1 (s1)\n=======\nline 1\n>>>>>>> parent of "+save1.Id[:8]+"\n")
+	content, _ = os.ReadFile(dir.Join("2.txt"))
+	assert.Equal(t, string(content), "2 content.")
+
+	// Check the ref is kept and the index holds the inverse changes
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.getCurrentSaveName(), save2.Id)
+	assert.Equal(t, len(repository.index), 2)
+	assert.Equal(t, repository.findStagedChange(dir.Join("1.txt")).ChangeType, directories.Conflict)
+	assert.Equal(t, repository.findStagedChange(dir.Join("2.txt")).ChangeType, directories.Modification)
+}
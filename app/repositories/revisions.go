@@ -0,0 +1,316 @@
+package repositories
+
+import (
+	"fmt"
+	"regexp"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shorter save hash prefixes are not resolved, they are too likely to be ambiguous.
+const MIN_HASH_PREFIX_LENGTH = 4
+
+const REVISION_RANGE_SEPARATOR = ".."
+
+var revisionSuffixRegex = regexp.MustCompile(`^([~^])(\d*)`)
+
+var reflogIndexRegex = regexp.MustCompile(`^\d+$`)
+
+var relativeDateRegex = regexp.MustCompile(`^(\d+)[. ](second|minute|hour|day|week|month|year)s?[. ]ago$`)
+
+// Split a revision range "from..to" into its sides, an omitted side is HEAD.
+func parseRevisionRange(revision string) (string, string, bool) {
+	from, to, found := strings.Cut(revision, REVISION_RANGE_SEPARATOR)
+	if !found {
+		return "", "", false
+	}
+
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	return from, to, true
+}
+
+// Parse the date of a "@{date}" revision suffix.
+//
+// Besides "now" and "yesterday", relative dates ("3 days ago" or "3.days.ago") and absolute dates
+// ("2026-10-01", "2026-10-01 15:04", "2026-10-01 15:04:05" or RFC 3339) are supported. Absolute dates
+// are in the local time zone, a date without a time is the end of that day.
+func parseRevisionDate(value string, now time.Time) (time.Time, error) {
+	switch value {
+	case "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	if match := relativeDateRegex.FindStringSubmatch(value); match != nil {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		switch match[2] {
+		case "second":
+			return now.Add(-time.Duration(amount) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(amount) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(amount) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -amount), nil
+		case "week":
+			return now.AddDate(0, 0, -7*amount), nil
+		case "month":
+			return now.AddDate(0, -amount, 0), nil
+		default:
+			return now.AddDate(-amount, 0, 0), nil
+		}
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04", time.DateTime, time.RFC3339} {
+		if date, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, &ValidationError{fmt.Sprintf("invalid date \"%s\".", value)}
+}
+
+// Resolve HEAD, a ref name, "stash", a save hash or a unique save hash prefix to a save name, "" when there is no such save.
+func (repository *Repository) resolveRevisionBase(base string) (string, error) {
+	if base == "HEAD" {
+		return repository.getCurrentSaveName(), nil
+	}
+	if saveName, ok := (*repository.refs)[base]; ok {
+		return saveName, nil
+	}
+	if base == STASH_REVISION {
+		return repository.findStashSave(0)
+	}
+	if len(base) < MIN_HASH_PREFIX_LENGTH {
+		return "", nil
+	}
+
+	ids, err := repository.fs.ListCheckpoints()
+	if err != nil {
+		return "", err
+	}
+
+	matches := []string{}
+	for _, id := range ids {
+		if id == base {
+			return id, nil
+		}
+		if strings.HasPrefix(id, base) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	default:
+		slices.Sort(matches)
+
+		return "", &ValidationError{fmt.Sprintf("revision \"%s\" is ambiguous, it matches saves %s.", base, strings.Join(matches, ", "))}
+	}
+}
+
+// Find the latest save of the first parent history created at or before the date.
+func (repository *Repository) findSaveAtDate(saveName string, date time.Time) (string, error) {
+	for saveName != "" {
+		checkpoint, err := repository.fs.ReadCheckpoint(saveName)
+		if err != nil {
+			return "", err
+		}
+
+		if !checkpoint.CreatedAt.After(date) {
+			return saveName, nil
+		}
+
+		saveName = checkpoint.FirstParent()
+	}
+
+	return "", nil
+}
+
+// Name of the reflog recording a revision base movements, "" when it is not HEAD or a ref.
+func (repository *Repository) getRevisionReflogName(base string) string {
+	if base == "HEAD" {
+		return filesystems.HEAD_REFLOG_NAME
+	}
+	if _, ok := (*repository.refs)[base]; ok {
+		return base
+	}
+
+	return ""
+}
+
+// Find the save a reflog pointed to, index movements ago.
+func (repository *Repository) findReflogSave(reflogName string, index int) (string, error) {
+	if reflogName == "" {
+		return "", nil
+	}
+
+	entries, err := repository.fs.ReadReflog(reflogName)
+	if err != nil {
+		return "", err
+	}
+	if index >= len(entries) {
+		return "", nil
+	}
+
+	return entries[len(entries)-1-index].NewId, nil
+}
+
+// Find the save of the Nth stash, "" when there are not that many stashes.
+func (repository *Repository) findStashSave(index int) (string, error) {
+	ids, err := repository.fs.ReadStashes()
+	if err != nil {
+		return "", err
+	}
+	if index >= len(ids) {
+		return "", nil
+	}
+
+	return ids[index], nil
+}
+
+// Find the save a reflog pointed to at the date, the first parent history is used when the reflog does not go back that far.
+func (repository *Repository) findReflogSaveAtDate(reflogName, saveName string, date time.Time) (string, error) {
+	if reflogName != "" {
+		entries, err := repository.fs.ReadReflog(reflogName)
+		if err != nil {
+			return "", err
+		}
+
+		for idx := len(entries) - 1; idx >= 0; idx-- {
+			if !entries[idx].CreatedAt.After(date) {
+				return entries[idx].NewId, nil
+			}
+		}
+	}
+
+	return repository.findSaveAtDate(saveName, date)
+}
+
+// Resolve a revision expression to a save name, "" when the revision does not point to a save.
+//
+// A revision is a base followed by suffixes:
+//
+//   - The base is HEAD, a ref name, "stash" (the newest stash), a save hash or a unique save hash prefix of
+//     at least 4 characters.
+//   - "@{N}", right after HEAD or a ref name, is the save it pointed to N movements ago according to its
+//     reflog, e.g. "HEAD@{1}" or "master@{3}". Right after "stash", it is the Nth stash, e.g. "stash@{1}".
+//   - "@{date}", right after the base, is the save it pointed to at date according to its reflog, or the
+//     latest save of its first parent history created at or before date, e.g. "master@{yesterday}".
+//   - "~N" is the Nth first parent ancestor, "~" is "~1", e.g. "HEAD~3".
+//   - "^N" is the Nth parent, "^" is "^1", e.g. "master^" or "HEAD^2" for the merged save of a merge save.
+func (repository *Repository) resolveRevision(revision string) (string, error) {
+	baseEnd := strings.IndexAny(revision, "~^@")
+	if baseEnd == -1 {
+		baseEnd = len(revision)
+	}
+	if baseEnd == 0 {
+		return "", &ValidationError{fmt.Sprintf("invalid revision \"%s\".", revision)}
+	}
+
+	base := revision[:baseEnd]
+	saveName, err := repository.resolveRevisionBase(base)
+	if err != nil {
+		return "", err
+	}
+
+	suffixes := revision[baseEnd:]
+
+	if strings.HasPrefix(suffixes, "@{") {
+		valueEnd := strings.Index(suffixes, "}")
+		if valueEnd == -1 {
+			return "", &ValidationError{fmt.Sprintf("invalid revision \"%s\".", revision)}
+		}
+
+		value := suffixes[2:valueEnd]
+		reflogName := repository.getRevisionReflogName(base)
+
+		if reflogIndexRegex.MatchString(value) {
+			index, err := strconv.Atoi(value)
+			if err != nil {
+				return "", &ValidationError{fmt.Sprintf("invalid revision \"%s\".", revision)}
+			}
+
+			if base == STASH_REVISION && reflogName == "" {
+				saveName, err = repository.findStashSave(index)
+			} else {
+				saveName, err = repository.findReflogSave(reflogName, index)
+			}
+			if err != nil {
+				return "", err
+			}
+		} else {
+			date, err := parseRevisionDate(value, time.Now())
+			if err != nil {
+				return "", err
+			}
+
+			if saveName, err = repository.findReflogSaveAtDate(reflogName, saveName, date); err != nil {
+				return "", err
+			}
+		}
+
+		suffixes = suffixes[valueEnd+1:]
+	}
+
+	for suffixes != "" {
+		match := revisionSuffixRegex.FindStringSubmatch(suffixes)
+		if match == nil {
+			return "", &ValidationError{fmt.Sprintf("invalid revision \"%s\".", revision)}
+		}
+
+		suffixes = suffixes[len(match[0]):]
+
+		count := 1
+		if match[2] != "" {
+			if count, err = strconv.Atoi(match[2]); err != nil {
+				return "", &ValidationError{fmt.Sprintf("invalid revision \"%s\".", revision)}
+			}
+		}
+
+		// "~N" takes N first parent steps, "^N" takes a single step to the Nth parent.
+		steps := count
+		if match[1] == "^" {
+			steps = min(count, 1)
+		}
+
+		for idx := 0; idx < steps && saveName != ""; idx++ {
+			checkpoint, err := repository.fs.ReadCheckpoint(saveName)
+			if err != nil {
+				return "", err
+			}
+
+			switch {
+			case match[1] == "~":
+				saveName = checkpoint.FirstParent()
+			case count <= len(checkpoint.Parents):
+				saveName = checkpoint.Parents[count-1]
+			default:
+				saveName = ""
+			}
+		}
+	}
+
+	return saveName, nil
+}
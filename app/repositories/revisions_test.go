@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"os"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRevisionRange(t *testing.T) {
+	from, to, isRange := parseRevisionRange("master..feature")
+	assert.True(t, isRange)
+	assert.Equal(t, from, "master")
+	assert.Equal(t, to, "feature")
+
+	from, to, isRange = parseRevisionRange("..feature")
+	assert.True(t, isRange)
+	assert.Equal(t, from, "HEAD")
+	assert.Equal(t, to, "feature")
+
+	_, _, isRange = parseRevisionRange("master~1")
+	assert.False(t, isRange)
+}
+
+func TestParseRevisionDate(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.Local)
+
+	for value, expected := range map[string]time.Time{
+		"now":                 now,
+		"yesterday":           time.Date(2026, 10, 16, 15, 30, 0, 0, time.Local),
+		"2 hours ago":         time.Date(2026, 10, 17, 13, 30, 0, 0, time.Local),
+		"3.days.ago":          time.Date(2026, 10, 14, 15, 30, 0, 0, time.Local),
+		"1 week ago":          time.Date(2026, 10, 10, 15, 30, 0, 0, time.Local),
+		"2026-10-01":          time.Date(2026, 10, 1, 23, 59, 59, 999999999, time.Local),
+		"2026-10-01 08:15":    time.Date(2026, 10, 1, 8, 15, 0, 0, time.Local),
+		"2026-10-01 08:15:30": time.Date(2026, 10, 1, 8, 15, 30, 0, time.Local),
+	} {
+		date, err := parseRevisionDate(value, now)
+		assert.Nil(t, err)
+		assert.True(t, date.Equal(expected), value)
+	}
+
+	_, err := parseRevisionDate("someday", now)
+	assert.EqualError(t, err, "Validation Error: invalid date \"someday\".")
+}
+
+func TestResolveRevision(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup: s0 <- s1 <- merge, s0 <- feature <- merge
+
+	writeCheckpoint := func(message string, day int, parents ...string) string {
+		id, _ := repository.fs.WriteCheckpoint(&filesystems.Checkpoint{
+			Message:   message,
+			Parents:   parents,
+			CreatedAt: time.Date(2026, 10, day, 12, 0, 0, 0, time.Local),
+			Changes:   []*directories.Change{},
+		})
+
+		return id
+	}
+
+	s0 := writeCheckpoint("s0", 1)
+	s1 := writeCheckpoint("s1", 2, s0)
+	feature := writeCheckpoint("feature", 3, s0)
+	merge := writeCheckpoint("merge", 4, s1, feature)
+
+	repository.setRef(filesystems.INITIAL_REF_NAME, merge, "test")
+	repository.setRef("feature", feature, "test")
+
+	// Test
+
+	for revision, expected := range map[string]string{
+		"HEAD":                           merge,
+		"master":                         merge,
+		merge:                            merge,
+		feature[:8]:                      feature,
+		"HEAD~":                          s1,
+		"HEAD~1":                         s1,
+		"HEAD~2":                         s0,
+		"HEAD~3":                         "",
+		"master^":                        s1,
+		"master^2":                       feature,
+		"master^0":                       merge,
+		"master^3":                       "",
+		"HEAD^2~1":                       s0,
+		"HEAD^^":                         s0,
+		feature[:8] + "~1":               s0,
+		"master@{now}":                   merge,
+		"master@{2026-10-03}":            s1,
+		"master@{2026-10-02 12:00}~1":    s0,
+		"master@{2026-10-01 11:00}":      "",
+		"undefined":                      "",
+		"undefined~2":                    "",
+		merge[:MIN_HASH_PREFIX_LENGTH-1]: "",
+	} {
+		saveName, err := repository.resolveRevision(revision)
+		assert.Nil(t, err, revision)
+		assert.Equal(t, saveName, expected, revision)
+	}
+
+	for revision, expected := range map[string]string{
+		"~1":             "Validation Error: invalid revision \"~1\".",
+		"HEAD~x":         "Validation Error: invalid revision \"HEAD~x\".",
+		"HEAD@{now":      "Validation Error: invalid revision \"HEAD@{now\".",
+		"HEAD@{someday}": "Validation Error: invalid date \"someday\".",
+		"HEAD~1@{now}":   "Validation Error: invalid revision \"HEAD~1@{now}\".",
+	} {
+		_, err := repository.resolveRevision(revision)
+		assert.EqualError(t, err, expected, revision)
+	}
+
+	// Check reflog revisions
+	repository.setRef(filesystems.INITIAL_REF_NAME, s1, "test")
+
+	for revision, expected := range map[string]string{
+		"master@{0}":    s1,
+		"master@{1}":    merge,
+		"master@{1}^2":  feature,
+		"master@{2}":    "",
+		"HEAD@{1}":      merge,
+		"feature@{0}":   feature,
+		"master@{now}":  s1,
+		s0[:8] + "@{0}": "",
+	} {
+		saveName, err := repository.resolveRevision(revision)
+		assert.Nil(t, err, revision)
+		assert.Equal(t, saveName, expected, revision)
+	}
+
+	// Check ambiguous prefixes
+	savesPath := dir.Join(filesystems.REPOSITORY_FOLDER_NAME, filesystems.SAVES_FOLDER_NAME)
+	first, second := "abcd0"+strings.Repeat("0", 59), "abcd1"+strings.Repeat("0", 59)
+
+	os.WriteFile(Path.Join(savesPath, first), []byte{}, 0644)
+	os.WriteFile(Path.Join(savesPath, second), []byte{}, 0644)
+
+	_, err := repository.resolveRevision("abcd~1")
+	assert.EqualError(t, err, "Validation Error: revision \"abcd\" is ambiguous, it matches saves "+first+", "+second+".")
+
+	_, err = repository.getSave("abcd")
+	assert.EqualError(t, err, "Validation Error: revision \"abcd\" is ambiguous, it matches saves "+first+", "+second+".")
+	_, err = repository.getCheckpoint("abcd")
+	assert.EqualError(t, err, "Validation Error: revision \"abcd\" is ambiguous, it matches saves "+first+", "+second+".")
+}
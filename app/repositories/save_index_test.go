@@ -57,12 +57,12 @@ func TestSaveIndex(t *testing.T) {
 			received,
 			fmt.Sprintf(
 				expected,
-				dir.Join("1.txt"),
-				dir.Join("a", "b", "6.txt"),
-				dir.Join("a", "b", "5.txt"),
-				dir.Join("a", "b", "7.txt"),
-				dir.Join("a", "b", "c", "8.txt"),
-				dir.Join("a", "b", "c", "9.txt"),
+				"1.txt",
+				"a/b/6.txt",
+				"a/b/5.txt",
+				"a/b/7.txt",
+				"a/b/c/8.txt",
+				"a/b/c/9.txt",
 			),
 		)
 
@@ -87,9 +87,9 @@ func TestSaveIndex(t *testing.T) {
 				received,
 				fmt.Sprintf(
 					expected,
-					dir.Join("1.txt"),
-					dir.Join("a", "b", "5.txt"),
-					dir.Join("a", "b", "c", "8.txt"),
+					"1.txt",
+					"a/b/5.txt",
+					"a/b/c/8.txt",
 				),
 			)
 		}
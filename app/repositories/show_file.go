@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"io"
+	"saymow/version-manager/app/repositories/directories"
+)
+
+type ShowOptions struct {
+	// Ref name, Save hash or HEAD the file is read from.
+	Ref string
+	// Side of the merge in progress the file is read from, instead of Ref. Union is not a stored side.
+	Side ResolveSide
+}
+
+// Open a file content stored in a save, or in a side of the merge in progress.
+//
+// Both sides of a conflicted merge are stored, so the side left out of the working directory, e.g. for
+// binary conflicts, can be retrieved.
+func (repository *Repository) ShowFile(path string, options *ShowOptions) (io.ReadCloser, error) {
+	filepath, err := repository.dir.AbsPath(path)
+	if err != nil {
+		return nil, &ValidationError{err.Error()}
+	}
+
+	var file *directories.File
+
+	if options.Side != "" {
+		mergeState, err := repository.fs.ReadMergeState()
+		if err != nil {
+			return nil, err
+		}
+		if mergeState == nil {
+			return nil, &ValidationError{"no merge in progress."}
+		}
+
+		sides, err := repository.readMergeSides(mergeState)
+		if err != nil {
+			return nil, err
+		}
+
+		switch options.Side {
+		case RESOLVE_OURS:
+			file = sides.ours[filepath]
+		case RESOLVE_THEIRS:
+			file = sides.theirs[filepath]
+		case RESOLVE_BASE:
+			file = sides.base[filepath]
+		default:
+			return nil, &ValidationError{"invalid side."}
+		}
+	} else {
+		dir, err := repository.getRevisionDir(options.Ref)
+		if err != nil {
+			return nil, err
+		}
+
+		file = getDirFilesMap(dir)[filepath]
+	}
+
+	if file == nil {
+		return nil, &ValidationError{"invalid path."}
+	}
+
+	return repository.fs.OpenObject(file.ObjectName)
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"io"
+	"saymow/version-manager/app/pkg/fixtures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readShownFile(repository *Repository, path string, options *ShowOptions) (string, error) {
+	reader, err := repository.ShowFile(path, options)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+
+	return string(content), err
+}
+
+func TestShowFile(t *testing.T) {
+	dir, repository := makeConflictedMerge(t)
+	defer dir.Remove()
+
+	// Saves
+	content, err := readShownFile(repository, "a.txt", &ShowOptions{Ref: "HEAD"})
+	assert.Nil(t, err)
+	assert.Equal(t, content, "1\ntwo ref\n3\n")
+
+	content, err = readShownFile(repository, "a.txt", &ShowOptions{Ref: "incoming"})
+	assert.Nil(t, err)
+	assert.Equal(t, content, "1\ntwo incoming\n3\n")
+
+	_, err = readShownFile(repository, "b.txt", &ShowOptions{Ref: "incoming"})
+	assert.EqualError(t, err, "Validation Error: invalid path.")
+
+	_, err = readShownFile(repository, "a.txt", &ShowOptions{Ref: "undefined"})
+	assert.EqualError(t, err, "Validation Error: invalid ref.")
+
+	// Merge sides
+	content, err = readShownFile(repository, dir.Join("a.txt"), &ShowOptions{Side: RESOLVE_OURS})
+	assert.Nil(t, err)
+	assert.Equal(t, content, "1\ntwo ref\n3\n")
+
+	content, err = readShownFile(repository, "a.txt", &ShowOptions{Side: RESOLVE_THEIRS})
+	assert.Nil(t, err)
+	assert.Equal(t, content, "1\ntwo incoming\n3\n")
+
+	content, err = readShownFile(repository, "a.txt", &ShowOptions{Side: RESOLVE_BASE})
+	assert.Nil(t, err)
+	assert.Equal(t, content, "1\n2\n3\n")
+
+	_, err = readShownFile(repository, "a.txt", &ShowOptions{Side: RESOLVE_UNION})
+	assert.EqualError(t, err, "Validation Error: invalid side.")
+
+	repository.AbortMerge()
+	repository = fixtureGetRepository(t, dir.Path())
+
+	_, err = readShownFile(repository, "a.txt", &ShowOptions{Side: RESOLVE_THEIRS})
+	assert.EqualError(t, err, "Validation Error: no merge in progress.")
+
+	// Check files that are not saved
+	fixtures.WriteFile(dir.Join("c.txt"), []byte("c.txt content."))
+
+	_, err = readShownFile(repository, "c.txt", &ShowOptions{Ref: "HEAD"})
+	assert.EqualError(t, err, "Validation Error: invalid path.")
+}
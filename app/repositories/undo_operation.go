@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	Path "path/filepath"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"slices"
+)
+
+func getSnapshotSaveName(snapshot *filesystems.Snapshot) string {
+	if saveName, ok := (*snapshot.Refs)[snapshot.Head]; ok {
+		return saveName
+	}
+
+	return snapshot.Head
+}
+
+// Remove a working directory file and its parent directories left empty.
+func (repository *Repository) removeWorkingFile(filepath string) error {
+	if err := os.Remove(filepath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for dirPath := Path.Dir(filepath); dirPath != repository.fs.Root; dirPath = Path.Dir(dirPath) {
+		if os.Remove(dirPath) != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Write a file to the working directory, creating its parent directories. A nil file removes the file instead.
+func (repository *Repository) writeWorkingFile(filepath string, file *directories.File) error {
+	if err := repository.recordWorkingFiles(filepath); err != nil {
+		return err
+	}
+
+	if file == nil {
+		return repository.removeWorkingFile(filepath)
+	}
+
+	if err := os.MkdirAll(Path.Dir(filepath), filesystems.USER_FILES_PERMISSIONS); err != nil {
+		return err
+	}
+
+	return repository.fs.CreateNode(&directories.Node{NodeType: directories.FileType, File: file})
+}
+
+// Move the repository from the snapshot taken on one side of an operation to the other side.
+//
+// The repository must still be as the "from" snapshot left it. Only the working directory files
+// the operation changed are written, so other working directory changes are kept, unless they
+// touch the same files.
+func (repository *Repository) moveToSnapshot(operation *filesystems.Operation, fromSide, toSide filesystems.SnapshotSide, reason string) error {
+	from, err := repository.fs.ReadSnapshot(operation.Id, fromSide)
+	if err != nil {
+		return err
+	}
+	to, err := repository.fs.ReadSnapshot(operation.Id, toSide)
+	if err != nil {
+		return err
+	}
+	current, err := repository.takeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	if !current.SameState(from) {
+		return &ValidationError{fmt.Sprintf("the repository changed since \"%s\".", operation.Command)}
+	}
+
+	fromObjectNames := make(map[string]string)
+	for _, change := range from.WorkingFiles {
+		fromObjectNames[change.GetPath()] = change.GetHash()
+	}
+
+	for _, change := range to.WorkingFiles {
+		objectName, err := hashWorkingFile(change.GetPath())
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if objectName != fromObjectNames[change.GetPath()] {
+			path, err := Path.Rel(repository.fs.Root, change.GetPath())
+			if err != nil {
+				return err
+			}
+
+			return &ValidationError{fmt.Sprintf("\"%s\" changed since \"%s\".", Path.ToSlash(path), operation.Command)}
+		}
+	}
+
+	for _, change := range to.WorkingFiles {
+		if err := repository.writeWorkingFile(change.GetPath(), change.File); err != nil {
+			return err
+		}
+	}
+
+	if err := repository.fs.SaveIndex(to.Index); err != nil {
+		return err
+	}
+	if to.MergeState != nil {
+		if err := repository.fs.WriteMergeState(to.MergeState); err != nil {
+			return err
+		}
+	} else if err := repository.fs.RemoveMergeState(); err != nil {
+		return err
+	}
+	if to.CherryPickState != nil {
+		if err := repository.fs.WriteCherryPickState(to.CherryPickState); err != nil {
+			return err
+		}
+	} else if err := repository.fs.RemoveCherryPickState(); err != nil {
+		return err
+	}
+	if to.RebaseState != nil {
+		if err := repository.fs.WriteRebaseState(to.RebaseState); err != nil {
+			return err
+		}
+	} else if err := repository.fs.RemoveRebaseState(); err != nil {
+		return err
+	}
+	if err := repository.fs.WriteStashes(to.Stashes); err != nil {
+		return err
+	}
+	if err := repository.fs.WriteRefs(to.Refs); err != nil {
+		return err
+	}
+	if err := repository.fs.WriteHead(to.Head); err != nil {
+		return err
+	}
+
+	refNames := []string{}
+	for _, refs := range []*filesystems.Refs{from.Refs, to.Refs} {
+		for name := range *refs {
+			if (*from.Refs)[name] != (*to.Refs)[name] && !slices.Contains(refNames, name) {
+				refNames = append(refNames, name)
+			}
+		}
+	}
+	slices.Sort(refNames)
+
+	for _, name := range refNames {
+		if err := repository.appendReflog(name, (*from.Refs)[name], (*to.Refs)[name], reason); err != nil {
+			return err
+		}
+	}
+
+	if fromSaveName, toSaveName := getSnapshotSaveName(from), getSnapshotSaveName(to); fromSaveName != toSaveName || from.Head != to.Head {
+		return repository.appendReflog(filesystems.HEAD_REFLOG_NAME, fromSaveName, toSaveName, reason)
+	}
+
+	return nil
+}
+
+// UndoOperation reverts the last operation not undone, restoring HEAD, the refs, the index, the merge,
+// cherry-pick or rebase in progress, the stashes and the working directory files as they were before it.
+func (repository *Repository) UndoOperation() (*filesystems.Operation, error) {
+	operations, err := repository.fs.ReadOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := slices.IndexFunc(operations, func(operation *filesystems.Operation) bool {
+		return operation.Undone
+	})
+	if idx == -1 {
+		idx = len(operations)
+	}
+	if idx == 0 {
+		return nil, &ValidationError{"nothing to undo."}
+	}
+
+	operation := operations[idx-1]
+
+	if err := repository.moveToSnapshot(operation, filesystems.SNAPSHOT_AFTER, filesystems.SNAPSHOT_BEFORE, fmt.Sprintf("undo: %s", operation.Command)); err != nil {
+		return nil, err
+	}
+
+	operation.Undone = true
+
+	return operation, repository.fs.WriteOperations(operations)
+}
+
+// RedoOperation applies again the last undone operation.
+func (repository *Repository) RedoOperation() (*filesystems.Operation, error) {
+	operations, err := repository.fs.ReadOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := slices.IndexFunc(operations, func(operation *filesystems.Operation) bool {
+		return operation.Undone
+	})
+	if idx == -1 {
+		return nil, &ValidationError{"nothing to redo."}
+	}
+
+	operation := operations[idx]
+
+	if err := repository.moveToSnapshot(operation, filesystems.SNAPSHOT_BEFORE, filesystems.SNAPSHOT_AFTER, fmt.Sprintf("redo: %s", operation.Command)); err != nil {
+		return nil, err
+	}
+
+	operation.Undone = false
+
+	return operation, repository.fs.WriteOperations(operations)
+}
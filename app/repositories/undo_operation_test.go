@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"os"
+	"saymow/version-manager/app/pkg/fixtures"
+	"saymow/version-manager/app/repositories/directories"
+	"saymow/version-manager/app/repositories/filesystems"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+)
+
+// Run a command recorded as an operation, the repository is reloaded afterwards.
+func recordOperationHelper(t *testing.T, dir *fs.Dir, repository *Repository, command string, run func(repository *Repository)) *Repository {
+	assert.Nil(t, repository.BeginOperation(command))
+	run(repository)
+	assert.Nil(t, repository.EndOperation())
+
+	return fixtureGetRepository(t, dir.Path())
+}
+
+func TestInvalidUndoOperation(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	_, err := repository.UndoOperation()
+	assert.EqualError(t, err, "Validation Error: nothing to undo.")
+	_, err = repository.RedoOperation()
+	assert.EqualError(t, err, "Validation Error: nothing to redo.")
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	fixtures.WriteFile(dir.Join("2.txt"), []byte("2 content."))
+
+	// Check commands without changes are not recorded
+	repository = recordOperationHelper(t, dir, repository, "status", func(repository *Repository) {
+		repository.GetStatus()
+	})
+
+	_, err = repository.UndoOperation()
+	assert.EqualError(t, err, "Validation Error: nothing to undo.")
+
+	// Check changes made since the operation
+	repository = recordOperationHelper(t, dir, repository, "add 1.txt", func(repository *Repository) {
+		repository.IndexFile("1.txt")
+		repository.SaveIndex()
+	})
+	repository.IndexFile("2.txt")
+	repository.SaveIndex()
+
+	_, err = repository.UndoOperation()
+	assert.EqualError(t, err, "Validation Error: the repository changed since \"add 1.txt\".")
+
+	repository.RemoveFile("2.txt")
+	repository.SaveIndex()
+	repository = recordOperationHelper(t, dir, repository, "rm 1.txt", func(repository *Repository) {
+		repository.RemoveFile("1.txt")
+		repository.SaveIndex()
+	})
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content (v2)."))
+
+	_, err = repository.UndoOperation()
+	assert.EqualError(t, err, "Validation Error: \"1.txt\" changed since \"rm 1.txt\".")
+}
+
+func TestUndoOperation(t *testing.T) {
+	dir, repository := fixtureGetNewProject(t)
+	defer dir.Remove()
+
+	// Setup
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 content."))
+	fixtures.MakeDirs(dir.Join("a"))
+	fixtures.WriteFile(dir.Join("a", "2.txt"), []byte("2 content."))
+
+	repository = recordOperationHelper(t, dir, repository, "add .", func(repository *Repository) {
+		repository.IndexFile("1.txt")
+		repository.IndexFile("a/2.txt")
+		repository.SaveIndex()
+	})
+
+	var save *filesystems.Checkpoint
+	repository = recordOperationHelper(t, dir, repository, "save -m s0", func(repository *Repository) {
+		save, _ = repository.CreateSave("s0")
+	})
+	repository = recordOperationHelper(t, dir, repository, "ref -n feature", func(repository *Repository) {
+		repository.CreateRef("feature")
+	})
+
+	fixtures.WriteFile(dir.Join("1.txt"), []byte("1 unsaved content."))
+	fixtures.RemoveFile(dir.Join("a", "2.txt"))
+	fixtures.WriteFile(dir.Join("3.txt"), []byte("3 content."))
+
+	repository = recordOperationHelper(t, dir, repository, "restore .", func(repository *Repository) {
+		repository.Restore("HEAD", ".")
+	})
+
+	content, _ := os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1 content.")
+
+	// Check the working directory files changed by the operation are restored
+	fixtures.WriteFile(dir.Join("4.txt"), []byte("4 content."))
+
+	operation, err := repository.UndoOperation()
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Command, "restore .")
+
+	content, _ = os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1 unsaved content.")
+	assert.NoFileExists(t, dir.Join("a", "2.txt"))
+	assert.NoDirExists(t, dir.Join("a"))
+	assert.FileExists(t, dir.Join("3.txt"))
+	assert.FileExists(t, dir.Join("4.txt"))
+
+	// Only the files the operation changed are recorded
+	snapshot, _ := repository.fs.ReadSnapshot(operation.Id, filesystems.SNAPSHOT_BEFORE)
+	assert.Equal(t, len(snapshot.WorkingFiles), 3)
+
+	// Check HEAD, refs and reflogs are restored
+	repository = fixtureGetRepository(t, dir.Path())
+	operation, err = repository.UndoOperation()
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Command, "ref -n feature")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, repository.head, filesystems.INITIAL_REF_NAME)
+	assert.Equal(t, *repository.refs, filesystems.Refs{filesystems.INITIAL_REF_NAME: save.Id})
+
+	reflog, _ := repository.GetReflog("HEAD")
+	assert.Equal(t, reflog.Entries[0].Command, "undo: ref -n feature")
+
+	// Check the index is restored
+	repository.UndoOperation()
+	repository = fixtureGetRepository(t, dir.Path())
+
+	assert.Equal(t, *repository.refs, filesystems.Refs{filesystems.INITIAL_REF_NAME: ""})
+	assert.Equal(t, len(repository.index), 2)
+	assert.Equal(t, repository.findStagedChange(dir.Join("1.txt")).ChangeType, directories.Creation)
+
+	// Check undone operations are redone in order
+	operation, err = repository.RedoOperation()
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Command, "save -m s0")
+
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Equal(t, *repository.refs, filesystems.Refs{filesystems.INITIAL_REF_NAME: save.Id})
+	assert.Equal(t, len(repository.index), 0)
+
+	operation, err = repository.RedoOperation()
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Command, "ref -n feature")
+
+	// Check the snapshots objects are kept by the garbage collection
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.CollectGarbage(&GarbageCollectionOptions{})
+
+	operation, err = repository.RedoOperation()
+	assert.Nil(t, err)
+	assert.Equal(t, operation.Command, "restore .")
+
+	content, _ = os.ReadFile(dir.Join("1.txt"))
+	assert.Equal(t, string(content), "1 content.")
+	content, _ = os.ReadFile(dir.Join("a", "2.txt"))
+	assert.Equal(t, string(content), "2 content.")
+
+	_, err = repository.RedoOperation()
+	assert.EqualError(t, err, "Validation Error: nothing to redo.")
+
+	// Check a new operation drops the undone ones
+	repository = fixtureGetRepository(t, dir.Path())
+	repository.UndoOperation()
+	repository = fixtureGetRepository(t, dir.Path())
+	fixtures.WriteFile(dir.Join("5.txt"), []byte("5 content."))
+	repository = recordOperationHelper(t, dir, repository, "add 4.txt", func(repository *Repository) {
+		repository.IndexFile("4.txt")
+		repository.SaveIndex()
+	})
+
+	_, err = repository.RedoOperation()
+	assert.EqualError(t, err, "Validation Error: nothing to redo.")
+
+	// Check the files the operation did not write are kept and their contents are not recorded
+	repository.UndoOperation()
+	repository = fixtureGetRepository(t, dir.Path())
+	assert.Nil(t, repository.findStagedChange(dir.Join("4.txt")))
+	assert.FileExists(t, dir.Join("4.txt"))
+
+	objectName, _ := hashWorkingFile(dir.Join("5.txt"))
+	assert.False(t, repository.fs.ObjectExists(objectName))
+}
@@ -5,6 +5,7 @@ import (
 	"saymow/version-manager/app/repositories/filesystems"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"gotest.tools/v3/fs"
 )
 
@@ -62,6 +63,20 @@ e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
 	)
 }
 
+func fixtureCreateRepository(t *testing.T, root string) *Repository {
+	repository, err := CreateRepository(root)
+	assert.Nil(t, err)
+
+	return repository
+}
+
+func fixtureGetRepository(t *testing.T, root string) *Repository {
+	repository, err := GetRepository(root)
+	assert.Nil(t, err)
+
+	return repository
+}
+
 func fixtureGetBaseProject(t *testing.T) (*fs.Dir, *Repository) {
 	dir := fs.NewDir(
 		t,
@@ -86,7 +101,7 @@ func fixtureGetBaseProject(t *testing.T) (*fs.Dir, *Repository) {
 		),
 	)
 
-	return dir, CreateRepository(dir.Path())
+	return dir, fixtureCreateRepository(t, dir.Path())
 }
 
 func fixtureGetNewProject(t *testing.T) (*fs.Dir, *Repository) {
@@ -95,7 +110,7 @@ func fixtureGetNewProject(t *testing.T) (*fs.Dir, *Repository) {
 		"project",
 	)
 
-	return dir, CreateRepository(dir.Path())
+	return dir, fixtureCreateRepository(t, dir.Path())
 }
 
 func fixtureGetCustomProject(t *testing.T, makeRepositoryDir func(dir *fs.Dir) fs.PathOp) (*fs.Dir, *Repository) {
@@ -124,5 +139,5 @@ func fixtureGetCustomProject(t *testing.T, makeRepositoryDir func(dir *fs.Dir) f
 
 	fs.Apply(t, dir, makeRepositoryDir(dir))
 
-	return dir, GetRepository(dir.Path())
+	return dir, fixtureGetRepository(t, dir.Path())
 }
@@ -3,8 +3,10 @@ module saymow/version-manager
 go 1.23.2
 
 require (
+	github.com/alecthomas/kong v1.4.0
 	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3
 	github.com/stretchr/testify v1.9.0
+	gotest.tools/v3 v3.5.1
 )
 
 require (
@@ -13,9 +15,7 @@ require (
 )
 
 require (
-	github.com/alecthomas/kong v1.4.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	gotest.tools/v3 v3.5.1 // indirect
 )